@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/adapter/archive"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/service"
+	"marketflow/pkg/logger"
+)
+
+const archiveUsage = `Usage:
+  marketflow archive list [options]
+  marketflow archive fetch --path <path> --out <file>
+
+Commands:
+  list         List hourly Parquet files from daily manifests
+  fetch        Download archive file or manifest
+
+Options of list:
+  --exchange E     Comma separated exchanges (default: all)
+  --symbol S       Comma separated symbols (default: all)
+  --from D         First day, YYYY-MM-DD (default: six days before --to)
+  --to D           Last day, YYYY-MM-DD (default: today)
+
+Options of fetch:
+  --path P         Path from archive list
+  --out F          File to write, "-" for stdout`
+
+// runArchive lists and downloads archived ticks, returns exit code
+func runArchive(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Println(archiveUsage)
+		return 0
+	}
+
+	cmd := args[0]
+	if cmd != "list" && cmd != "fetch" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", cmd, archiveUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("archive "+cmd, flag.ContinueOnError)
+	exchangesFlag := fs.String("exchange", "", "Comma separated exchanges")
+	symbolsFlag := fs.String("symbol", "", "Comma separated symbols")
+	fromFlag := fs.String("from", "", "First day, YYYY-MM-DD")
+	toFlag := fs.String("to", "", "Last day, YYYY-MM-DD")
+	pathFlag := fs.String("path", "", "Path from archive list")
+	out := fs.String("out", "", "File to write, \"-\" for stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var filter domain.ArchiveFilter
+	switch cmd {
+	case "list":
+		var err error
+		if filter, err = parseArchiveFilter(*exchangesFlag, *symbolsFlag, *fromFlag, *toFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	case "fetch":
+		if *pathFlag == "" || *out == "" {
+			fmt.Fprintln(os.Stderr, "--path and --out are required")
+			return 2
+		}
+	}
+
+	cfg, err := config.New()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init config:", err)
+		return 1
+	}
+
+	store, err := archive.NewStore(ctx, cfg.Archive)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init archive store:", err)
+		return 1
+	}
+
+	// Staging is used only by archiving run, reading archives needs store only
+	log := logger.InitLogger(ctx, logger.LevelError)
+	archiver := service.NewArchiver(nil, store, archive.NewParquetEncoder(), cfg.Archive, cfg.Redis.HistoryDeleteDuration, log)
+
+	if cmd == "list" {
+		files, err := archiver.List(ctx, filter)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to list archives:", err)
+			return 1
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PATH\tROWS\tSIZE\tFIRST TICK\tLAST TICK")
+		for _, f := range files {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", f.Path, f.Rows, f.Size, f.FirstTick.Format(time.RFC3339), f.LastTick.Format(time.RFC3339))
+		}
+		tw.Flush()
+		return 0
+	}
+
+	r, _, err := archiver.Open(ctx, *pathFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open %s: %v\n", *pathFlag, err)
+		return 1
+	}
+	defer r.Close()
+
+	if *out == "-" {
+		_, err = io.Copy(os.Stdout, r)
+	} else {
+		err = copyToFile(*out, r)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to fetch %s: %v\n", *pathFlag, err)
+		return 1
+	}
+
+	return 0
+}
+
+// copyToFile writes reader to temporary file and renames it when copy is complete
+func copyToFile(path string, r io.Reader) error {
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp) // no-op after rename
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// parseArchiveFilter parses flags of archive list, empty lists match everything
+func parseArchiveFilter(exchanges, symbols, from, to string) (domain.ArchiveFilter, error) {
+	var filter domain.ArchiveFilter
+
+	for _, exchange := range strings.Split(exchanges, ",") {
+		if exchange = strings.TrimSpace(exchange); exchange == "" {
+			continue
+		}
+		if !types.IsValidExchange(exchange) {
+			return filter, fmt.Errorf("invalid exchange %q, available exchanges %v", exchange, types.ValidExchanges)
+		}
+		filter.Exchanges = append(filter.Exchanges, types.Exchange(exchange))
+	}
+
+	for _, symbol := range strings.Split(symbols, ",") {
+		if symbol = strings.TrimSpace(symbol); symbol == "" {
+			continue
+		}
+		if !types.IsValidSymbol(symbol) {
+			return filter, fmt.Errorf("invalid symbol %q, available symbols %v", symbol, types.ValidSymbols)
+		}
+		filter.Symbols = append(filter.Symbols, types.Symbol(symbol))
+	}
+
+	filter.To = time.Now().UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		parsed, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --to: %w", err)
+		}
+		filter.To = parsed
+	}
+
+	filter.From = filter.To.AddDate(0, 0, -6)
+	if from != "" {
+		parsed, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --from: %w", err)
+		}
+		filter.From = parsed
+	}
+
+	if filter.From.After(filter.To) {
+		return filter, fmt.Errorf("--from must not be after --to")
+	}
+
+	return filter, nil
+}
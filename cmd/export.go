@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"marketflow/config"
+	repo "marketflow/internal/adapter/postgres"
+	"marketflow/internal/adapter/redis"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/service"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/postgres"
+)
+
+const exportUsage = `Usage:
+  marketflow export <ticks|stats> --out <file> [options]
+
+Commands:
+  ticks        Ticks from recent history in Redis
+  stats        Aggregated stats from PostgreSQL
+
+Options:
+  --out F          File to write, "-" for stdout
+  --exchange E     Comma separated exchanges (default: all)
+  --symbol S       Comma separated symbols (default: all)
+  --from T         Start of range, RFC 3339 (default: hour before --to)
+  --to T           End of range, RFC 3339 (default: now)
+  --format F       csv or ndjson (default: csv)`
+
+// runExport exports historical data to file, returns exit code
+func runExport(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Println(exportUsage)
+		return 0
+	}
+
+	kind := args[0]
+	if kind != "ticks" && kind != "stats" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", kind, exportUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("export "+kind, flag.ContinueOnError)
+	out := fs.String("out", "", "File to write, \"-\" for stdout")
+	exchangesFlag := fs.String("exchange", "", "Comma separated exchanges")
+	symbolsFlag := fs.String("symbol", "", "Comma separated symbols")
+	fromFlag := fs.String("from", "", "Start of range, RFC 3339")
+	toFlag := fs.String("to", "", "End of range, RFC 3339")
+	formatFlag := fs.String("format", string(types.FormatCSV), "csv or ndjson")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "--out is required")
+		return 2
+	}
+
+	filter, err := parseExportFilter(*exchangesFlag, *symbolsFlag, *fromFlag, *toFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if !types.IsValidExportFormat(*formatFlag) {
+		fmt.Fprintf(os.Stderr, "invalid format %q, available formats %v\n", *formatFlag, types.ValidExportFormats)
+		return 2
+	}
+	format := types.ExportFormat(*formatFlag)
+
+	cfg, err := config.New()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init config:", err)
+		return 1
+	}
+
+	// Interrupted export leaves no partial file
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log := logger.InitLogger(ctx, logger.LevelError)
+
+	var export func(ctx context.Context, filter domain.ExportFilter, format types.ExportFormat, w io.Writer) (int, error)
+	switch kind {
+	case "ticks":
+		cache, err := redis.NewClient(ctx, cfg.Redis)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to connect redis:", err)
+			return 1
+		}
+		defer cache.Close()
+
+		export = service.NewExport(cache, nil, log).Ticks
+	case "stats":
+		db, err := postgres.New(ctx, cfg.Postgres)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to connect postgres:", err)
+			return 1
+		}
+		defer db.Pool.Close()
+
+		export = service.NewExport(nil, repo.NewMarketRepository(db.Pool), log).Stats
+	}
+
+	var rows int
+	if *out == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		if rows, err = export(ctx, filter, format, w); err == nil {
+			err = w.Flush()
+		}
+	} else {
+		rows, err = exportToFile(ctx, *out, filter, format, export)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to export %s: %v\n", kind, err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d %s rows from %s to %s\n", rows, kind, filter.From.Format(time.RFC3339), filter.To.Format(time.RFC3339))
+	return 0
+}
+
+// exportToFile writes export to temporary file and renames it when export is complete
+func exportToFile(ctx context.Context, path string, filter domain.ExportFilter, format types.ExportFormat,
+	export func(ctx context.Context, filter domain.ExportFilter, format types.ExportFormat, w io.Writer) (int, error),
+) (int, error) {
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp) // no-op after rename
+
+	w := bufio.NewWriter(f)
+	rows, err := export(ctx, filter, format, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return rows, err
+	}
+
+	return rows, os.Rename(tmp, path)
+}
+
+// parseExportFilter parses flags of export, empty lists match everything
+func parseExportFilter(exchanges, symbols, from, to string) (domain.ExportFilter, error) {
+	var filter domain.ExportFilter
+
+	for _, exchange := range strings.Split(exchanges, ",") {
+		if exchange = strings.TrimSpace(exchange); exchange == "" {
+			continue
+		}
+		if !types.IsValidExchange(exchange) {
+			return filter, fmt.Errorf("invalid exchange %q, available exchanges %v", exchange, types.ValidExchanges)
+		}
+		filter.Exchanges = append(filter.Exchanges, types.Exchange(exchange))
+	}
+
+	for _, symbol := range strings.Split(symbols, ",") {
+		if symbol = strings.TrimSpace(symbol); symbol == "" {
+			continue
+		}
+		if !types.IsValidSymbol(symbol) {
+			return filter, fmt.Errorf("invalid symbol %q, available symbols %v", symbol, types.ValidSymbols)
+		}
+		filter.Symbols = append(filter.Symbols, types.Symbol(symbol))
+	}
+
+	filter.To = time.Now()
+	if to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --to: %w", err)
+		}
+		filter.To = parsed
+	}
+
+	filter.From = filter.To.Add(-time.Hour)
+	if from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --from: %w", err)
+		}
+		filter.From = parsed
+	}
+
+	if !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("--from must be before --to")
+	}
+
+	return filter, nil
+}
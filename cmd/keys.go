@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"marketflow/config"
+	repo "marketflow/internal/adapter/postgres"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/service"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/postgres"
+)
+
+const keysUsage = `Usage:
+  marketflow keys create --name <name> [--scopes read,admin]
+  marketflow keys list
+  marketflow keys revoke --id <id>
+
+Commands:
+  create       Issue new API key, the key is printed only once
+  list         List API keys
+  revoke       Revoke API key
+
+Scopes:
+  read         Market data routes
+  admin        Mode switching and admin routes, implies read`
+
+// runKeys manages API keys stored in Postgres, returns exit code
+func runKeys(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Println(keysUsage)
+		return 0
+	}
+
+	cfg, err := config.New()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init config:", err)
+		return 1
+	}
+
+	db, err := postgres.New(ctx, cfg.Postgres)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect postgres:", err)
+		return 1
+	}
+	defer db.Pool.Close()
+
+	log := logger.InitLogger(ctx, logger.LevelError)
+	keys := service.NewAPIKeys(repo.NewAPIKeyRepository(db.Pool), cfg.Auth.CacheTTL, log)
+
+	switch args[0] {
+	case "create":
+		return createKey(ctx, keys, args[1:])
+	case "list":
+		return listKeys(ctx, keys)
+	case "revoke":
+		return revokeKey(ctx, keys, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", args[0], keysUsage)
+		return 2
+	}
+}
+
+func createKey(ctx context.Context, keys *service.APIKeys, args []string) int {
+	fs := flag.NewFlagSet("keys create", flag.ContinueOnError)
+	name := fs.String("name", "", "Name of the key owner")
+	scopesFlag := fs.String("scopes", string(types.ScopeRead), "Comma separated scopes")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "--name is required")
+		return 2
+	}
+
+	var scopes []types.Scope
+	for _, scope := range strings.Split(*scopesFlag, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, types.Scope(scope))
+		}
+	}
+
+	plain, key, err := keys.Create(ctx, *name, scopes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create key:", err)
+		return 1
+	}
+
+	fmt.Printf("Created key %d (%s) with scopes %v\n", key.ID, key.Name, key.Scopes)
+	fmt.Println("Store it now, it will not be shown again:")
+	fmt.Println(plain)
+	return 0
+}
+
+func listKeys(ctx context.Context, keys *service.APIKeys) int {
+	list, err := keys.List(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to list keys:", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tCREATED\tREVOKED")
+	for _, key := range list {
+		revoked := "-"
+		if key.IsRevoked() {
+			revoked = key.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s...\t%v\t%s\t%s\n", key.ID, key.Name, key.Prefix, key.Scopes, key.CreatedAt.Format(time.RFC3339), revoked)
+	}
+	w.Flush()
+
+	return 0
+}
+
+func revokeKey(ctx context.Context, keys *service.APIKeys, args []string) int {
+	fs := flag.NewFlagSet("keys revoke", flag.ContinueOnError)
+	id := fs.Int("id", 0, "ID of the key")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *id <= 0 {
+		fmt.Fprintln(os.Stderr, "--id is required")
+		return 2
+	}
+
+	if err := keys.Revoke(ctx, *id); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to revoke key:", err)
+		return 1
+	}
+
+	fmt.Printf("Revoked key %d\n", *id)
+	return 0
+}
@@ -14,19 +14,44 @@ import (
 func Run() {
 	ctx := context.Background()
 
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(runKeys(ctx, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(runExport(ctx, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "archive" {
+		os.Exit(runArchive(ctx, os.Args[2:]))
+	}
+
 	portFlag := flag.Int("port", 0, "Port number")
+	roleFlag := flag.String("role", "", "Components to run: all, ingest, aggregate, api")
 	help := flag.Bool("help", false, "Show help message")
 
 	flag.Parse()
 
 	if *help {
 		fmt.Println(`Usage:
-  marketflow [--port <N>]
+  marketflow [--port <N>] [--role <role>]
+  marketflow keys <create|list|revoke> [options]
+  marketflow export <ticks|stats> --out <file> [options]
+  marketflow archive <list|fetch> [options]
   marketflow --help
 
 Options:
   --port N     Port number
-  --help       Show help message`)
+  --role R     Components to run (default: all)
+                 all        - every component in a single process
+                 ingest     - exchange sources and collector
+                 aggregate  - stream consumer, aggregator and scheduler
+                 api        - REST API
+  --help       Show help message
+
+Subcommands:
+  keys         Manage API keys, see "marketflow keys --help"
+  export       Export historical data as CSV or NDJSON, see "marketflow export --help"
+  archive      List and download Parquet archives, see "marketflow archive --help"`)
 		os.Exit(0)
 	}
 
@@ -44,6 +69,10 @@ Options:
 		cfg.Server.HTTPServer.Port = *portFlag
 	}
 
+	if *roleFlag != "" {
+		cfg.Role = *roleFlag
+	}
+
 	// Printing the config
 	config.PrintConfig(cfg)
 
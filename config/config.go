@@ -12,10 +12,17 @@ import (
 type (
 	// Config
 	Config struct {
+		Role        string `env:"ROLE" default:"all"` // all, ingest, aggregate, api
 		Server      Server
+		Auth        Auth
+		RateLimit   RateLimit
 		Postgres    postgres.Config
 		Redis       Redis
 		DataManager DataManager
+		Archive     Archive
+		Ticks       Ticks
+		Freshness   Freshness
+		Coverage    Coverage
 	}
 
 	Test struct {
@@ -28,9 +35,41 @@ type (
 		HTTPServer HTTPServer
 	}
 
+	// API key authentication
+	Auth struct {
+		Enabled    bool          `env:"AUTH_ENABLED" default:"true"`
+		Header     string        `env:"AUTH_HEADER" default:"X-API-Key"`
+		PublicRead bool          `env:"AUTH_PUBLIC_READ" default:"true"` // market data routes do not require key
+		CacheTTL   time.Duration `env:"AUTH_CACHE_TTL" default:"30s"`    // how long verified keys are cached, revocation delay
+	}
+
+	// Token bucket per API key or client IP
+	RateLimit struct {
+		Enabled    bool    `env:"RATE_LIMIT_ENABLED" default:"true"`
+		Store      string  `env:"RATE_LIMIT_STORE" default:"memory"` // memory - per instance, redis - shared by replicas
+		Rate       float64 `env:"RATE_LIMIT_RATE" default:"10"`      // tokens per second
+		Burst      int     `env:"RATE_LIMIT_BURST" default:"20"`
+		TrustProxy bool    `env:"RATE_LIMIT_TRUST_PROXY" default:"false"` // client IP from X-Forwarded-For
+		// comma separated IPs or CIDRs of proxies in front of API, empty trusts the direct peer only
+		TrustedProxies string `env:"RATE_LIMIT_TRUSTED_PROXIES"`
+	}
+
 	// HTTP service
 	HTTPServer struct {
-		Port int `env:"HTTP_PORT" default:"8080"`
+		Port               int    `env:"HTTP_PORT" default:"8080"`
+		CORSAllowedOrigins string `env:"HTTP_CORS_ALLOWED_ORIGINS"` // comma separated, "*" allows any origin
+		Gzip               bool   `env:"HTTP_GZIP" default:"true"`
+
+		ReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"10s"`
+		ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+		WriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"30s"` // streaming handlers extend it per write
+		IdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"60s"`
+		MaxHeaderBytes    int           `env:"HTTP_MAX_HEADER_BYTES" default:"1048576"`
+		ShutdownTimeout   time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" default:"15s"` // time for in-flight requests to drain
+
+		TLSCertFile string `env:"HTTP_TLS_CERT_FILE"` // TLS is enabled if both cert and key are set
+		TLSKeyFile  string `env:"HTTP_TLS_KEY_FILE"`
+		UnixSocket  string `env:"HTTP_UNIX_SOCKET"` // path of unix socket to listen on instead of port
 	}
 
 	Redis struct {
@@ -39,26 +78,193 @@ type (
 		HistoryDeleteDuration time.Duration `env:"REDIS_HISTORY_DELETE_DURATION" default:"5m"`
 	}
 
+	// Data is stale when the last tick is older than this by its timestamp
+	Freshness struct {
+		ExchangeStaleAfter time.Duration `env:"FRESHNESS_EXCHANGE_STALE_AFTER" default:"5s"` // no ticks of any symbol
+		SymbolStaleAfter   time.Duration `env:"FRESHNESS_SYMBOL_STALE_AFTER" default:"15s"`  // no ticks of symbol, also age of stale latest price
+	}
+
+	// Detection of minutes without live data
+	Coverage struct {
+		ScanInterval   time.Duration `env:"COVERAGE_SCAN_INTERVAL" default:"5m"`
+		ScanWindow     time.Duration `env:"COVERAGE_SCAN_WINDOW" default:"1h"`       // minutes scanned by every run, overlapping runs rescan gaps
+		Settle         time.Duration `env:"COVERAGE_SETTLE" default:"2m"`            // minutes younger than this are not reported, aggregation is not done yet
+		EventRetention time.Duration `env:"COVERAGE_EVENT_RETENTION" default:"720h"` // pipeline events explaining gaps are kept this long
+	}
+
+	// Durable ingestion buffer on Redis Streams
+	Stream struct {
+		Enabled   bool          `env:"STREAM_ENABLED" default:"false"`
+		Group     string        `env:"STREAM_GROUP" default:"collectors"`
+		Consumer  string        `env:"STREAM_CONSUMER"` // hostname if empty
+		MaxLen    int64         `env:"STREAM_MAX_LEN" default:"100000"`
+		BatchSize int64         `env:"STREAM_BATCH_SIZE" default:"100"`
+		Block     time.Duration `env:"STREAM_BLOCK" default:"2s"`
+		ClaimIdle time.Duration `env:"STREAM_CLAIM_IDLE" default:"30s"`
+	}
+
 	DataManager struct {
-		Exchanges   Exchanges
-		Distributor Distributor
-		Aggregator  Aggregator
+		// Time given to old sources to drain in-flight data on mode switch and shutdown
+		DrainTimeout time.Duration `env:"DRAIN_TIMEOUT" default:"5s"`
+		// Window of recorded ticks replayed by exchanges in replay mode
+		ReplayWindow time.Duration `env:"REPLAY_WINDOW" default:"5m"`
+
+		Exchanges    Exchanges
+		Distributor  Distributor
+		Collector    Collector
+		Backpressure Backpressure
+		Feed         Feed
+		Aggregator   Aggregator
+		Stream       Stream
+		Leader       Leader
 	}
 
 	Distributor struct {
 		WorkerCount int `env:"DISTRIBUTOR_WORKER_COUNT" default:"5"`
 	}
 
-	// Exchanges config
+	// Collector writes ticks to Redis in micro-batches, each batch in single pipeline
+	Collector struct {
+		BatchSize     int           `env:"COLLECTOR_BATCH_SIZE" default:"500"`     // batch is written once it has this many ticks
+		FlushInterval time.Duration `env:"COLLECTOR_FLUSH_INTERVAL" default:"5ms"` // or when its first tick waited this long
+		MaxPending    int           `env:"COLLECTOR_MAX_PENDING" default:"5000"`   // ticks collected while batch is written, input is not read above it
+	}
+
+	// Bounded buffers between pipeline stages and policies applied when they are full:
+	// block, drop-oldest, drop-newest or conflate
+	Backpressure struct {
+		WorkersPolicy   string  `env:"BACKPRESSURE_WORKERS_POLICY" default:"block"` // ticks received from exchange waiting for workers
+		WorkersSize     int     `env:"BACKPRESSURE_WORKERS_SIZE" default:"100"`
+		ProcessedPolicy string  `env:"BACKPRESSURE_PROCESSED_POLICY" default:"block"` // validated ticks of exchange
+		ProcessedSize   int     `env:"BACKPRESSURE_PROCESSED_SIZE" default:"100"`
+		CollectorPolicy string  `env:"BACKPRESSURE_COLLECTOR_POLICY" default:"block"` // ticks of all exchanges waiting for collector
+		CollectorSize   int     `env:"BACKPRESSURE_COLLECTOR_SIZE" default:"10000"`
+		Watermark       float64 `env:"BACKPRESSURE_WATERMARK" default:"0.8"` // warning is logged when buffer is filled above this share
+	}
+
+	// Feed latency and clock skew measurement of live exchanges.
+	// Skew is estimated as the lowest receive delay of ticks within SkewWindow.
+	Feed struct {
+		SkewWindow      time.Duration `env:"FEED_SKEW_WINDOW" default:"1m"`
+		RebaseThreshold time.Duration `env:"FEED_REBASE_THRESHOLD" default:"0s"` // timestamps are replaced by receive time while skew exceeds it, 0 disables
+	}
+
+	// Exchanges config. Every exchange is read over its transport: tcp (host:port), websocket (ws:// or wss:// URL,
+	// subscribe message is sent after connecting) or http (URL polled for tickers).
+	// With TLS enabled tcp connections are encrypted and websocket and http require wss:// and https:// URLs.
+	// Feed is decoded by its decoder configured with fields mapping (symbol=s,price=data.p,timestamp=data.T),
+	// timestamp unit (auto, s, ms, us, ns, rfc3339) and symbol aliases (XBTUSDT=BTCUSDT,...)
 	Exchanges struct {
-		Exchange1Addr string `env:"EXCHANGE1_ADDR" default:"localhost:40101"`
-		Exchange2Addr string `env:"EXCHANGE2_ADDR" default:"localhost:40102"`
-		Exchange3Addr string `env:"EXCHANGE3_ADDR" default:"localhost:40103"`
+		WebSocket ExchangeWebSocket
+		Poll      ExchangePoll
+		TLS       ExchangeTLS
+
+		Exchange1Addr          string `env:"EXCHANGE1_ADDR" default:"localhost:40101"`
+		Exchange1Transport     string `env:"EXCHANGE1_TRANSPORT" default:"tcp"`
+		Exchange1Subscribe     string `env:"EXCHANGE1_SUBSCRIBE"`
+		Exchange1TLS           bool   `env:"EXCHANGE1_TLS" default:"false"`
+		Exchange1TLSCAFile     string `env:"EXCHANGE1_TLS_CA_FILE"`   // system roots if empty
+		Exchange1TLSCertFile   string `env:"EXCHANGE1_TLS_CERT_FILE"` // client certificate and key for mTLS
+		Exchange1TLSKeyFile    string `env:"EXCHANGE1_TLS_KEY_FILE"`
+		Exchange1TLSServerName string `env:"EXCHANGE1_TLS_SERVER_NAME"` // host of address if empty
+		Exchange1TLSMinVersion string `env:"EXCHANGE1_TLS_MIN_VERSION" default:"1.2"`
+		Exchange1Decoder       string `env:"EXCHANGE1_DECODER" default:"json"`
+		Exchange1Fields        string `env:"EXCHANGE1_FIELDS"`
+		Exchange1TimestampUnit string `env:"EXCHANGE1_TIMESTAMP_UNIT" default:"auto"`
+		Exchange1SymbolAliases string `env:"EXCHANGE1_SYMBOL_ALIASES"`
+
+		Exchange2Addr          string `env:"EXCHANGE2_ADDR" default:"localhost:40102"`
+		Exchange2Transport     string `env:"EXCHANGE2_TRANSPORT" default:"tcp"`
+		Exchange2Subscribe     string `env:"EXCHANGE2_SUBSCRIBE"`
+		Exchange2TLS           bool   `env:"EXCHANGE2_TLS" default:"false"`
+		Exchange2TLSCAFile     string `env:"EXCHANGE2_TLS_CA_FILE"`   // system roots if empty
+		Exchange2TLSCertFile   string `env:"EXCHANGE2_TLS_CERT_FILE"` // client certificate and key for mTLS
+		Exchange2TLSKeyFile    string `env:"EXCHANGE2_TLS_KEY_FILE"`
+		Exchange2TLSServerName string `env:"EXCHANGE2_TLS_SERVER_NAME"` // host of address if empty
+		Exchange2TLSMinVersion string `env:"EXCHANGE2_TLS_MIN_VERSION" default:"1.2"`
+		Exchange2Decoder       string `env:"EXCHANGE2_DECODER" default:"json"`
+		Exchange2Fields        string `env:"EXCHANGE2_FIELDS"`
+		Exchange2TimestampUnit string `env:"EXCHANGE2_TIMESTAMP_UNIT" default:"auto"`
+		Exchange2SymbolAliases string `env:"EXCHANGE2_SYMBOL_ALIASES"`
+
+		Exchange3Addr          string `env:"EXCHANGE3_ADDR" default:"localhost:40103"`
+		Exchange3Transport     string `env:"EXCHANGE3_TRANSPORT" default:"tcp"`
+		Exchange3Subscribe     string `env:"EXCHANGE3_SUBSCRIBE"`
+		Exchange3TLS           bool   `env:"EXCHANGE3_TLS" default:"false"`
+		Exchange3TLSCAFile     string `env:"EXCHANGE3_TLS_CA_FILE"`   // system roots if empty
+		Exchange3TLSCertFile   string `env:"EXCHANGE3_TLS_CERT_FILE"` // client certificate and key for mTLS
+		Exchange3TLSKeyFile    string `env:"EXCHANGE3_TLS_KEY_FILE"`
+		Exchange3TLSServerName string `env:"EXCHANGE3_TLS_SERVER_NAME"` // host of address if empty
+		Exchange3TLSMinVersion string `env:"EXCHANGE3_TLS_MIN_VERSION" default:"1.2"`
+		Exchange3Decoder       string `env:"EXCHANGE3_DECODER" default:"json"`
+		Exchange3Fields        string `env:"EXCHANGE3_FIELDS"`
+		Exchange3TimestampUnit string `env:"EXCHANGE3_TIMESTAMP_UNIT" default:"auto"`
+		Exchange3SymbolAliases string `env:"EXCHANGE3_SYMBOL_ALIASES"`
+	}
+
+	// WebSocket exchange connections are pinged every PingInterval and reconnected if nothing,
+	// not even pong, was received for PingInterval + PongTimeout. HandshakeTimeout limits opening handshake
+	ExchangeWebSocket struct {
+		PingInterval     time.Duration `env:"EXCHANGE_WS_PING_INTERVAL" default:"15s"`
+		PongTimeout      time.Duration `env:"EXCHANGE_WS_PONG_TIMEOUT" default:"10s"`
+		HandshakeTimeout time.Duration `env:"EXCHANGE_WS_HANDSHAKE_TIMEOUT" default:"10s"`
+		ReconnectMin     time.Duration `env:"EXCHANGE_WS_RECONNECT_MIN" default:"1s"` // reconnect delay doubles up to max
+		ReconnectMax     time.Duration `env:"EXCHANGE_WS_RECONNECT_MAX" default:"30s"`
+	}
+
+	// HTTP exchanges are polled every Interval, rate limited responses postpone the next poll
+	ExchangePoll struct {
+		Interval time.Duration `env:"EXCHANGE_POLL_INTERVAL" default:"1s"`
+		Timeout  time.Duration `env:"EXCHANGE_POLL_TIMEOUT" default:"5s"`
+	}
+
+	// TLS files of exchanges are checked for changes every ReloadInterval and reloaded,
+	// certificates expiring within ExpiryWarning are reported in health
+	ExchangeTLS struct {
+		ReloadInterval time.Duration `env:"EXCHANGE_TLS_RELOAD_INTERVAL" default:"30s"`
+		ExpiryWarning  time.Duration `env:"EXCHANGE_TLS_EXPIRY_WARNING" default:"720h"`
+	}
+
+	// Leader election for singleton duties (aggregator, scheduler)
+	Leader struct {
+		Key      string        `env:"LEADER_KEY" default:"marketflow:leader"`
+		ID       string        `env:"LEADER_ID"` // hostname-pid if empty
+		LeaseTTL time.Duration `env:"LEADER_LEASE_TTL" default:"10s"`
 	}
 
 	Aggregator struct {
 		TickerDuration time.Duration `env:"AGGREGATOR_TICKER_DURATION" default:"1m"`
 	}
+
+	// Archive of raw ticks in hourly Parquet files
+	Archive struct {
+		Enabled  bool          `env:"ARCHIVE_ENABLED" default:"false"`
+		Interval time.Duration `env:"ARCHIVE_INTERVAL" default:"1m"`   // must be shorter than Redis history retention
+		Delay    time.Duration `env:"ARCHIVE_DELAY" default:"10s"`     // ticks younger than delay are archived by next run
+		Storage  string        `env:"ARCHIVE_STORAGE" default:"local"` // local or s3
+		Dir      string        `env:"ARCHIVE_DIR" default:"./archive"` // used by local storage
+		S3       ArchiveS3
+	}
+
+	// Raw ticks in partitioned Postgres table for audit
+	Ticks struct {
+		Enabled       bool          `env:"TICKS_ENABLED" default:"false"`
+		BatchSize     int           `env:"TICKS_BATCH_SIZE" default:"1000"`    // ticks written by single COPY
+		FlushInterval time.Duration `env:"TICKS_FLUSH_INTERVAL" default:"1s"`  // partial batch is written after interval
+		BufferSize    int           `env:"TICKS_BUFFER_SIZE" default:"100000"` // ticks are dropped when buffer is full
+		Retention     time.Duration `env:"TICKS_RETENTION" default:"720h"`     // older daily partitions are dropped, 0 keeps all
+		MaxRetries    int           `env:"TICKS_MAX_RETRIES" default:"5"`      // failed batch is split after retries, failing ticks are discarded
+	}
+
+	// S3 compatible storage of archive, e.g. MinIO
+	ArchiveS3 struct {
+		Endpoint  string `env:"ARCHIVE_S3_ENDPOINT"` // host:port
+		Bucket    string `env:"ARCHIVE_S3_BUCKET" default:"marketflow-archive"`
+		AccessKey string `env:"ARCHIVE_S3_ACCESS_KEY"`
+		SecretKey string `env:"ARCHIVE_S3_SECRET_KEY"`
+		Region    string `env:"ARCHIVE_S3_REGION"`
+		UseSSL    bool   `env:"ARCHIVE_S3_USE_SSL" default:"true"`
+	}
 )
 
 func New() (Config, error) {
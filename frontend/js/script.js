@@ -28,27 +28,39 @@ async function fetchLatestPrices() {
     const symbols = ["BTCUSDT", "DOGEUSDT", "TONUSDT", "SOLUSDT", "ETHUSDT"];
     const exchanges = ["exchange1", "exchange2", "exchange3"];
     const container = document.getElementById("latest-prices");
-    container.innerHTML = "";
 
+    const queries = [];
     for (const exchange of exchanges) {
         for (const symbol of symbols) {
-            try {
-                const res = await fetch(`/prices/latest/${exchange}/${symbol}`);
-                if (!res.ok) continue;
-                const data = await res.json();
-                const entry = document.createElement("div");
-                entry.className = "price-entry";
-                entry.innerHTML = `
-                    <strong>${data.data.symbol}</strong> | ${data.data.exchange} — 
-                    $${data.data.price.toFixed(2)} <br>
-                    <small>${new Date(data.data.timestamp).toLocaleString()}</small>
-                `;
-                container.appendChild(entry);
-            } catch (err) {
-                console.error(`Failed for ${exchange}/${symbol}:`, err);
-            }
+            queries.push({ metric: "latest", exchange, symbol });
         }
     }
+
+    try {
+        const res = await fetch("/prices/batch", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ queries }),
+        });
+        if (!res.ok) return;
+        const { data: results } = await res.json();
+
+        container.innerHTML = "";
+        for (const result of results) {
+            if (!result.data) continue;
+            const data = result.data;
+            const entry = document.createElement("div");
+            entry.className = "price-entry";
+            entry.innerHTML = `
+                <strong>${data.symbol}</strong> | ${data.exchange} — 
+                $${data.price.toFixed(2)} <br>
+                <small>${new Date(data.timestamp).toLocaleString()}</small>
+            `;
+            container.appendChild(entry);
+        }
+    } catch (err) {
+        console.error("Failed to fetch latest prices:", err);
+    }
 }
 
 async function fetchAggregated() {
@@ -0,0 +1,107 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"marketflow/internal/domain"
+)
+
+// LocalStore keeps archive files in directory on local disk
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates archive directory if it does not exist
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// Put writes file atomically, readers never see partially written file
+func (s *LocalStore) Put(ctx context.Context, path string, data []byte, contentType string) error {
+	root, err := os.OpenRoot(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to open archive directory: %w", err)
+	}
+	defer root.Close()
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := mkdirAll(root, dir); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	tmp := path + ".tmp"
+	f, err := root.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		root.Remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		root.Remove(tmp)
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	// os.Root has no rename, paths are already checked to stay inside the root
+	if err := os.Rename(filepath.Join(s.dir, tmp), filepath.Join(s.dir, path)); err != nil {
+		root.Remove(tmp)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+
+	return nil
+}
+
+// Get opens regular file, directories and other files are not found, paths escaping archive directory are rejected
+func (s *LocalStore) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	root, err := os.OpenRoot(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive directory: %w", err)
+	}
+	defer root.Close()
+
+	f, err := root.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, domain.ErrNotFound
+	}
+
+	return f, nil
+}
+
+// mkdirAll creates directory with parents inside root
+func mkdirAll(root *os.Root, dir string) error {
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	if err := mkdirAll(root, filepath.Dir(dir)); err != nil {
+		return err
+	}
+	if err := root.Mkdir(dir, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"marketflow/internal/domain"
+)
+
+func readAll(t *testing.T, store *LocalStore, path string) string {
+	t.Helper()
+	r, err := store.Get(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLocalStorePutGet(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(dir, "archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := "date=2025-01-02/exchange=exchange1/symbol=BTCUSDT/15.parquet"
+	if err := store.Put(ctx, path, []byte("first"), "application/vnd.apache.parquet"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, store, path); got != "first" {
+		t.Fatalf("got %q", got)
+	}
+
+	// file is replaced, temporary file is not left behind
+	if err := store.Put(ctx, path, []byte("second"), "application/vnd.apache.parquet"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, store, path); got != "second" {
+		t.Fatalf("got %q after replace", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "archive", path+".tmp")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("temporary file is left, stat error %v", err)
+	}
+}
+
+func TestLocalStoreGetNotFound(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "date=2025-01-02/manifest.json", []byte("{}"), "application/json"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"date=2025-01-03/manifest.json", "date=2025-01-02", "."} {
+		if _, err := store.Get(ctx, path); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want not found", path, err)
+		}
+	}
+
+	if _, err := store.Get(ctx, "../outside"); err == nil {
+		t.Fatal("path escaping archive directory must fail")
+	}
+	if err := store.Put(ctx, "../outside", []byte("x"), "text/plain"); err == nil {
+		t.Fatal("put escaping archive directory must fail")
+	}
+}
@@ -0,0 +1,59 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+
+	"marketflow/internal/domain"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// parquetTick is a row of archive file
+type parquetTick struct {
+	Exchange  string  `parquet:"exchange,dict"`
+	Symbol    string  `parquet:"symbol,dict"`
+	Timestamp int64   `parquet:"timestamp,timestamp(millisecond)"`
+	Price     float64 `parquet:"price"`
+	Source    string  `parquet:"source,dict"`
+}
+
+// ParquetEncoder encodes ticks into zstd compressed Parquet file
+type ParquetEncoder struct{}
+
+func NewParquetEncoder() *ParquetEncoder {
+	return &ParquetEncoder{}
+}
+
+// Encode writes ticks as single Parquet file
+func (e *ParquetEncoder) Encode(w io.Writer, ticks []*domain.PriceData) error {
+	rows := make([]parquetTick, len(ticks))
+	for i, t := range ticks {
+		rows[i] = parquetTick{
+			Exchange:  string(t.Exchange),
+			Symbol:    string(t.Symbol),
+			Timestamp: t.Timestamp.UnixMilli(),
+			Price:     t.Price,
+			Source:    string(t.Source),
+		}
+	}
+
+	pw := parquet.NewGenericWriter[parquetTick](w, parquet.Compression(&zstd.Codec{}))
+	if _, err := pw.Write(rows); err != nil {
+		return fmt.Errorf("failed to write parquet rows: %w", err)
+	}
+	if err := pw.Close(); err != nil {
+		return fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+
+	return nil
+}
+
+func (e *ParquetEncoder) Extension() string {
+	return ".parquet"
+}
+
+func (e *ParquetEncoder) ContentType() string {
+	return "application/vnd.apache.parquet"
+}
@@ -0,0 +1,39 @@
+package archive
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetEncoder(t *testing.T) {
+	at := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	ticks := []*domain.PriceData{
+		{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: 100.5, Timestamp: at, Source: types.SourceLive},
+		{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: 101, Timestamp: at.Add(1500 * time.Millisecond), Source: types.SourceLive},
+	}
+
+	var buf bytes.Buffer
+	if err := NewParquetEncoder().Encode(&buf, ticks); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := parquet.Read[parquetTick](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(ticks) {
+		t.Fatalf("read %d rows, want %d", len(rows), len(ticks))
+	}
+	for i, row := range rows {
+		want := parquetTick{Exchange: "exchange1", Symbol: "BTCUSDT", Timestamp: ticks[i].Timestamp.UnixMilli(), Price: ticks[i].Price, Source: string(types.SourceLive)}
+		if row != want {
+			t.Fatalf("row %d = %+v, want %+v", i, row, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store keeps archive files in bucket of S3 compatible storage
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store connects to storage and creates bucket if it does not exist
+func NewS3Store(ctx context.Context, cfg config.ArchiveS3) (*S3Store, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("S3 endpoint is not set")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+
+	return &S3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Put uploads object, S3 objects are replaced atomically
+func (s *S3Store) Put(ctx context.Context, path string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, path, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", path, err)
+	}
+	return nil
+}
+
+// Get downloads object
+func (s *S3Store) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	// GetObject does not report missing object until it is read
+	if _, err := s.client.StatObject(ctx, s.bucket, path, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, path, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path, err)
+	}
+	return obj, nil
+}
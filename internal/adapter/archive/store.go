@@ -0,0 +1,21 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+
+	"marketflow/config"
+	"marketflow/internal/ports"
+)
+
+// NewStore creates archive store selected by config
+func NewStore(ctx context.Context, cfg config.Archive) (ports.ArchiveStore, error) {
+	switch cfg.Storage {
+	case "local":
+		return NewLocalStore(cfg.Dir)
+	case "s3":
+		return NewS3Store(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("invalid archive storage %q, available storages [local s3]", cfg.Storage)
+	}
+}
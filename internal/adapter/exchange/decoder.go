@@ -0,0 +1,357 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+)
+
+// Decoder turns one line of exchange feed into tick. Exchange, source and receive time are set by the caller.
+type Decoder interface {
+	Decode(line []byte) (*domain.PriceData, error)
+}
+
+// DecoderFactory creates decoder of the exchange from its configuration
+type DecoderFactory func(cfg DecoderConfig) (Decoder, error)
+
+// DecoderConfig describes feed format of the exchange
+type DecoderConfig struct {
+	Exchange types.Exchange
+	Decoder  string // name of registered decoder
+
+	SymbolField    string // dot separated path in JSON object, e.g. data.s
+	PriceField     string
+	TimestampField string
+	TimestampUnit  string // auto, s, ms, us, ns or rfc3339
+
+	Aliases map[string]types.Symbol // canonical spelling of exchange symbol to our symbol
+}
+
+// Timestamp units of numeric timestamps, auto detects unit of numbers by magnitude and reads strings as RFC3339
+const (
+	UnitAuto    = "auto"
+	UnitSeconds = "s"
+	UnitMillis  = "ms"
+	UnitMicros  = "us"
+	UnitNanos   = "ns"
+	UnitRFC3339 = "rfc3339"
+)
+
+var timestampUnits = []string{UnitAuto, UnitSeconds, UnitMillis, UnitMicros, UnitNanos, UnitRFC3339}
+
+const DefaultDecoder = "json"
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]DecoderFactory{
+		DefaultDecoder: NewJSONDecoder,
+	}
+)
+
+// RegisterDecoder makes decoder available by name for EXCHANGE<N>_DECODER, it replaces decoder with the same name
+func RegisterDecoder(name string, factory DecoderFactory) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[name] = factory
+}
+
+// NewDecoder creates decoder registered under configured name
+func NewDecoder(cfg DecoderConfig) (Decoder, error) {
+	decodersMu.RLock()
+	factory, ok := decoders[cfg.Decoder]
+	decodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown decoder %q of %s", cfg.Decoder, cfg.Exchange)
+	}
+
+	decoder, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create decoder of %s: %w", cfg.Exchange, err)
+	}
+	return decoder, nil
+}
+
+// NewDecoders creates decoders of all exchanges from config
+func NewDecoders(cfg config.Exchanges) (map[types.Exchange]Decoder, error) {
+	decoders := make(map[types.Exchange]Decoder, len(types.ValidExchanges))
+	for _, exchange := range types.ValidExchanges {
+		decoderCfg, err := DecoderConfigOf(exchange, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		decoder, err := NewDecoder(decoderCfg)
+		if err != nil {
+			return nil, err
+		}
+		decoders[exchange] = decoder
+	}
+	return decoders, nil
+}
+
+// DecoderConfigOf parses decoder configuration of the exchange
+func DecoderConfigOf(exchange types.Exchange, cfg config.Exchanges) (DecoderConfig, error) {
+	var name, fields, unit, aliases string
+	switch exchange {
+	case types.Exchange1:
+		name, fields, unit, aliases = cfg.Exchange1Decoder, cfg.Exchange1Fields, cfg.Exchange1TimestampUnit, cfg.Exchange1SymbolAliases
+	case types.Exchange2:
+		name, fields, unit, aliases = cfg.Exchange2Decoder, cfg.Exchange2Fields, cfg.Exchange2TimestampUnit, cfg.Exchange2SymbolAliases
+	case types.Exchange3:
+		name, fields, unit, aliases = cfg.Exchange3Decoder, cfg.Exchange3Fields, cfg.Exchange3TimestampUnit, cfg.Exchange3SymbolAliases
+	default:
+		return DecoderConfig{}, fmt.Errorf("%w: %s", domain.ErrInvalidExchange, exchange)
+	}
+
+	decoderCfg := DecoderConfig{
+		Exchange:       exchange,
+		Decoder:        name,
+		SymbolField:    "symbol",
+		PriceField:     "price",
+		TimestampField: "timestamp",
+		TimestampUnit:  unit,
+		Aliases:        make(map[string]types.Symbol),
+	}
+	if decoderCfg.Decoder == "" {
+		decoderCfg.Decoder = DefaultDecoder
+	}
+	if decoderCfg.TimestampUnit == "" {
+		decoderCfg.TimestampUnit = UnitAuto
+	}
+
+	for field, path := range pairs(fields) {
+		switch field {
+		case "symbol":
+			decoderCfg.SymbolField = path
+		case "price":
+			decoderCfg.PriceField = path
+		case "timestamp":
+			decoderCfg.TimestampField = path
+		default:
+			return DecoderConfig{}, fmt.Errorf("unknown field %q in fields of %s, available fields symbol, price, timestamp", field, exchange)
+		}
+	}
+
+	for alias, symbol := range pairs(aliases) {
+		if !types.IsValidSymbol(symbol) {
+			return DecoderConfig{}, fmt.Errorf("alias %q of %s: %w: %s", alias, exchange, domain.ErrInvalidSymbol, symbol)
+		}
+		decoderCfg.Aliases[canonicalSymbol(alias)] = types.Symbol(symbol)
+	}
+
+	return decoderCfg, nil
+}
+
+// pairs parses comma separated key=value list, malformed entries are returned with empty value
+func pairs(s string) map[string]string {
+	result := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(entry, "=")
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// canonicalSymbol returns symbol in upper case without separators, so BTC-USDT, btc_usdt and BTC/USDT are BTCUSDT
+func canonicalSymbol(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', '/', ':', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(s))
+}
+
+// JSONDecoder decodes JSON object per line with fields at configured paths.
+// Prices may be numbers or strings, symbols are normalized and mapped by aliases.
+type JSONDecoder struct {
+	symbol    []string
+	price     []string
+	timestamp []string
+	unit      string
+	aliases   map[string]types.Symbol
+}
+
+// NewJSONDecoder creates JSON decoder, default configuration reads {"symbol","price","timestamp"} objects
+func NewJSONDecoder(cfg DecoderConfig) (Decoder, error) {
+	if !slices.Contains(timestampUnits, cfg.TimestampUnit) {
+		return nil, fmt.Errorf("invalid timestamp unit %q, available units %v", cfg.TimestampUnit, timestampUnits)
+	}
+	for _, path := range []string{cfg.SymbolField, cfg.PriceField, cfg.TimestampField} {
+		if path == "" || slices.Contains(strings.Split(path, "."), "") {
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+	}
+
+	return &JSONDecoder{
+		symbol:    strings.Split(cfg.SymbolField, "."),
+		price:     strings.Split(cfg.PriceField, "."),
+		timestamp: strings.Split(cfg.TimestampField, "."),
+		unit:      cfg.TimestampUnit,
+		aliases:   cfg.Aliases,
+	}, nil
+}
+
+func (d *JSONDecoder) Decode(line []byte) (*domain.PriceData, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var object map[string]any
+	if err := dec.Decode(&object); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	symbol, err := d.decodeSymbol(lookup(object, d.symbol))
+	if err != nil {
+		return nil, err
+	}
+	price, err := decodePrice(lookup(object, d.price))
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := d.decodeTimestamp(lookup(object, d.timestamp))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PriceData{
+		Symbol:    symbol,
+		Price:     price,
+		Timestamp: timestamp,
+	}, nil
+}
+
+// lookup returns value at path of nested objects, nil if there is none
+func lookup(object map[string]any, path []string) any {
+	var value any = object
+	for _, key := range path {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = nested[key]
+	}
+	return value
+}
+
+func (d *JSONDecoder) decodeSymbol(v any) (types.Symbol, error) {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidSymbol, v)
+	}
+
+	canonical := canonicalSymbol(s)
+	if symbol, ok := d.aliases[canonical]; ok {
+		return symbol, nil
+	}
+	return types.Symbol(canonical), nil
+}
+
+// decodePrice accepts numbers and numeric strings
+func decodePrice(v any) (float64, error) {
+	var (
+		price float64
+		err   error
+	)
+	switch v := v.(type) {
+	case json.Number:
+		price, err = v.Float64()
+	case string:
+		price, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		return 0, fmt.Errorf("unsupported price type: %T", v)
+	}
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0, fmt.Errorf("invalid price %v", v)
+	}
+	return price, nil
+}
+
+// decodeTimestamp reads numbers and numeric strings in configured unit, or RFC3339 strings
+func (d *JSONDecoder) decodeTimestamp(v any) (time.Time, error) {
+	var number json.Number
+	switch v := v.(type) {
+	case json.Number:
+		if d.unit == UnitRFC3339 {
+			return time.Time{}, fmt.Errorf("numeric timestamp %s, RFC3339 string expected", v)
+		}
+		number = v
+	case string:
+		if d.unit == UnitAuto || d.unit == UnitRFC3339 {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid timestamp format: %w", err)
+			}
+			return t, nil
+		}
+		number = json.Number(strings.TrimSpace(v))
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp type: %T", v)
+	}
+
+	unit := time.Millisecond
+	switch d.unit {
+	case UnitAuto:
+		unit = autoUnit(number)
+	case UnitSeconds:
+		unit = time.Second
+	case UnitMicros:
+		unit = time.Microsecond
+	case UnitNanos:
+		unit = time.Nanosecond
+	}
+
+	// integers are exact, fractions are accepted for seconds and milliseconds
+	if n, err := number.Int64(); err == nil {
+		if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+			return time.Time{}, fmt.Errorf("timestamp %d out of range", n)
+		}
+		return time.Unix(0, n*int64(unit)), nil
+	}
+	f, err := number.Float64()
+	if err != nil || math.IsNaN(f) || math.Abs(f*float64(unit)) > math.MaxInt64 {
+		return time.Time{}, fmt.Errorf("invalid numeric timestamp %q", number)
+	}
+	return time.Unix(0, int64(f*float64(unit))), nil
+}
+
+// Upper bounds of magnitude of timestamps detected as seconds, milliseconds and microseconds.
+// 1e11 seconds is year 5138 and 1e11 milliseconds is March 1973, so current times of every unit are apart.
+const (
+	autoMaxSeconds = 1e11
+	autoMaxMillis  = 1e14
+	autoMaxMicros  = 1e17
+)
+
+// autoUnit detects unit of numeric timestamp by its magnitude, invalid numbers are left to conversion
+func autoUnit(number json.Number) time.Duration {
+	f, err := number.Float64()
+	if err != nil {
+		return time.Millisecond
+	}
+	switch f = math.Abs(f); {
+	case f < autoMaxSeconds:
+		return time.Second
+	case f < autoMaxMillis:
+		return time.Millisecond
+	case f < autoMaxMicros:
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain/types"
+)
+
+func TestJSONDecoder(t *testing.T) {
+	at := time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC) // 1700000000 seconds
+
+	for _, tc := range []struct {
+		name    string
+		fields  string
+		unit    string
+		aliases string
+		line    string
+		symbol  types.Symbol
+		price   float64
+		at      time.Time
+	}{
+		{"default fields", "", UnitAuto, "", `{"symbol":"BTCUSDT","price":100.5,"timestamp":1700000000000}`, types.BTCUSDT, 100.5, at},
+		{"dot paths", "symbol=data.s,price=data.p,timestamp=data.T", UnitMillis, "", `{"data":{"s":"ETHUSDT","p":10,"T":1700000000000}}`, types.ETHUSDT, 10, at},
+		{"string price", "", UnitMillis, "", `{"symbol":"BTCUSDT","price":" 100.25 ","timestamp":1700000000000}`, types.BTCUSDT, 100.25, at},
+		{"separators are normalized", "", UnitMillis, "", `{"symbol":"btc-usdt","price":1,"timestamp":1700000000000}`, types.BTCUSDT, 1, at},
+		{"alias", "", UnitMillis, "XBT/USDT=BTCUSDT", `{"symbol":"xbt_usdt","price":1,"timestamp":1700000000000}`, types.BTCUSDT, 1, at},
+		{"seconds", "", UnitSeconds, "", `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000.5}`, types.BTCUSDT, 1, at.Add(500 * time.Millisecond)},
+		{"milliseconds", "", UnitMillis, "", `{"symbol":"BTCUSDT","price":1,"timestamp":"1700000000000"}`, types.BTCUSDT, 1, at},
+		{"microseconds", "", UnitMicros, "", `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000000001}`, types.BTCUSDT, 1, at.Add(time.Microsecond)},
+		{"nanoseconds", "", UnitNanos, "", `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000000000001}`, types.BTCUSDT, 1, at.Add(time.Nanosecond)},
+		{"rfc3339", "", UnitRFC3339, "", `{"symbol":"BTCUSDT","price":1,"timestamp":"2023-11-14T22:13:20Z"}`, types.BTCUSDT, 1, at},
+		{"auto seconds", "", UnitAuto, "", `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000}`, types.BTCUSDT, 1, at},
+		{"auto milliseconds", "", UnitAuto, "", `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000000}`, types.BTCUSDT, 1, at},
+		{"auto microseconds", "", UnitAuto, "", `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000000000}`, types.BTCUSDT, 1, at},
+		{"auto nanoseconds", "", UnitAuto, "", `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000000000000}`, types.BTCUSDT, 1, at},
+		{"auto rfc3339", "", UnitAuto, "", `{"symbol":"BTCUSDT","price":1,"timestamp":"2023-11-15T01:13:20+03:00"}`, types.BTCUSDT, 1, at},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := DecoderConfigOf(types.Exchange1, config.Exchanges{Exchange1Fields: tc.fields, Exchange1TimestampUnit: tc.unit, Exchange1SymbolAliases: tc.aliases})
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoder, err := NewDecoder(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := decoder.Decode([]byte(tc.line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Symbol != tc.symbol || p.Price != tc.price || !p.Timestamp.Equal(tc.at) {
+				t.Fatalf("got %s %g at %v, want %s %g at %v", p.Symbol, p.Price, p.Timestamp.UTC(), tc.symbol, tc.price, tc.at)
+			}
+		})
+	}
+}
+
+func TestJSONDecoderRejects(t *testing.T) {
+	for _, tc := range []struct {
+		name, unit, line string
+	}{
+		{"not JSON", UnitAuto, `symbol=BTCUSDT`},
+		{"missing path", UnitAuto, `{"symbol":"BTCUSDT","price":1}`},
+		{"empty symbol", UnitAuto, `{"symbol":"","price":1,"timestamp":1700000000}`},
+		{"price is not number", UnitAuto, `{"symbol":"BTCUSDT","price":"high","timestamp":1700000000}`},
+		{"price is NaN", UnitAuto, `{"symbol":"BTCUSDT","price":"NaN","timestamp":1700000000}`},
+		{"number for rfc3339", UnitRFC3339, `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000}`},
+		{"numeric string for auto", UnitAuto, `{"symbol":"BTCUSDT","price":1,"timestamp":"1700000000"}`},
+		{"out of range", UnitSeconds, `{"symbol":"BTCUSDT","price":1,"timestamp":1700000000000000}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			decoder, err := NewJSONDecoder(DecoderConfig{SymbolField: "symbol", PriceField: "price", TimestampField: "timestamp", TimestampUnit: tc.unit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p, err := decoder.Decode([]byte(tc.line)); err == nil {
+				t.Fatalf("decoded %+v, want error", p)
+			}
+		})
+	}
+
+	if _, err := DecoderConfigOf(types.Exchange1, config.Exchanges{Exchange1Fields: "volume=v"}); err == nil {
+		t.Fatal("unknown field must fail")
+	}
+	if _, err := DecoderConfigOf(types.Exchange1, config.Exchanges{Exchange1SymbolAliases: "XBT=XBTUSDT"}); err == nil {
+		t.Fatal("alias of unknown symbol must fail")
+	}
+	if _, err := NewJSONDecoder(DecoderConfig{SymbolField: "data..s", PriceField: "p", TimestampField: "T", TimestampUnit: UnitAuto}); err == nil {
+		t.Fatal("empty path segment must fail")
+	}
+}
@@ -3,7 +3,7 @@ package exchange
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"time"
@@ -20,14 +20,19 @@ type Exchange struct {
 	conn   net.Conn
 	cancel context.CancelFunc
 
+	decoder Decoder
+	tls     *TLSConfig // nil for plain connection
+
 	log logger.Logger
 }
 
-// NewExchange creates new instance of Exchange
-func NewExchange(name types.Exchange, connAddr string, log logger.Logger) *Exchange {
+// NewExchange creates new instance of Exchange reading feed lines with given decoder, over TLS if config is set
+func NewExchange(name types.Exchange, connAddr string, decoder Decoder, tlsConfig *TLSConfig, log logger.Logger) *Exchange {
 	return &Exchange{
-		name: name,
-		Addr: connAddr,
+		name:    name,
+		Addr:    connAddr,
+		decoder: decoder,
+		tls:     tlsConfig,
 
 		log: log,
 	}
@@ -39,7 +44,7 @@ func (e *Exchange) Start(ctx context.Context) (<-chan *domain.PriceData, error)
 	ctx, cancel := context.WithCancel(ctx)
 	e.cancel = cancel
 
-	conn, err := net.Dial("tcp", e.Addr)
+	conn, err := e.dial(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
@@ -56,15 +61,17 @@ func (e *Exchange) Start(ctx context.Context) (<-chan *domain.PriceData, error)
 
 		scanner := bufio.NewScanner(conn)
 		for scanner.Scan() {
+			receivedAt := time.Now()
 			line := scanner.Bytes()
 
-			// Mapping to struct
-			data := new(domain.PriceData)
-			if err := json.Unmarshal(line, data); err != nil {
-				log.ErrorContext(ctx, "failed to parse JSON", "error", err)
+			data, err := e.decoder.Decode(line)
+			if err != nil {
+				log.ErrorContext(ctx, "failed to decode tick", "error", err)
 				continue
 			}
 			data.Exchange = e.name
+			data.Source = types.SourceLive
+			data.ReceivedAt = receivedAt
 
 			select {
 			case out <- data:
@@ -82,6 +89,14 @@ func (e *Exchange) Start(ctx context.Context) (<-chan *domain.PriceData, error)
 	return out, nil
 }
 
+// dial connects to exchange with current TLS config if it is set
+func (e *Exchange) dial(ctx context.Context) (net.Conn, error) {
+	if e.tls != nil {
+		return dialTLS(ctx, e.Addr, e.tls)
+	}
+	return (&net.Dialer{}).DialContext(ctx, "tcp", e.Addr)
+}
+
 // Stop closes the connection
 func (e *Exchange) Close() error {
 	const fn = "exchange.Stop"
@@ -93,7 +108,8 @@ func (e *Exchange) Close() error {
 
 	log.Info("closing connection")
 	if e.conn != nil {
-		if err := e.conn.Close(); err != nil {
+		// connection is already closed if exchange closed the feed
+		if err := e.conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 			log.Error("failed to close connection", "error", err)
 			return err
 		}
@@ -107,7 +123,10 @@ func (e *Exchange) Name() string {
 
 // Health checks if the exchange service is available by attempting a connection
 func (e *Exchange) Health(ctx context.Context) (bool, error) {
-	conn, err := net.DialTimeout("tcp", e.Addr, 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	conn, err := e.dial(ctx)
 	if err != nil {
 		return false, fmt.Errorf("health check failed for %s: %w", e.name, err)
 	}
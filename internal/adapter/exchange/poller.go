@@ -0,0 +1,239 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+// maxPollBody limits size of polled response
+const maxPollBody = 16 << 20
+
+var errRateLimited = errors.New("rate limited by exchange")
+
+// PollingExchange polls exchange REST ticker endpoint. Response is a JSON array of tickers or tickers
+// separated by new lines, each is decoded as a tick. ETag of the last response is sent with If-None-Match,
+// so unchanged tickers are not read again, and ticks already sent are skipped.
+// Rate limited responses postpone the next poll by Retry-After or rate limit reset headers.
+type PollingExchange struct {
+	name    types.Exchange
+	Addr    string
+	decoder Decoder
+	client  *http.Client
+	cfg     config.ExchangePoll
+	cancel  context.CancelFunc
+
+	etag string
+	last map[types.Symbol]*domain.PriceData // last tick sent of every symbol
+
+	log logger.Logger
+}
+
+// NewPollingExchange creates poller, https connections use TLS config if it is set
+func NewPollingExchange(name types.Exchange, url string, decoder Decoder, tlsConfig *TLSConfig, cfg config.ExchangePoll, log logger.Logger) *PollingExchange {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.DialTLSContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialTLS(ctx, addr, tlsConfig)
+		}
+	}
+
+	return &PollingExchange{
+		name:    name,
+		Addr:    url,
+		decoder: decoder,
+		client:  &http.Client{Timeout: cfg.Timeout, Transport: transport},
+		cfg:     cfg,
+		last:    make(map[types.Symbol]*domain.PriceData),
+		log:     log,
+	}
+}
+
+// Start polls exchange once to check it is available and keeps polling until Close.
+// Rate limited exchange is available, the next poll waits as long as it asks.
+func (p *PollingExchange) Start(ctx context.Context) (<-chan *domain.PriceData, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	ticks, wait, err := p.poll(ctx)
+	if err != nil && !errors.Is(err, errRateLimited) {
+		cancel()
+		return nil, fmt.Errorf("failed to poll: %w", err)
+	}
+
+	log := p.log.GetSlogLogger().With("name", p.Name(), "address", p.Addr)
+	log.InfoContext(ctx, "polling exchange!", "interval", p.cfg.Interval)
+	if err != nil {
+		log.WarnContext(ctx, "rate limited by exchange, postponing poll", "retry_in", wait)
+	}
+
+	out := make(chan *domain.PriceData)
+
+	go func() {
+		defer close(out)
+
+		for {
+			for _, tick := range ticks {
+				select {
+				case out <- tick:
+				case <-ctx.Done():
+					log.InfoContext(ctx, "context cancelled")
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				log.InfoContext(ctx, "context cancelled")
+				return
+			case <-time.After(max(wait, p.cfg.Interval)):
+			}
+
+			ticks, wait, err = p.poll(ctx)
+			if ctx.Err() != nil {
+				log.InfoContext(ctx, "context cancelled")
+				return
+			}
+			if errors.Is(err, errRateLimited) {
+				log.WarnContext(ctx, "rate limited by exchange, postponing poll", "retry_in", wait)
+			} else if err != nil {
+				log.WarnContext(ctx, "failed to poll exchange", "error", err)
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// poll requests tickers and returns new ticks, wait is time to wait before the next poll requested by exchange
+func (p *PollingExchange) poll(ctx context.Context) ([]*domain.PriceData, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Addr, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	receivedAt := time.Now()
+
+	wait := rateLimitWait(resp.Header, receivedAt)
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, wait, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		return nil, max(wait, p.cfg.Interval), errRateLimited
+	case resp.StatusCode != http.StatusOK:
+		return nil, wait, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPollBody))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	p.etag = resp.Header.Get("ETag")
+
+	var ticks []*domain.PriceData
+	for _, item := range splitTickers(body) {
+		data, err := p.decoder.Decode(item)
+		if err != nil {
+			p.log.Error(ctx, "failed to decode tick", "name", p.Name(), "error", err)
+			continue
+		}
+		data.Exchange = p.name
+		data.Source = types.SourceLive
+		data.ReceivedAt = receivedAt
+
+		// unchanged ticker is returned until exchange updates it
+		if last, ok := p.last[data.Symbol]; ok && last.Price == data.Price && last.Timestamp.Equal(data.Timestamp) {
+			continue
+		}
+		p.last[data.Symbol] = data
+		ticks = append(ticks, data)
+	}
+
+	return ticks, wait, nil
+}
+
+// splitTickers splits JSON array into its elements, other bodies into non-empty lines
+func splitTickers(body []byte) [][]byte {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err == nil {
+			result := make([][]byte, len(items))
+			for i, item := range items {
+				result[i] = item
+			}
+			return result
+		}
+	}
+
+	var result [][]byte
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// rateLimitWait returns time until rate limit of exchange is reset: Retry-After in seconds or HTTP date,
+// or reset of exhausted RateLimit-Remaining / X-RateLimit-Remaining in seconds or unix time
+func rateLimitWait(h http.Header, now time.Time) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(max(seconds, 0)) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(now), 0)
+		}
+	}
+
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		if h.Get(prefix+"Remaining") != "0" {
+			continue
+		}
+		reset, err := strconv.ParseInt(h.Get(prefix+"Reset"), 10, 64)
+		if err != nil || reset < 0 {
+			continue
+		}
+		// values that look like unix time are absolute
+		if reset > 1_000_000_000 {
+			return max(time.Unix(reset, 0).Sub(now), 0)
+		}
+		return time.Duration(reset) * time.Second
+	}
+
+	return 0
+}
+
+// Close stops polling
+func (p *PollingExchange) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	return nil
+}
+
+func (p *PollingExchange) Name() string {
+	return string(p.name)
+}
@@ -0,0 +1,123 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+// maxReplayGap limits pause between replayed ticks, so gaps in recording do not stall the replay
+const maxReplayGap = time.Second
+
+// HistoryReader provides recorded ticks
+type HistoryReader interface {
+	GetPriceInPeriod(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) ([]*domain.PriceData, error)
+}
+
+// ReplayExchangeSource replays live ticks recorded for the exchange in a loop,
+// keeping intervals between ticks and stamping them with the current time.
+type ReplayExchangeSource struct {
+	name    types.Exchange
+	history HistoryReader
+	window  time.Duration
+	cancel  context.CancelFunc
+
+	log logger.Logger
+}
+
+func NewReplayExchange(name types.Exchange, history HistoryReader, window time.Duration, log logger.Logger) *ReplayExchangeSource {
+	return &ReplayExchangeSource{
+		name:    name,
+		history: history,
+		window:  window,
+		log:     log,
+	}
+}
+
+// Start loads recorded ticks and starts replaying them
+func (r *ReplayExchangeSource) Start(ctx context.Context) (<-chan *domain.PriceData, error) {
+	recorded, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+
+	r.log.Info(ctx, "replaying recorded ticks", "name", r.Name(), "count", len(recorded), "window", r.window)
+
+	out := make(chan *domain.PriceData)
+
+	go func() {
+		defer close(out)
+
+		for {
+			for i, tick := range recorded {
+				if i > 0 {
+					gap := min(tick.Timestamp.Sub(recorded[i-1].Timestamp), maxReplayGap)
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(gap):
+					}
+				}
+
+				select {
+				case out <- &domain.PriceData{
+					Exchange:  r.name,
+					Symbol:    tick.Symbol,
+					Price:     tick.Price,
+					Timestamp: time.Now(),
+					Source:    types.SourceReplay,
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// load returns live ticks of all symbols recorded in the window ordered by timestamp
+func (r *ReplayExchangeSource) load(ctx context.Context) ([]*domain.PriceData, error) {
+	var recorded []*domain.PriceData
+	for _, symbol := range types.ValidSymbols {
+		prices, err := r.history.GetPriceInPeriod(ctx, r.name, symbol, r.window)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load recorded ticks: %w", err)
+		}
+		for _, p := range prices {
+			if p.Source == types.SourceLive {
+				recorded = append(recorded, p)
+			}
+		}
+	}
+
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("%w for %s in last %s", domain.ErrNothingToReplay, r.name, r.window)
+	}
+
+	sort.Slice(recorded, func(i, j int) bool {
+		return recorded[i].Timestamp.Before(recorded[j].Timestamp)
+	})
+
+	return recorded, nil
+}
+
+func (r *ReplayExchangeSource) Close() error {
+	if r.cancel != nil {
+		r.cancel() // canceling context to stop the gouroutine
+	}
+	return nil
+}
+
+func (r *ReplayExchangeSource) Name() string {
+	return string(r.name)
+}
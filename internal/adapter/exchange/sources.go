@@ -0,0 +1,163 @@
+package exchange
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// Transports of live exchange feeds
+const (
+	TransportTCP       = "tcp"       // newline delimited ticks over TCP, address is host:port
+	TransportWebSocket = "websocket" // ticks in websocket messages, address is ws:// or wss:// URL
+	TransportHTTP      = "http"      // polled REST tickers, address is http:// or https:// URL
+)
+
+var transports = []string{TransportTCP, TransportWebSocket, TransportHTTP}
+
+// LiveSources creates live sources of exchanges with their configured transport, decoder and TLS config
+type LiveSources struct {
+	transports map[types.Exchange]string
+	subscribe  map[types.Exchange]string
+	decoders   map[types.Exchange]Decoder
+	tls        map[types.Exchange]*TLSConfig // exchanges with TLS enabled
+
+	cfg config.Exchanges
+	log logger.Logger
+}
+
+// NewLiveSources checks transports, addresses and decoders of all exchanges and loads their TLS files
+func NewLiveSources(cfg config.Exchanges, log logger.Logger) (*LiveSources, error) {
+	decoders, err := NewDecoders(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &LiveSources{
+		transports: map[types.Exchange]string{
+			types.Exchange1: cfg.Exchange1Transport,
+			types.Exchange2: cfg.Exchange2Transport,
+			types.Exchange3: cfg.Exchange3Transport,
+		},
+		subscribe: map[types.Exchange]string{
+			types.Exchange1: cfg.Exchange1Subscribe,
+			types.Exchange2: cfg.Exchange2Subscribe,
+			types.Exchange3: cfg.Exchange3Subscribe,
+		},
+		decoders: decoders,
+		tls:      make(map[types.Exchange]*TLSConfig),
+		cfg:      cfg,
+		log:      log,
+	}
+
+	if cfg.TLS.ReloadInterval <= 0 || cfg.TLS.ExpiryWarning < 0 {
+		return nil, fmt.Errorf("TLS reload interval must be positive and expiry warning must not be negative")
+	}
+	tlsFiles := map[types.Exchange]TLSFiles{
+		types.Exchange1: {cfg.Exchange1TLSCAFile, cfg.Exchange1TLSCertFile, cfg.Exchange1TLSKeyFile, cfg.Exchange1TLSServerName, cfg.Exchange1TLSMinVersion},
+		types.Exchange2: {cfg.Exchange2TLSCAFile, cfg.Exchange2TLSCertFile, cfg.Exchange2TLSKeyFile, cfg.Exchange2TLSServerName, cfg.Exchange2TLSMinVersion},
+		types.Exchange3: {cfg.Exchange3TLSCAFile, cfg.Exchange3TLSCertFile, cfg.Exchange3TLSKeyFile, cfg.Exchange3TLSServerName, cfg.Exchange3TLSMinVersion},
+	}
+	for exchange, enabled := range map[types.Exchange]bool{
+		types.Exchange1: cfg.Exchange1TLS,
+		types.Exchange2: cfg.Exchange2TLS,
+		types.Exchange3: cfg.Exchange3TLS,
+	} {
+		if !enabled {
+			continue
+		}
+		tlsConfig, err := NewTLSConfig(exchange, tlsFiles[exchange], cfg.TLS, log)
+		if err != nil {
+			return nil, err
+		}
+		s.tls[exchange] = tlsConfig
+	}
+
+	addrs := map[types.Exchange]string{
+		types.Exchange1: cfg.Exchange1Addr,
+		types.Exchange2: cfg.Exchange2Addr,
+		types.Exchange3: cfg.Exchange3Addr,
+	}
+	for exchange, transport := range s.transports {
+		if !slices.Contains(transports, transport) {
+			return nil, fmt.Errorf("invalid transport %q of %s, available transports %v", transport, exchange, transports)
+		}
+		if err := s.ValidateAddr(exchange, addrs[exchange]); err != nil {
+			return nil, fmt.Errorf("address of %s: %w", exchange, err)
+		}
+	}
+
+	if cfg.WebSocket.PingInterval <= 0 || cfg.WebSocket.PongTimeout <= 0 || cfg.WebSocket.HandshakeTimeout <= 0 ||
+		cfg.WebSocket.ReconnectMin <= 0 || cfg.WebSocket.ReconnectMax < cfg.WebSocket.ReconnectMin {
+		return nil, fmt.Errorf("websocket ping interval, pong and handshake timeouts and reconnect delays must be positive, max reconnect delay must not be less than min")
+	}
+	if cfg.Poll.Interval <= 0 || cfg.Poll.Timeout <= 0 {
+		return nil, fmt.Errorf("poll interval and timeout must be positive")
+	}
+
+	return s, nil
+}
+
+// New creates live source of the exchange reading given address
+func (s *LiveSources) New(name types.Exchange, addr string) ports.ExchangeSource {
+	switch s.transports[name] {
+	case TransportWebSocket:
+		return NewWebSocketExchange(name, addr, s.subscribe[name], s.decoders[name], s.tls[name], s.cfg.WebSocket, s.log)
+	case TransportHTTP:
+		return NewPollingExchange(name, addr, s.decoders[name], s.tls[name], s.cfg.Poll, s.log)
+	default:
+		return NewExchange(name, addr, s.decoders[name], s.tls[name], s.log)
+	}
+}
+
+// ValidateAddr checks that address fits transport of the exchange, exchanges with TLS enabled need secure URL
+func (s *LiveSources) ValidateAddr(name types.Exchange, addr string) error {
+	_, secure := s.tls[name]
+
+	var schemes []string
+	switch s.transports[name] {
+	case TransportWebSocket:
+		schemes = []string{"wss"}
+		if !secure {
+			schemes = append(schemes, "ws")
+		}
+	case TransportHTTP:
+		schemes = []string{"https"}
+		if !secure {
+			schemes = append(schemes, "http")
+		}
+	default:
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("%w: %w", domain.ErrInvalidAddress, err)
+		}
+		return nil
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidAddress, err)
+	}
+	if !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		return fmt.Errorf("%w: %s URL expected", domain.ErrInvalidAddress, strings.Join(schemes, " or "))
+	}
+	return nil
+}
+
+// Certificates returns TLS certificates of exchanges sorted by exchange
+func (s *LiveSources) Certificates() []domain.Certificate {
+	certs := []domain.Certificate{}
+	for _, exchange := range types.ValidExchanges {
+		if tlsConfig, ok := s.tls[exchange]; ok {
+			certs = append(certs, tlsConfig.Certificates()...)
+		}
+	}
+	return certs
+}
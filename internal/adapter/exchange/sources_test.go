@@ -0,0 +1,340 @@
+package exchange
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/websocket"
+)
+
+func testDecoder(t *testing.T, fields, unit string) Decoder {
+	t.Helper()
+	cfg, err := DecoderConfigOf(types.Exchange1, config.Exchanges{Exchange1Fields: fields, Exchange1TimestampUnit: unit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoder
+}
+
+func receive(t *testing.T, ch <-chan *domain.PriceData) *domain.PriceData {
+	t.Helper()
+	select {
+	case p, ok := <-ch:
+		if !ok {
+			t.Fatal("feed closed")
+		}
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tick received")
+	}
+	return nil
+}
+
+func TestWebSocketExchange(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+
+	var connections atomic.Int32
+	subscribed := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		n := connections.Add(1)
+
+		_, subscribe, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		subscribed <- string(subscribe)
+
+		conn.WriteMessage(websocket.OpText, []byte(`{"result":null,"id":1}`))
+		conn.WriteMessage(websocket.OpText, []byte(`{"data":{"s":"btc-usdt","p":"100.5","T":1700000000}}`))
+		if n == 1 {
+			return // dropped connection must be reconnected
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.ExchangeWebSocket{PingInterval: 50 * time.Millisecond, PongTimeout: time.Second, HandshakeTimeout: time.Second, ReconnectMin: 10 * time.Millisecond, ReconnectMax: 100 * time.Millisecond}
+	source := NewWebSocketExchange(types.Exchange1, "ws"+strings.TrimPrefix(server.URL, "http"), `{"method":"SUBSCRIBE"}`,
+		testDecoder(t, "symbol=data.s,price=data.p,timestamp=data.T", UnitSeconds), nil, cfg, log)
+
+	ch, err := source.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if got := <-subscribed; got != `{"method":"SUBSCRIBE"}` {
+			t.Fatalf("subscribe message %q", got)
+		}
+		p := receive(t, ch)
+		if p.Symbol != types.BTCUSDT || p.Price != 100.5 || p.Timestamp.Unix() != 1700000000 || p.Exchange != types.Exchange1 || p.ReceivedAt.IsZero() {
+			t.Fatalf("unexpected tick %+v", p)
+		}
+	}
+
+	// pings are answered by stand-in server, so connection outlives ping interval and pong timeout
+	time.Sleep(3 * cfg.PingInterval)
+	if n := connections.Load(); n != 2 {
+		t.Fatalf("expected 2 connections, got %d", n)
+	}
+
+	if err := source.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range ch {
+	}
+}
+
+func TestPollingExchange(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch polls.Add(1) {
+		case 1:
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`[{"symbol":"BTCUSDT","price":"100","timestamp":1700000000000},{"symbol":"ETHUSDT","price":"10","timestamp":1700000000000}]`))
+		case 2:
+			if r.Header.Get("If-None-Match") != `"v1"` {
+				t.Errorf("If-None-Match %q", r.Header.Get("If-None-Match"))
+			}
+			w.WriteHeader(http.StatusNotModified)
+		case 3:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			// BTCUSDT is unchanged and must not be sent again
+			w.Write([]byte(`[{"symbol":"BTCUSDT","price":"100","timestamp":1700000000000},{"symbol":"ETHUSDT","price":"11","timestamp":1700000001000}]`))
+		}
+	}))
+	defer server.Close()
+
+	source := NewPollingExchange(types.Exchange1, server.URL, testDecoder(t, "", UnitAuto), nil, config.ExchangePoll{Interval: 10 * time.Millisecond, Timeout: time.Second}, log)
+	ch, err := source.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for range 3 {
+		p := receive(t, ch)
+		got = append(got, fmt.Sprintf("%s=%g", p.Symbol, p.Price))
+	}
+	if strings.Join(got, ",") != "BTCUSDT=100,ETHUSDT=10,ETHUSDT=11" {
+		t.Fatalf("unexpected ticks %v", got)
+	}
+	if n := polls.Load(); n < 4 {
+		t.Fatalf("expected at least 4 polls, got %d", n)
+	}
+
+	source.Close()
+	for range ch {
+	}
+}
+
+func TestPollingExchangeStartsRateLimited(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"symbol":"BTCUSDT","price":"100","timestamp":1700000000000}`))
+	}))
+	defer server.Close()
+
+	source := NewPollingExchange(types.Exchange1, server.URL, testDecoder(t, "", UnitAuto), nil, config.ExchangePoll{Interval: 10 * time.Millisecond, Timeout: time.Second}, log)
+	started := time.Now()
+	ch, err := source.Start(ctx)
+	if err != nil {
+		t.Fatalf("rate limited first poll must start source, got %v", err)
+	}
+
+	if p := receive(t, ch); p.Symbol != types.BTCUSDT {
+		t.Fatalf("unexpected tick %+v", p)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Fatalf("second poll after %v, Retry-After must be honoured", elapsed)
+	}
+
+	source.Close()
+	for range ch {
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, tc := range []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1700000005"}}, 5 * time.Second},
+		{http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"2"}}, 2 * time.Second},
+		{http.Header{"Ratelimit-Remaining": {"5"}, "Ratelimit-Reset": {"2"}}, 0},
+	} {
+		if got := rateLimitWait(tc.header, now); got != tc.want {
+			t.Errorf("rateLimitWait(%v) = %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
+
+// writeCert writes PEM certificate signed by parent, or self-signed if parent is nil, and its key
+func writeCert(t *testing.T, dir, name string, tmpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return cert, key
+}
+
+func TestExchangeMutualTLS(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+	dir := t.TempDir()
+	now := time.Now()
+
+	ca, caKey := writeCert(t, dir, "ca", &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "test ca"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(48 * time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}, nil, nil)
+	writeCert(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "exchange"}, DNSNames: []string{"exchange.test"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(48 * time.Hour),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	client := &x509.Certificate{
+		SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: "marketflow"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(24 * time.Hour),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	writeCert(t, dir, "client", client, ca, caKey)
+
+	serverCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte(`{"symbol":"BTCUSDT","price":1,"timestamp":1700000000000}` + "\n"))
+				io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+
+	cfg := config.ExchangeTLS{ReloadInterval: time.Millisecond, ExpiryWarning: 36 * time.Hour}
+	tlsConfig, err := NewTLSConfig(types.Exchange1, TLSFiles{
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+		ServerName: "exchange.test",
+		MinVersion: "1.2",
+	}, cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs := tlsConfig.Certificates()
+	if len(certs) != 2 || certs[0].Kind != CertCA || certs[1].Kind != CertClient || !certs[1].ExpiresSoon || certs[0].ExpiresSoon {
+		t.Fatalf("unexpected certificates %+v", certs)
+	}
+
+	source := NewExchange(types.Exchange1, listener.Addr().String(), testDecoder(t, "", UnitAuto), tlsConfig, log)
+	ch, err := source.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := receive(t, ch); p.Symbol != types.BTCUSDT {
+		t.Fatalf("unexpected tick %+v", p)
+	}
+	source.Close()
+
+	// rotated client certificate is used for new connections
+	client.SerialNumber, client.NotAfter = big.NewInt(4), now.Add(72*time.Hour)
+	writeCert(t, dir, "client", client, ca, caKey)
+	future := now.Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "client.pem"), future, future)
+	time.Sleep(2 * cfg.ReloadInterval)
+
+	certs = tlsConfig.Certificates()
+	if !certs[1].NotAfter.Equal(client.NotAfter.Truncate(time.Second)) || certs[1].ExpiresSoon || certs[1].Error != "" {
+		t.Fatalf("client certificate was not reloaded %+v", certs[1])
+	}
+
+	source = NewExchange(types.Exchange1, listener.Addr().String(), testDecoder(t, "", UnitAuto), tlsConfig, log)
+	if ch, err = source.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, ch)
+	source.Close()
+}
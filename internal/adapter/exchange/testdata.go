@@ -46,6 +46,7 @@ func (t *TestExchangeSource) Start(ctx context.Context) (<-chan *domain.PriceDat
 						Symbol:    symbol,
 						Price:     generateRandomPrice(symbol, r),
 						Timestamp: time.Now(),
+						Source:    types.SourceTest,
 					}:
 					case <-ctx.Done():
 						return
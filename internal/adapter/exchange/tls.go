@@ -0,0 +1,256 @@
+package exchange
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/metrics"
+)
+
+// Kinds of exchange certificates
+const (
+	CertClient = "client"
+	CertCA     = "ca"
+)
+
+var certExpiry = metrics.NewGaugeVec("marketflow_exchange_tls_cert_expiry_timestamp_seconds",
+	"Unix time when the first TLS certificate of the kind used for exchange connections expires.", "exchange", "kind")
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSFiles are TLS options of exchange connection
+type TLSFiles struct {
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+	MinVersion string
+}
+
+// TLSConfig keeps TLS config of exchange loaded from files. Files are checked for changes at most every reload
+// interval when config is requested, changed files are reloaded, and if they fail to load the previous config is kept.
+// New connections use the reloaded config, established ones keep theirs until they reconnect.
+type TLSConfig struct {
+	exchange   types.Exchange
+	files      TLSFiles
+	minVersion uint16
+	cfg        config.ExchangeTLS
+
+	mu       sync.Mutex
+	checked  time.Time
+	modTimes []time.Time // of CA, cert and key files
+	current  *tls.Config
+	certs    []domain.Certificate
+	lastErr  error
+
+	log logger.Logger
+}
+
+// NewTLSConfig loads TLS config of exchange, it fails if files can not be loaded
+func NewTLSConfig(exchange types.Exchange, files TLSFiles, cfg config.ExchangeTLS, log logger.Logger) (*TLSConfig, error) {
+	minVersion, ok := tlsVersions[files.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid TLS min version %q of %s, available versions 1.0, 1.1, 1.2, 1.3", files.MinVersion, exchange)
+	}
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, fmt.Errorf("both TLS cert and key files of %s must be set for client certificate", exchange)
+	}
+
+	t := &TLSConfig{
+		exchange:   exchange,
+		files:      files,
+		minVersion: minVersion,
+		cfg:        cfg,
+		log:        log,
+	}
+
+	modTimes, err := t.stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS files of %s: %w", exchange, err)
+	}
+	if err := t.load(modTimes); err != nil {
+		return nil, fmt.Errorf("failed to load TLS files of %s: %w", exchange, err)
+	}
+	t.checked = time.Now()
+
+	return t, nil
+}
+
+// Config returns TLS config for new connection, reloading changed files
+func (t *TLSConfig) Config() *tls.Config {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reload()
+	return t.current
+}
+
+// Certificates returns certificates in use with their expiry
+func (t *TLSConfig) Certificates() []domain.Certificate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reload()
+
+	now := time.Now()
+	certs := slices.Clone(t.certs)
+	for i := range certs {
+		c := &certs[i]
+		c.ExpiresIn = c.NotAfter.Sub(now).Round(time.Second).String()
+		c.Expired = !now.Before(c.NotAfter)
+		c.ExpiresSoon = !c.Expired && c.NotAfter.Sub(now) < t.cfg.ExpiryWarning
+		if t.lastErr != nil {
+			c.Error = t.lastErr.Error()
+		}
+	}
+	return certs
+}
+
+// reload loads files if reload interval passed since last check and they changed
+func (t *TLSConfig) reload() {
+	now := time.Now()
+	if now.Sub(t.checked) < t.cfg.ReloadInterval {
+		return
+	}
+	t.checked = now
+
+	modTimes, err := t.stat()
+	if err == nil && slices.EqualFunc(modTimes, t.modTimes, time.Time.Equal) {
+		return
+	}
+	if err == nil {
+		err = t.load(modTimes)
+	}
+
+	ctx := context.Background()
+	if err != nil {
+		// rotation may be caught between writes of cert and key, files are retried on next check
+		if t.lastErr == nil || t.lastErr.Error() != err.Error() {
+			t.log.Error(ctx, "failed to reload TLS files, keeping previous ones", "exchange", t.exchange, "error", err)
+		}
+		t.lastErr = err
+		return
+	}
+
+	t.log.Info(ctx, "reloaded TLS files", "exchange", t.exchange)
+}
+
+// stat returns modification times of configured files
+func (t *TLSConfig) stat() ([]time.Time, error) {
+	modTimes := make([]time.Time, 0, 3)
+	for _, file := range []string{t.files.CAFile, t.files.CertFile, t.files.KeyFile} {
+		if file == "" {
+			modTimes = append(modTimes, time.Time{})
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// load reads files and replaces current config and certificates
+func (t *TLSConfig) load(modTimes []time.Time) error {
+	cfg := &tls.Config{
+		MinVersion: t.minVersion,
+		ServerName: t.files.ServerName,
+	}
+	var certs []domain.Certificate
+
+	if t.files.CAFile != "" {
+		data, err := os.ReadFile(t.files.CAFile)
+		if err != nil {
+			return err
+		}
+
+		pool := x509.NewCertPool()
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			ca, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("invalid CA certificate in %s: %w", t.files.CAFile, err)
+			}
+			pool.AddCert(ca)
+			certs = append(certs, t.certificate(CertCA, t.files.CAFile, ca))
+		}
+		if len(certs) == 0 {
+			return fmt.Errorf("no certificates in %s", t.files.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.files.CertFile != "" {
+		pair, err := tls.LoadX509KeyPair(t.files.CertFile, t.files.KeyFile)
+		if err != nil {
+			return err
+		}
+		if pair.Leaf == nil {
+			return errors.New("client certificate has no leaf")
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+		certs = append(certs, t.certificate(CertClient, t.files.CertFile, pair.Leaf))
+	}
+
+	expiry := make(map[string]time.Time)
+	for _, cert := range certs {
+		if first, ok := expiry[cert.Kind]; !ok || cert.NotAfter.Before(first) {
+			expiry[cert.Kind] = cert.NotAfter
+		}
+	}
+	for kind, notAfter := range expiry {
+		certExpiry.With(string(t.exchange), kind).Set(float64(notAfter.Unix()))
+	}
+
+	t.current, t.certs, t.modTimes = cfg, certs, modTimes
+	t.lastErr = nil
+	return nil
+}
+
+func (t *TLSConfig) certificate(kind, file string, cert *x509.Certificate) domain.Certificate {
+	return domain.Certificate{
+		Exchange: t.exchange,
+		Kind:     kind,
+		File:     file,
+		Subject:  cert.Subject.String(),
+		Issuer:   cert.Issuer.String(),
+		NotAfter: cert.NotAfter,
+		LoadedAt: time.Now(),
+	}
+}
+
+// dialTLS connects to address with current config of exchange, server name defaults to host of address
+func dialTLS(ctx context.Context, addr string, t *TLSConfig) (net.Conn, error) {
+	cfg := t.Config()
+	if cfg.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg = cfg.Clone()
+		cfg.ServerName = host
+	}
+	return (&tls.Dialer{Config: cfg}).DialContext(ctx, "tcp", addr)
+}
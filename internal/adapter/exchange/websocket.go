@@ -0,0 +1,222 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/websocket"
+)
+
+// closeFrameTimeout limits sending close frame on Close
+const closeFrameTimeout = time.Second
+
+// WebSocketExchange reads exchange feed from websocket, every text or binary message is decoded as a tick.
+// Subscribe message is sent after every connect. Connection is pinged and reconnected with backoff
+// when it fails or nothing is received within ping interval and pong timeout, so channel is closed only by Close.
+type WebSocketExchange struct {
+	name      types.Exchange
+	Addr      string
+	subscribe string
+	decoder   Decoder
+	dialer    *websocket.Dialer
+	tls       *TLSConfig // nil for default config of wss://
+	cfg       config.ExchangeWebSocket
+
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	cancel context.CancelFunc
+
+	log logger.Logger
+}
+
+func NewWebSocketExchange(name types.Exchange, url, subscribe string, decoder Decoder, tlsConfig *TLSConfig, cfg config.ExchangeWebSocket, log logger.Logger) *WebSocketExchange {
+	return &WebSocketExchange{
+		name:      name,
+		Addr:      url,
+		subscribe: subscribe,
+		decoder:   decoder,
+		dialer:    &websocket.Dialer{HandshakeTimeout: cfg.HandshakeTimeout},
+		tls:       tlsConfig,
+		cfg:       cfg,
+		log:       log,
+	}
+}
+
+// Start connects to exchange, the first connection must succeed, later ones are retried
+func (w *WebSocketExchange) Start(ctx context.Context) (<-chan *domain.PriceData, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+
+	conn, err := w.connect(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	log := w.log.GetSlogLogger().With("name", w.Name(), "address", w.Addr)
+	log.InfoContext(ctx, "connected to exchange!")
+
+	out := make(chan *domain.PriceData)
+
+	go func() {
+		defer close(out)
+
+		backoff := w.cfg.ReconnectMin
+		for {
+			err := w.read(ctx, conn, out)
+			conn.Close()
+			if ctx.Err() != nil {
+				log.InfoContext(ctx, "context cancelled")
+				return
+			}
+			log.WarnContext(ctx, "connection lost, reconnecting", "error", err)
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+
+				conn, err = w.connect(ctx)
+				if err == nil {
+					log.InfoContext(ctx, "reconnected to exchange")
+					backoff = w.cfg.ReconnectMin
+					break
+				}
+				if ctx.Err() != nil {
+					return
+				}
+
+				backoff = min(backoff*2, w.cfg.ReconnectMax)
+				log.WarnContext(ctx, "failed to reconnect", "error", err, "retry_in", backoff)
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// connect dials exchange with current TLS config and sends subscribe message
+func (w *WebSocketExchange) connect(ctx context.Context) (*websocket.Conn, error) {
+	if w.tls != nil {
+		w.dialer.TLSConfig = w.tls.Config()
+	}
+	conn, err := w.dialer.Dial(ctx, w.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.subscribe != "" {
+		if err := conn.WriteMessage(websocket.OpText, []byte(w.subscribe)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe: %w", err)
+		}
+	}
+
+	w.mu.Lock()
+	w.conn = conn
+	w.mu.Unlock()
+
+	// connection may be set after Close, it must not outlive the source
+	if ctx.Err() != nil {
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	return conn, nil
+}
+
+// read forwards ticks of the connection until it fails, pinging it meanwhile
+func (w *WebSocketExchange) read(ctx context.Context, conn *websocket.Conn, out chan<- *domain.PriceData) error {
+	extend := func([]byte) {
+		conn.SetReadDeadline(time.Now().Add(w.cfg.PingInterval + w.cfg.PongTimeout))
+	}
+	extend(nil)
+	// peer not reading pings within pong timeout is as dead as one not answering them
+	conn.SetWriteTimeout(w.cfg.PongTimeout)
+	conn.SetPongHandler(extend)
+	conn.SetPingHandler(extend)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(w.cfg.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := conn.Ping(nil); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		receivedAt := time.Now()
+		extend(nil)
+
+		// subscription confirmations and heartbeats of exchange are not ticks
+		data, err := w.decoder.Decode(message)
+		if err != nil {
+			w.log.Debug(ctx, "skipped websocket message", "name", w.Name(), "error", err)
+			continue
+		}
+		data.Exchange = w.name
+		data.Source = types.SourceLive
+		data.ReceivedAt = receivedAt
+
+		select {
+		case out <- data:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close stops reconnecting and closes the connection
+func (w *WebSocketExchange) Close() error {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	w.mu.Lock()
+	conn := w.conn
+	w.mu.Unlock()
+
+	if conn != nil {
+		// close frame waits for writes in progress, connection is closed anyway after timeout, which fails them
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			conn.WriteClose(websocket.CloseGoingAway, "")
+		}()
+		select {
+		case <-sent:
+		case <-time.After(closeFrameTimeout):
+		}
+
+		if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *WebSocketExchange) Name() string {
+	return string(w.name)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/validator"
+)
+
+const (
+	// defaultArchiveDays is listed when from is not given
+	defaultArchiveDays = 7
+	// maxArchiveDays limits days listed at once, every day is a manifest read
+	maxArchiveDays = 31
+)
+
+type ArchiveReader interface {
+	List(ctx context.Context, filter domain.ArchiveFilter) ([]domain.ArchiveFile, error)
+	Open(ctx context.Context, path string) (io.ReadCloser, string, error)
+}
+
+type Archives struct {
+	archives ArchiveReader
+	log      logger.Logger
+}
+
+func NewArchives(archives ArchiveReader, log logger.Logger) *Archives {
+	return &Archives{
+		archives: archives,
+		log:      log,
+	}
+}
+
+// List returns archive files of days from manifests
+func (h *Archives) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	v := validator.New()
+	var filter domain.ArchiveFilter
+
+	for _, exchange := range splitList(query["exchange"]) {
+		validateExchange(v, exchange)
+		filter.Exchanges = append(filter.Exchanges, types.Exchange(exchange))
+	}
+	for _, symbol := range splitList(query["symbol"]) {
+		validateSymbol(v, symbol)
+		filter.Symbols = append(filter.Symbols, types.Symbol(symbol))
+	}
+
+	filter.To = time.Now().UTC().Truncate(24 * time.Hour)
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse(time.DateOnly, to)
+		v.Check(err == nil, "to", "must be date, e.g. 2025-01-02")
+		filter.To = parsed
+	}
+
+	filter.From = filter.To.AddDate(0, 0, -(defaultArchiveDays - 1))
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse(time.DateOnly, from)
+		v.Check(err == nil, "from", "must be date, e.g. 2025-01-02")
+		filter.From = parsed
+	}
+
+	if v.Valid() {
+		v.Check(!filter.From.After(filter.To), "from", "must not be after to")
+		v.Check(filter.To.Sub(filter.From) < maxArchiveDays*24*time.Hour, "from", "range must not be longer than 31 days")
+	}
+	if !v.Valid() {
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	files, err := h.archives.List(ctx, filter)
+	if err != nil {
+		h.log.Error(ctx, "failed to list archives", "error", err)
+		internalErrorResponse(w, "failed to list archives")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"data": files}, nil)
+}
+
+// Fetch streams archive file or manifest by its path
+func (h *Archives) Fetch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filePath := r.PathValue("path")
+
+	if filePath == "" || path.Clean(filePath) != filePath || strings.HasPrefix(filePath, "..") {
+		notFoundErrorResponse(w)
+		return
+	}
+
+	file, contentType, err := h.archives.Open(ctx, filePath)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			notFoundErrorResponse(w)
+			return
+		}
+		h.log.Error(ctx, "failed to open archive", "path", filePath, "error", err)
+		internalErrorResponse(w, "failed to open archive")
+		return
+	}
+	defer file.Close()
+
+	// Download of large file may take longer than server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.log.Warn(ctx, "failed to clear write deadline", "error", err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+strings.ReplaceAll(filePath, "/", "_")+`"`)
+
+	if _, err := io.Copy(w, file); err != nil {
+		h.log.Error(ctx, "failed to send archive", "path", filePath, "error", err)
+	}
+}
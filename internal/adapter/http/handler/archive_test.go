@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/adapter/archive"
+	"marketflow/internal/service"
+	"marketflow/pkg/logger"
+)
+
+func TestArchivesFetch(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+
+	store, err := archive.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := "date=2025-01-02/exchange=exchange1/symbol=BTCUSDT/15.parquet"
+	if err := store.Put(ctx, file, []byte("PAR1"), "application/vnd.apache.parquet"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "date=2025-01-02/manifest.json", []byte(`{"date":"2025-01-02","files":[]}`), "application/json"); err != nil {
+		t.Fatal(err)
+	}
+
+	archiver := service.NewArchiver(nil, store, archive.NewParquetEncoder(), config.Archive{}, time.Hour, log)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /archives/{path...}", NewArchives(archiver, log).Fetch)
+
+	for _, tc := range []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{file, http.StatusOK, "application/vnd.apache.parquet", "PAR1"},
+		{"date=2025-01-02/manifest.json", http.StatusOK, "application/json", `{"date":"2025-01-02","files":[]}`},
+		{"date=2025-01-03/manifest.json", http.StatusNotFound, "", ""},
+		// directories are not files
+		{"date=2025-01-02", http.StatusNotFound, "", ""},
+		{"date=2025-01-02/exchange=exchange1/", http.StatusNotFound, "", ""},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/archives/"+tc.path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Fatalf("status %d, want %d", w.Code, tc.status)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			body, _ := io.ReadAll(w.Body)
+			if got := w.Header().Get("Content-Type"); got != tc.contentType || string(body) != tc.body {
+				t.Fatalf("got %s %q", got, body)
+			}
+			if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, strings.ReplaceAll(tc.path, "/", "_")) {
+				t.Fatalf("Content-Disposition %q", disposition)
+			}
+		})
+	}
+}
+
+func TestArchivesListValidation(t *testing.T) {
+	log := logger.InitLogger(context.Background(), "error")
+	h := NewArchives(nil, log)
+
+	for _, query := range []string{
+		"exchange=unknown",
+		"symbol=XBTUSDT",
+		"from=2025-01-10&to=2025-01-02",
+		"from=2025-01-01&to=2025-03-01",
+		"to=yesterday",
+	} {
+		w := httptest.NewRecorder()
+		h.List(w, httptest.NewRequest(http.MethodGet, "/archives?"+query, nil))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status %d, want 422", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/validator"
+)
+
+// maxBatchQueries limits number of queries in single batch request
+const maxBatchQueries = 50
+
+type batchQuery struct {
+	Metric   string `json:"metric"`
+	Exchange string `json:"exchange,omitempty"` // empty for price among all exchanges
+	Symbol   string `json:"symbol"`
+	Period   string `json:"period,omitempty"` // not used by latest metric
+}
+
+// batchResult is a query with its data or error, error is a string or validation messages by field
+type batchResult struct {
+	batchQuery
+	Data  any `json:"data,omitempty"`
+	Error any `json:"error,omitempty"`
+}
+
+// PriceBatch returns latest prices and stats for many queries in single response, errors are reported per query
+func (h *Market) PriceBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := h.log.GetSlogLogger()
+
+	var input struct {
+		Queries []batchQuery `json:"queries"`
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(input.Queries) > 0, "queries", "must be provided")
+	v.Check(len(input.Queries) <= maxBatchQueries, "queries", fmt.Sprintf("must not contain more than %d queries", maxBatchQueries))
+	if !v.Valid() {
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	results := make([]batchResult, len(input.Queries))
+
+	// Valid queries and indexes of their results
+	queries := make([]domain.PriceQuery, 0, len(input.Queries))
+	indexes := make([]int, 0, len(input.Queries))
+
+	for i, q := range input.Queries {
+		query, v := parseBatchQuery(&q)
+		results[i].batchQuery = q
+		if !v.Valid() {
+			results[i].Error = v.Errors
+			continue
+		}
+		queries = append(queries, query)
+		indexes = append(indexes, i)
+	}
+
+	for j, res := range h.market.GetBatch(ctx, queries) {
+		i := indexes[j]
+		switch {
+		case errors.Is(res.Err, domain.ErrNotFound):
+			results[i].Error = "requested resource not found"
+		case res.Err != nil:
+			log.ErrorContext(ctx, "failed to get batch price data", "query", results[i].batchQuery, "error", res.Err)
+			results[i].Error = "failed to get price data"
+		case res.Latest != nil:
+			results[i].Data = res.Latest
+		default:
+			results[i].Data = res.Stats
+		}
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"data": results}, nil)
+}
+
+// parseBatchQuery validates query and normalizes its period
+func parseBatchQuery(q *batchQuery) (domain.PriceQuery, *validator.Validator) {
+	v := validator.New()
+
+	validateMetric(v, q.Metric)
+	validateSymbol(v, q.Symbol)
+
+	query := domain.PriceQuery{
+		Metric:   types.Metric(q.Metric),
+		Exchange: types.AllExchanges,
+		Symbol:   types.Symbol(q.Symbol),
+	}
+
+	if q.Exchange != "" {
+		validateExchange(v, q.Exchange)
+		query.Exchange = types.Exchange(q.Exchange)
+	}
+
+	if query.Metric != types.MetricLatest {
+		period, normalizedPeriod, err := parsePeriod(q.Period)
+		if err != nil {
+			v.AddError("period", err.Error())
+		} else {
+			query.Period = period
+			q.Period = normalizedPeriod
+		}
+	}
+
+	return query, v
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/validator"
+)
+
+const (
+	// defaultCoverageRange is reported when from is not given
+	defaultCoverageRange = 24 * time.Hour
+	// maxCoverageRange limits minutes reported at once
+	maxCoverageRange = 31 * 24 * time.Hour
+)
+
+type CoverageReporter interface {
+	Report(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time) (*domain.Coverage, error)
+}
+
+type Coverage struct {
+	coverage CoverageReporter
+	log      logger.Logger
+}
+
+func NewCoverage(coverage CoverageReporter, log logger.Logger) *Coverage {
+	return &Coverage{
+		coverage: coverage,
+		log:      log,
+	}
+}
+
+// Get returns share of minutes with live data of exchange and symbol and gaps between them
+func (h *Coverage) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+
+	v := validator.New()
+	validateExchange(v, exchange)
+	validateSymbol(v, symbol)
+
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		v.Check(err == nil, "to", "must be RFC 3339 time, e.g. 2025-01-02T15:04:05Z")
+		to = parsed
+	}
+
+	from := to.Add(-defaultCoverageRange)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		v.Check(err == nil, "from", "must be RFC 3339 time, e.g. 2025-01-02T15:04:05Z")
+		from = parsed
+	}
+
+	if v.Valid() {
+		v.Check(from.Before(to), "from", "must be before to")
+		v.Check(to.Sub(from) <= maxCoverageRange, "from", "range must not be longer than 31 days")
+	}
+	if !v.Valid() {
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	coverage, err := h.coverage.Report(ctx, types.Exchange(exchange), types.Symbol(symbol), from, to)
+	if err != nil {
+		h.log.Error(ctx, "failed to report coverage", "exchange", exchange, "symbol", symbol, "error", err)
+		internalErrorResponse(w, "failed to report coverage")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"data": coverage}, nil)
+}
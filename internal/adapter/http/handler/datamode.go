@@ -5,12 +5,16 @@ import (
 	"net/http"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
 	"marketflow/pkg/logger"
+	"marketflow/pkg/validator"
 )
 
 type ModeSwitcher interface {
-	SwitchToTest() error
-	SwitchToLive() error
+	SwitchToTest() (*domain.ModeStatus, error)
+	SwitchToLive() (*domain.ModeStatus, error)
+	SwitchExchange(exchange types.Exchange, mode types.Source) (*domain.ModeStatus, error)
+	ExchangeMode(exchange types.Exchange) types.Source
 }
 
 type DataMode struct {
@@ -26,31 +30,101 @@ func NewDataMode(manager ModeSwitcher, log logger.Logger) *DataMode {
 }
 
 func (h *DataMode) TestMode(w http.ResponseWriter, r *http.Request) {
-	if err := h.mode.SwitchToTest(); err != nil {
+	status, err := h.mode.SwitchToTest()
+	if err != nil {
 		if errors.Is(err, domain.ErrAlreadyOnTestMode) {
 			errorResponse(w, http.StatusBadRequest, err.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrManagerStopped) {
+			errorResponse(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
 
 		h.log.Error(r.Context(), "failed to switch to test mode", "error", err)
 		internalErrorResponse(w, "failed to switch to test mode")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, envelope{"message": "switched to test mode"}, nil)
+	writeJSON(w, http.StatusOK, envelope{"message": "switched to test mode", "data": status}, nil)
 }
 
 func (h *DataMode) LiveMode(w http.ResponseWriter, r *http.Request) {
-	if err := h.mode.SwitchToLive(); err != nil {
+	status, err := h.mode.SwitchToLive()
+	if err != nil {
 		if errors.Is(err, domain.ErrAlreadyOnLiveMode) {
 			errorResponse(w, http.StatusBadRequest, err.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrManagerStopped) {
+			errorResponse(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
 
 		h.log.Error(r.Context(), "failed to switch to live mode", "error", err)
 		internalErrorResponse(w, "failed to switch to live mode")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, envelope{"message": "switched to live mode"}, nil)
+	writeJSON(w, http.StatusOK, envelope{"message": "switched to live mode", "data": status}, nil)
+}
+
+// ExchangeMode returns mode of single exchange
+func (h *DataMode) ExchangeMode(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+
+	v := validator.New()
+	if validateExchange(v, exchange); !v.Valid() {
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	mode := h.mode.ExchangeMode(types.Exchange(exchange))
+	if mode == "" {
+		notFoundErrorResponse(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"data": envelope{"exchange": exchange, "mode": mode}}, nil)
+}
+
+// SwitchExchangeMode switches single exchange to live, test or replay mode
+func (h *DataMode) SwitchExchangeMode(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	log := h.log.GetSlogLogger().With("exchange", exchange)
+
+	var input struct {
+		Mode string `json:"mode"`
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	validateExchange(v, exchange)
+	if validateMode(v, input.Mode); !v.Valid() {
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	status, err := h.mode.SwitchExchange(types.Exchange(exchange), types.Source(input.Mode))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAlreadyOnMode):
+			errorResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, domain.ErrInvalidExchange):
+			notFoundErrorResponse(w)
+		case errors.Is(err, domain.ErrNothingToReplay):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, domain.ErrManagerStopped):
+			errorResponse(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			log.ErrorContext(r.Context(), "failed to switch exchange mode", "mode", input.Mode, "error", err)
+			internalErrorResponse(w, "failed to switch exchange mode")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"message": "switched " + exchange + " to " + input.Mode + " mode", "data": status}, nil)
 }
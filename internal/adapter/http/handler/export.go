@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/validator"
+)
+
+// defaultExportRange is exported when from is not given
+const defaultExportRange = time.Hour
+
+type Exporter interface {
+	Ticks(ctx context.Context, filter domain.ExportFilter, format types.ExportFormat, w io.Writer) (int, error)
+	Stats(ctx context.Context, filter domain.ExportFilter, format types.ExportFormat, w io.Writer) (int, error)
+}
+
+type Export struct {
+	exporter Exporter
+	log      logger.Logger
+}
+
+func NewExport(exporter Exporter, log logger.Logger) *Export {
+	return &Export{
+		exporter: exporter,
+		log:      log,
+	}
+}
+
+// Ticks streams ticks from recent history
+func (h *Export) Ticks(w http.ResponseWriter, r *http.Request) {
+	h.export(w, r, "ticks", h.exporter.Ticks)
+}
+
+// Stats streams aggregated stats
+func (h *Export) Stats(w http.ResponseWriter, r *http.Request) {
+	h.export(w, r, "stats", h.exporter.Stats)
+}
+
+type exportFunc func(ctx context.Context, filter domain.ExportFilter, format types.ExportFormat, w io.Writer) (int, error)
+
+// export validates query and streams rows with chunked transfer encoding
+func (h *Export) export(w http.ResponseWriter, r *http.Request, name string, export exportFunc) {
+	ctx := r.Context()
+	log := h.log.GetSlogLogger().With("export", name)
+
+	filter, format, v := parseExportQuery(r.URL.Query())
+	if !v.Valid() {
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	// Export may take longer than server write timeout, it is stopped when client goes away
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.WarnContext(ctx, "failed to clear write deadline", "error", err)
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == types.FormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s-%s.%s"`,
+		name, filter.From.UTC().Format("20060102T150405Z"), filter.To.UTC().Format("20060102T150405Z"), format))
+
+	fw := &flushWriter{w: w, rc: rc}
+	rows, err := export(ctx, filter, format, fw)
+	if err != nil {
+		if !fw.written {
+			w.Header().Del("Content-Disposition")
+			internalErrorResponse(w, "failed to export "+name)
+			return
+		}
+
+		// Status is already sent, aborting response so client does not take partial export as complete
+		log.ErrorContext(ctx, "export interrupted", "rows", rows, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	log.InfoContext(ctx, "exported", "rows", rows)
+}
+
+// parseExportQuery parses exchange and symbol lists, time range and format of export
+func parseExportQuery(query url.Values) (domain.ExportFilter, types.ExportFormat, *validator.Validator) {
+	v := validator.New()
+	var filter domain.ExportFilter
+
+	for _, exchange := range splitList(query["exchange"]) {
+		validateExchange(v, exchange)
+		filter.Exchanges = append(filter.Exchanges, types.Exchange(exchange))
+	}
+	for _, symbol := range splitList(query["symbol"]) {
+		validateSymbol(v, symbol)
+		filter.Symbols = append(filter.Symbols, types.Symbol(symbol))
+	}
+
+	filter.To = time.Now()
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		v.Check(err == nil, "to", "must be RFC 3339 time, e.g. 2025-01-02T15:04:05Z")
+		filter.To = parsed
+	}
+
+	filter.From = filter.To.Add(-defaultExportRange)
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		v.Check(err == nil, "from", "must be RFC 3339 time, e.g. 2025-01-02T15:04:05Z")
+		filter.From = parsed
+	}
+	if v.Valid() {
+		v.Check(filter.From.Before(filter.To), "from", "must be before to")
+	}
+
+	format := types.FormatCSV
+	if f := query.Get("format"); f != "" {
+		v.Check(types.IsValidExportFormat(f), "format", ErrInvalidExportFormat)
+		format = types.ExportFormat(f)
+	}
+
+	return filter, format, v
+}
+
+// splitList returns values of repeated and comma separated query parameter
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// flushWriter flushes response to the client on request and remembers if anything was written
+type flushWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	written bool
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	fw.written = true
+	return fw.w.Write(p)
+}
+
+func (fw *flushWriter) Flush() error {
+	return fw.rc.Flush()
+}
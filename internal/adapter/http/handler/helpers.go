@@ -3,11 +3,16 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"maps"
 	"net/http"
 	"time"
 )
 
+// maxBodyBytes limits size of JSON request body
+const maxBodyBytes = 1 << 20
+
 type envelope map[string]any
 
 func errorResponse(w http.ResponseWriter, status int, message any) {
@@ -40,6 +45,27 @@ func writeJSON(w http.ResponseWriter, status int, data envelope, headers http.He
 	return nil
 }
 
+// readJSON decodes single JSON value from request body, unknown fields are rejected
+func readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("body must not be empty")
+		}
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
 func internalErrorResponse(w http.ResponseWriter, message any) {
 	errorResponse(w, http.StatusInternalServerError, message)
 }
@@ -34,7 +34,7 @@ func (h *Market) LatestPrice(w http.ResponseWriter, r *http.Request) {
 
 	v := validator.New()
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
@@ -46,7 +46,7 @@ func (h *Market) LatestPrice(w http.ResponseWriter, r *http.Request) {
 			notFoundErrorResponse(w)
 			return
 		}
-		log.Error("failed to get latest data from all exchanges", "error", err)
+		log.ErrorContext(ctx, "failed to get latest data from all exchanges", "error", err)
 		internalErrorResponse(w, "failed to get latest data from all exchanges")
 		return
 	}
@@ -68,7 +68,7 @@ func (h *Market) LatestPriceByExchange(w http.ResponseWriter, r *http.Request) {
 	validateExchange(v, exchange)
 
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
@@ -80,7 +80,7 @@ func (h *Market) LatestPriceByExchange(w http.ResponseWriter, r *http.Request) {
 			notFoundErrorResponse(w)
 			return
 		}
-		log.Error("failed to get latest data from specific exchange", "error", err)
+		log.ErrorContext(ctx, "failed to get latest data from specific exchange", "error", err)
 		internalErrorResponse(w, "failed to get latest data from all exchanges")
 		return
 	}
@@ -106,7 +106,7 @@ func (h *Market) HighestPrice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
@@ -145,7 +145,7 @@ func (h *Market) HighestPriceByExchange(w http.ResponseWriter, r *http.Request)
 	validateExchange(v, exchange)
 
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
@@ -184,7 +184,7 @@ func (h *Market) LowestPrice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
@@ -223,7 +223,7 @@ func (h *Market) LowestPriceByExchange(w http.ResponseWriter, r *http.Request) {
 	validateExchange(v, exchange)
 
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
@@ -262,7 +262,7 @@ func (h *Market) AveragePrice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
@@ -302,7 +302,7 @@ func (h *Market) AveragePriceByExchange(w http.ResponseWriter, r *http.Request)
 	validateExchange(v, exchange)
 
 	if validateSymbol(v, symbol); !v.Valid() {
-		log.Error("failed to validate request", "errors", v.Errors)
+		log.ErrorContext(ctx, "failed to validate request", "errors", v.Errors)
 		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
 		return
 	}
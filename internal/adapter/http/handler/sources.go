@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/validator"
+)
+
+type SourceManager interface {
+	Sources() []domain.SourceInfo
+	Source(exchange types.Exchange) (*domain.SourceInfo, error)
+	Pause(exchange types.Exchange) (*domain.SourceInfo, error)
+	Resume(exchange types.Exchange) (*domain.SourceInfo, error)
+	Restart(exchange types.Exchange) (*domain.SourceInfo, error)
+	Reconfigure(exchange types.Exchange, addr string) (*domain.SourceInfo, error)
+}
+
+type Sources struct {
+	sources SourceManager
+	log     logger.Logger
+}
+
+func NewSources(sources SourceManager, log logger.Logger) *Sources {
+	return &Sources{
+		sources: sources,
+		log:     log,
+	}
+}
+
+// List returns state of all exchange sources
+func (h *Sources) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, envelope{"data": h.sources.Sources()}, nil)
+}
+
+// Get returns state of single exchange source
+func (h *Sources) Get(w http.ResponseWriter, r *http.Request) {
+	exchange, ok := h.exchange(w, r)
+	if !ok {
+		return
+	}
+
+	source, err := h.sources.Source(exchange)
+	if err != nil {
+		h.errorResponse(w, r, "failed to get source", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"data": source}, nil)
+}
+
+// Pause detaches exchange source
+func (h *Sources) Pause(w http.ResponseWriter, r *http.Request) {
+	h.action(w, r, "paused", h.sources.Pause)
+}
+
+// Resume starts paused exchange source
+func (h *Sources) Resume(w http.ResponseWriter, r *http.Request) {
+	h.action(w, r, "resumed", h.sources.Resume)
+}
+
+// Restart reconnects exchange source
+func (h *Sources) Restart(w http.ResponseWriter, r *http.Request) {
+	h.action(w, r, "restarted", h.sources.Restart)
+}
+
+// Reconfigure changes address of exchange source
+func (h *Sources) Reconfigure(w http.ResponseWriter, r *http.Request) {
+	exchange, ok := h.exchange(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Address string `json:"address"`
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	if v.Check(input.Address != "", "address", "must be provided"); !v.Valid() {
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	source, err := h.sources.Reconfigure(exchange, input.Address)
+	if err != nil {
+		h.errorResponse(w, r, "failed to reconfigure source", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"message": "reconfigured " + string(exchange), "data": source}, nil)
+}
+
+func (h *Sources) action(w http.ResponseWriter, r *http.Request, done string, fn func(types.Exchange) (*domain.SourceInfo, error)) {
+	exchange, ok := h.exchange(w, r)
+	if !ok {
+		return
+	}
+
+	source, err := fn(exchange)
+	if err != nil {
+		h.errorResponse(w, r, "failed to manage source", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"message": done + " " + string(exchange), "data": source}, nil)
+}
+
+// exchange validates exchange path value
+func (h *Sources) exchange(w http.ResponseWriter, r *http.Request) (types.Exchange, bool) {
+	exchange := r.PathValue("exchange")
+
+	v := validator.New()
+	if validateExchange(v, exchange); !v.Valid() {
+		errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+		return "", false
+	}
+
+	return types.Exchange(exchange), true
+}
+
+func (h *Sources) errorResponse(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidExchange):
+		notFoundErrorResponse(w)
+	case errors.Is(err, domain.ErrSourcePaused), errors.Is(err, domain.ErrSourceNotPaused), errors.Is(err, domain.ErrNothingToReplay):
+		errorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidAddress):
+		errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, domain.ErrManagerStopped):
+		errorResponse(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		h.log.Error(r.Context(), msg, "exchange", r.PathValue("exchange"), "error", err)
+		errorResponse(w, http.StatusBadGateway, err.Error())
+	}
+}
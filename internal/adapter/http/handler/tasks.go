@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"marketflow/internal/domain"
+	"marketflow/pkg/logger"
+)
+
+type TaskLister interface {
+	Tasks() []domain.TaskInfo
+}
+
+type Tasks struct {
+	tasks TaskLister
+	log   logger.Logger
+}
+
+func NewTasks(tasks TaskLister, log logger.Logger) *Tasks {
+	return &Tasks{
+		tasks: tasks,
+		log:   log,
+	}
+}
+
+// List returns scheduled tasks with their last run, duration, next run and last error
+func (h *Tasks) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, envelope{"data": h.tasks.Tasks()}, nil)
+}
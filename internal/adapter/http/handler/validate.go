@@ -18,7 +18,20 @@ func validateSymbol(v *validator.Validator, symbol string) {
 	v.Check(types.IsValidSymbol(symbol), "symbol", ErrInvalidSymbol)
 }
 
+func validateMode(v *validator.Validator, mode string) {
+	v.Check(mode != "", "mode", "must be provided")
+	v.Check(types.IsValidSource(mode), "mode", ErrInvalidMode)
+}
+
+func validateMetric(v *validator.Validator, metric string) {
+	v.Check(metric != "", "metric", "must be provided")
+	v.Check(types.IsValidMetric(metric), "metric", ErrInvalidMetric)
+}
+
 var (
-	ErrInvalidExchange = fmt.Sprintf("invalid exchange. Available exchanges %v", types.ValidExchanges)
-	ErrInvalidSymbol   = fmt.Sprintf("invalid symbol. Available: %v", types.ValidSymbols)
+	ErrInvalidMetric       = fmt.Sprintf("invalid metric. Available metrics %v", types.ValidMetrics)
+	ErrInvalidExportFormat = fmt.Sprintf("invalid format. Available formats %v", types.ValidExportFormats)
+	ErrInvalidMode         = fmt.Sprintf("invalid mode. Available modes %v", types.ValidSources)
+	ErrInvalidExchange     = fmt.Sprintf("invalid exchange. Available exchanges %v", types.ValidExchanges)
+	ErrInvalidSymbol       = fmt.Sprintf("invalid symbol. Available: %v", types.ValidSymbols)
 )
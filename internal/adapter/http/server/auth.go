@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+)
+
+// Authenticator verifies API keys
+type Authenticator interface {
+	Authenticate(ctx context.Context, plain string) (*domain.APIKey, error)
+}
+
+type contextKey string
+
+const apiKeyContextKey contextKey = "api_key"
+
+// APIKeyFromContext returns API key of authenticated request, nil if request is anonymous
+func APIKeyFromContext(ctx context.Context) *domain.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey).(*domain.APIKey)
+	return key
+}
+
+// requireScope allows request only with API key granting the scope. All requests are allowed if auth is disabled.
+func (a *API) requireScope(scope types.Scope, next http.HandlerFunc) http.HandlerFunc {
+	if a.auth == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		plain := a.apiKey(r)
+		if plain == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="marketflow"`)
+			writeError(w, http.StatusUnauthorized, "API key required in "+a.authCfg.Header+" header")
+			return
+		}
+
+		key, ok := a.authenticate(w, r, plain)
+		if !ok {
+			return
+		}
+
+		if !key.HasScope(scope) {
+			writeError(w, http.StatusForbidden, "API key does not grant "+string(scope)+" scope")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, key)))
+	}
+}
+
+// optionalKey authenticates API key of request if it has one, so requests to public routes
+// are rate limited per key. Requests without key pass anonymous, invalid keys are rejected.
+func (a *API) optionalKey(next http.HandlerFunc) http.HandlerFunc {
+	if a.auth == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		plain := a.apiKey(r)
+		if plain == "" {
+			next(w, r)
+			return
+		}
+
+		key, ok := a.authenticate(w, r, plain)
+		if !ok {
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, key)))
+	}
+}
+
+// authenticate verifies API key, writes error response if it fails
+func (a *API) authenticate(w http.ResponseWriter, r *http.Request, plain string) (*domain.APIKey, bool) {
+	key, err := a.auth.Authenticate(r.Context(), plain)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="marketflow", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return nil, false
+		}
+		a.log.Error(r.Context(), "failed to authenticate api key", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to authenticate API key")
+		return nil, false
+	}
+	return key, true
+}
+
+// requireRead protects market data routes unless they are configured public.
+// Keys sent to public routes are still resolved for rate limiting.
+func (a *API) requireRead(next http.HandlerFunc) http.HandlerFunc {
+	if a.authCfg.PublicRead {
+		return a.optionalKey(next)
+	}
+	return a.requireScope(types.ScopeRead, next)
+}
+
+// requireAdmin protects mode switching and admin routes
+func (a *API) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return a.requireScope(types.ScopeAdmin, next)
+}
+
+// apiKey returns key from configured header or from "Authorization: Bearer" header
+func (a *API) apiKey(r *http.Request) string {
+	if key := r.Header.Get(a.authCfg.Header); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"error": message})
+}
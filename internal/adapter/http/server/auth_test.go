@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"marketflow/config"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+func TestMetricsRequireReadKey(t *testing.T) {
+	var cfg config.Config
+	cfg.Auth.PublicRead = true
+	cfg.Auth.Header = "X-API-Key"
+
+	api := New(cfg, types.RoleAll, Options{
+		Authenticator: fakeAuthenticator{
+			"reader": {ID: 1, Scopes: []types.Scope{types.ScopeRead}},
+			"none":   {ID: 2},
+		},
+	}, logger.InitLogger(context.Background(), "error"))
+
+	for key, want := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"none":   http.StatusForbidden,
+		"reader": http.StatusOK,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		api.router.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("metrics with key %q got %d, want %d", key, w.Code, want)
+		}
+	}
+}
@@ -1,10 +1,58 @@
 package server
 
 import (
+	"bufio"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
 	"time"
+
+	"marketflow/pkg/logger"
 )
 
+const requestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware takes request ID from X-Request-ID header or generates new one,
+// puts it into context, so every log line of the request carries it, and returns it in response
+func (m *API) RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts only short IDs of safe characters, so client can not inject into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 // LoggingMiddleware wraps an http.Handler and logs requests
 func (m *API) LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -21,23 +69,242 @@ func (m *API) LoggingMiddleware(next http.Handler) http.Handler {
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", lrw.statusCode,
+			"bytes", lrw.bytes,
 			"duration", time.Since(start),
 			"remote_ip", r.RemoteAddr,
 		)
 	})
 }
 
+// RecoverMiddleware turns panic in handler into JSON 500 response and logs it with stack trace
+func (m *API) RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lrw, ok := w.(*loggingResponseWriter)
+		if !ok {
+			lrw = NewLoggingResponseWriter(w)
+		}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Aborting the response is the way to stop the handler, not an error
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			m.log.Error(r.Context(), "panic in handler",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(rec),
+				"stack", string(debug.Stack()),
+			)
+
+			if lrw.wroteHeader {
+				// Response is already started, the only option is to abort the connection
+				panic(http.ErrAbortHandler)
+			}
+			lrw.Header().Set("Connection", "close")
+			writeError(lrw, http.StatusInternalServerError, "internal server error")
+		}()
+
+		next.ServeHTTP(lrw, r)
+	})
+}
+
+// CORSMiddleware allows configured origins, e.g. dashboards served from other hosts, to call the API
+func (m *API) CORSMiddleware(next http.Handler) http.Handler {
+	origins := strings.Split(m.cfg.CORSAllowedOrigins, ",")
+	for i := range origins {
+		origins[i] = strings.TrimSpace(origins[i])
+	}
+	allowAny := slices.Contains(origins, "*")
+
+	allowHeaders := strings.Join([]string{"Content-Type", "Authorization", m.authCfg.Header, requestIDHeader}, ", ")
+	exposeHeaders := strings.Join([]string{requestIDHeader, "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if !allowAny && !slices.Contains(origins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", exposeHeaders)
+
+		// Preflight request
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PATCH, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", allowHeaders)
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// GzipMiddleware compresses responses for clients accepting gzip
+func (m *API) GzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// Ranges of compressed body would not match ranges of the file
+		if !acceptsGzip(r) || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(coding) == "gzip" {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter decides whether to compress on first write, when headers are known
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.decided = true
+		if compressible(code, w.Header()) {
+			w.Header().Del("Content-Length")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.gz = gzipWriters.Get().(*gzip.Writer)
+			w.gz.Reset(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends compressed data written so far, used by streaming responses
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *gzipResponseWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	w.gz.Close()
+	w.gz.Reset(nil)
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+}
+
+// compressible reports whether response worth compressing: it has body, is not encoded yet and is not compressed format
+func compressible(code int, h http.Header) bool {
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "application/octet-stream"} {
+		if strings.HasPrefix(contentType, prefix) && contentType != "image/svg+xml" {
+			return false
+		}
+	}
+	return true
+}
+
 // loggingResponseWriter wraps http.ResponseWriter to capture the status code
 type loggingResponseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	bytes       int
+	wroteHeader bool
 }
 
 func NewLoggingResponseWriter(w http.ResponseWriter) *loggingResponseWriter {
-	return &loggingResponseWriter{w, http.StatusOK} // Default to 200 OK
+	return &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK} // Default to 200 OK
 }
 
 func (lrw *loggingResponseWriter) WriteHeader(code int) {
-	lrw.statusCode = code
+	if !lrw.wroteHeader {
+		lrw.statusCode = code
+		lrw.wroteHeader = true
+	}
 	lrw.ResponseWriter.WriteHeader(code)
 }
+
+func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
+	lrw.wroteHeader = true
+	n, err := lrw.ResponseWriter.Write(b)
+	lrw.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach Flush and Hijack of the underlying writer
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+// Hijack is needed for protocol upgrades through the wrapped writer
+func (lrw *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := lrw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking is not supported")
+	}
+	return hj.Hijack()
+}
+
+// Flush implements http.Flusher for streaming responses
+func (lrw *loggingResponseWriter) Flush() {
+	lrw.wroteHeader = true
+	http.NewResponseController(lrw.ResponseWriter).Flush()
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"net/http"
+)
+
+// openAPISpec documents every route registered in setupRoutes, openapi_test.go keeps them in sync
+//
+//go:embed static/openapi.json
+var openAPISpec []byte
+
+//go:embed static/docs.html
+var docsPage []byte
+
+// docsPolicy lets docs page run only its own inline script and fetch the document from the same origin
+var docsPolicy = "default-src 'none'; style-src 'unsafe-inline'; connect-src 'self'; script-src " + inlineScriptHash(docsPage)
+
+// inlineScriptHash returns CSP source of the first inline script of page
+func inlineScriptHash(page []byte) string {
+	_, script, _ := bytes.Cut(page, []byte("<script>"))
+	script, _, _ = bytes.Cut(script, []byte("</script>"))
+	sum := sha256.Sum256(script)
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+// OpenAPI serves OpenAPI document of the API
+func (a *API) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// Docs serves page rendering OpenAPI document
+func (a *API) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", docsPolicy)
+	w.Write(docsPage)
+}
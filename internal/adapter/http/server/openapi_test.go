@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketflow/config"
+	"marketflow/internal/adapter/http/handler"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// Components are only checked for nil when routes are registered, so embedded nil interfaces are enough
+type (
+	fakeMarket        struct{ ports.Market }
+	fakeModeSwitcher  struct{ handler.ModeSwitcher }
+	fakeTaskLister    struct{ handler.TaskLister }
+	fakeSourceManager struct{ handler.SourceManager }
+	fakeExporter      struct{ handler.Exporter }
+	fakeArchiveReader struct{ handler.ArchiveReader }
+	fakeCoverage      struct{ handler.CoverageReporter }
+)
+
+// TestOpenAPICoversRoutes fails when a route is registered without spec entry or spec documents unknown route
+func TestOpenAPICoversRoutes(t *testing.T) {
+	api := New(config.Config{}, types.RoleAll, Options{
+		Market:        fakeMarket{},
+		ModeSwitcher:  fakeModeSwitcher{},
+		TaskLister:    fakeTaskLister{},
+		SourceManager: fakeSourceManager{},
+		Exporter:      fakeExporter{},
+		ArchiveReader: fakeArchiveReader{},
+		Coverage:      fakeCoverage{},
+	}, logger.InitLogger(context.Background(), "error"))
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("failed to parse OpenAPI document: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Fatalf("expected OpenAPI 3 document, got version %q", spec.OpenAPI)
+	}
+
+	registered := make(map[string]bool)
+	for _, pattern := range api.patterns {
+		method, path := routeOperation(pattern)
+		registered[method+" "+path] = true
+
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("route %q is not documented in OpenAPI document, add %s %s", pattern, strings.ToUpper(method), path)
+		}
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				t.Errorf("OpenAPI document has %s %s, but no such route is registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	api := New(config.Config{}, types.RoleAPI, Options{}, logger.InitLogger(context.Background(), "error"))
+
+	for path, contentType := range map[string]string{
+		"/openapi.json": "application/json",
+		"/docs":         "text/html",
+	} {
+		rec := httptest.NewRecorder()
+		api.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: expected status 200, got %d", path, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, contentType) {
+			t.Errorf("GET %s: expected content type %s, got %s", path, contentType, got)
+		}
+	}
+}
+
+func TestDocsPageRunsOnlyOwnScript(t *testing.T) {
+	api := New(config.Config{}, types.RoleAPI, Options{}, logger.InitLogger(context.Background(), "error"))
+	rec := httptest.NewRecorder()
+	api.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	page := rec.Body.String()
+	if strings.Contains(page, "://") {
+		t.Fatal("docs page must not load third-party resources")
+	}
+	if strings.Count(page, "<script") != 1 {
+		t.Fatal("docs page must have single inline script allowed by its hash")
+	}
+	if policy := rec.Header().Get("Content-Security-Policy"); !strings.Contains(policy, "script-src 'sha256-") {
+		t.Fatalf("docs page must allow only its script, got policy %q", policy)
+	}
+}
+
+// routeOperation splits ServeMux pattern into lowercase method and path, patterns without method are documented as GET
+func routeOperation(pattern string) (method, path string) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return "get", pattern
+	}
+	return strings.ToLower(method), strings.TrimSpace(path)
+}
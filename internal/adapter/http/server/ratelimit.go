@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/pkg/ratelimit"
+)
+
+// Cost of routes in tokens, routes reading Postgres or switching sources are more expensive
+const (
+	costLatest = 1
+	costStats  = 2
+	costBatch  = 10 // up to maxBatchQueries lookups in a few Redis round trips
+	costExport = 20 // streams whole time range
+	costAdmin  = 1
+	costSwitch = 5
+)
+
+// rateLimit limits requests per API key, or per client IP for anonymous requests.
+// Must be wrapped by auth middleware to limit by API key. Requests pass if store fails.
+func (a *API) rateLimit(cost int, next http.HandlerFunc) http.HandlerFunc {
+	if a.limiter == nil {
+		return next
+	}
+
+	limit := ratelimit.Limit{Rate: a.rateCfg.Rate, Burst: a.rateCfg.Burst}
+	cost = min(cost, limit.Burst) // more than burst would never be allowed
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := a.limiter.Take(r.Context(), a.clientKey(r), cost, limit)
+		if err != nil {
+			a.log.Warn(r.Context(), "rate limit store failed, request is not limited", "error", err)
+			next(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(windowSeconds(limit)))
+		h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded, retry later")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// clientKey returns API key id of request with API key, otherwise client IP.
+// Keys are resolved on public routes too, so clients sending them are never limited per IP.
+func (a *API) clientKey(r *http.Request) string {
+	if key := APIKeyFromContext(r.Context()); key != nil {
+		return "key:" + strconv.Itoa(key.ID)
+	}
+	return "ip:" + a.clientIP(r)
+}
+
+// clientIP returns peer address, or with trusted proxy the rightmost X-Forwarded-For entry not added
+// by a trusted proxy. Entries on the left are set by client and can be forged.
+func (a *API) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !a.rateCfg.TrustProxy {
+		return host
+	}
+	// Without configured proxies the direct peer is trusted, peers of unix socket are local
+	if len(a.trustedProxies) > 0 && a.cfg.UnixSocket == "" && !a.trustedProxy(host) {
+		return host
+	}
+
+	var entries []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		entries = append(entries, strings.Split(value, ",")...)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(entries[i])
+		if ip != "" && !a.trustedProxy(ip) {
+			return ip
+		}
+	}
+	return host
+}
+
+// trustedProxy reports whether address is in configured trusted proxies
+func (a *API) trustedProxy(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range a.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses comma separated IPs and CIDRs
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+			}
+			ip = ip.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// windowSeconds is time to refill empty bucket
+func windowSeconds(limit ratelimit.Limit) int {
+	return int(math.Ceil(float64(limit.Burst) / limit.Rate))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
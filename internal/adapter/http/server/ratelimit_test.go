@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/ratelimit"
+)
+
+type fakeAuthenticator map[string]*domain.APIKey
+
+func (a fakeAuthenticator) Authenticate(ctx context.Context, plain string) (*domain.APIKey, error) {
+	if key, ok := a[plain]; ok {
+		return key, nil
+	}
+	return nil, domain.ErrInvalidAPIKey
+}
+
+func TestClientIP(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		trust     bool
+		proxies   string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"proxy not trusted", false, "", "10.0.0.1:1234", []string{"1.1.1.1"}, "10.0.0.1"},
+		{"peer is trusted proxy", true, "", "10.0.0.1:1234", []string{"6.6.6.6, 1.1.1.1"}, "1.1.1.1"},
+		{"no forwarded header", true, "", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"trusted proxies are skipped", true, "10.0.0.0/8, 192.168.1.1", "10.0.0.1:1234", []string{"6.6.6.6, 1.1.1.1, 192.168.1.1", "10.0.0.2"}, "1.1.1.1"},
+		{"peer is not trusted proxy", true, "10.0.0.0/8", "2.2.2.2:1234", []string{"1.1.1.1"}, "2.2.2.2"},
+		{"all entries are trusted", true, "10.0.0.0/8", "10.0.0.1:1234", []string{"10.0.0.2"}, "10.0.0.1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			proxies, err := parseTrustedProxies(tc.proxies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := &API{rateCfg: config.RateLimit{TrustProxy: tc.trust}, trustedProxies: proxies}
+
+			r := httptest.NewRequest(http.MethodGet, "/prices/latest/BTCUSDT", nil)
+			r.RemoteAddr = tc.remote
+			for _, value := range tc.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := a.clientIP(r); got != tc.want {
+				t.Fatalf("clientIP = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := parseTrustedProxies("10.0.0.0/8,not-an-ip"); err == nil {
+		t.Fatal("invalid trusted proxy must fail")
+	}
+}
+
+func TestPublicRouteLimitsPerKey(t *testing.T) {
+	var cfg config.Config
+	cfg.Auth.PublicRead = true
+	cfg.Auth.Header = "X-API-Key"
+	cfg.RateLimit.Rate, cfg.RateLimit.Burst = 0.001, 1
+
+	a := &API{
+		auth:    fakeAuthenticator{"reader": {ID: 7, Scopes: []types.Scope{types.ScopeRead}}},
+		authCfg: cfg.Auth,
+		limiter: ratelimit.NewMemory(),
+		rateCfg: cfg.RateLimit,
+		log:     logger.InitLogger(context.Background(), "error"),
+	}
+	route := a.requireRead(a.rateLimit(1, func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(remote, key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/prices/latest/BTCUSDT", nil)
+		r.RemoteAddr = remote
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		route(w, r)
+		return w.Code
+	}
+
+	// key is limited across client addresses
+	if code := serve("1.1.1.1:1", "reader"); code != http.StatusOK {
+		t.Fatalf("first request with key got %d", code)
+	}
+	if code := serve("2.2.2.2:1", "reader"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request with key from other address got %d, want 429", code)
+	}
+
+	// anonymous requests are limited per address, not by the key used from the same address
+	if code := serve("1.1.1.1:1", ""); code != http.StatusOK {
+		t.Fatalf("anonymous request got %d", code)
+	}
+	if code := serve("3.3.3.3:1", "unknown"); code != http.StatusUnauthorized {
+		t.Fatalf("invalid key on public route got %d, want 401", code)
+	}
+}
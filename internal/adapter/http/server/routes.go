@@ -3,41 +3,108 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/metrics"
 )
 
 // setupRoutes - setups http routes
 func (a *API) setupRoutes() {
-	// Frontend
-	a.router.Handle("/", http.FileServer(http.Dir("./frontend")))
-
 	// System Health
-	a.router.HandleFunc("/health", a.HealthCheck)
+	a.handleFunc("/health", a.HealthCheck)
+	// Metrics expose internals, so they need a key even if market data is public
+	a.handleFunc("GET /metrics", a.requireScope(types.ScopeRead, metrics.Handler().ServeHTTP))
+
+	// API documentation
+	a.handleFunc("GET /openapi.json", a.OpenAPI)
+	a.handleFunc("GET /docs", a.Docs)
+
+	if a.routes.market != nil {
+		a.setupMarketRoutes()
+	}
+
+	// Export of historical data
+	if a.routes.export != nil {
+		a.handleFunc("GET /export/ticks", a.requireRead(a.rateLimit(costExport, a.routes.export.Ticks)))
+		a.handleFunc("GET /export/stats", a.requireRead(a.rateLimit(costExport, a.routes.export.Stats)))
+	}
+
+	// Archived ticks
+	if a.routes.archives != nil {
+		a.handleFunc("GET /archives", a.requireRead(a.rateLimit(costStats, a.routes.archives.List)))
+		a.handleFunc("GET /archives/{path...}", a.requireRead(a.rateLimit(costExport, a.routes.archives.Fetch)))
+	}
+
+	// Minutes with live data and gaps
+	if a.routes.coverage != nil {
+		a.handleFunc("GET /coverage/{exchange}/{symbol}", a.requireRead(a.rateLimit(costExport, a.routes.coverage.Get)))
+	}
+
+	// Mode switching and admin routes require admin scope
+	if a.routes.mode != nil {
+		// Data Mode
+		a.handleFunc("POST /mode/test", a.requireAdmin(a.rateLimit(costSwitch, a.routes.mode.TestMode)))
+		a.handleFunc("POST /mode/live", a.requireAdmin(a.rateLimit(costSwitch, a.routes.mode.LiveMode)))
+		a.handleFunc("GET /mode/{exchange}", a.requireAdmin(a.rateLimit(costAdmin, a.routes.mode.ExchangeMode)))
+		a.handleFunc("POST /mode/{exchange}", a.requireAdmin(a.rateLimit(costSwitch, a.routes.mode.SwitchExchangeMode)))
+	}
+
+	// Admin
+	if a.routes.tasks != nil {
+		a.handleFunc("GET /admin/tasks", a.requireAdmin(a.rateLimit(costAdmin, a.routes.tasks.List)))
+	}
+	if a.routes.sources != nil {
+		a.handleFunc("GET /admin/sources", a.requireAdmin(a.rateLimit(costAdmin, a.routes.sources.List)))
+		a.handleFunc("GET /admin/sources/{exchange}", a.requireAdmin(a.rateLimit(costAdmin, a.routes.sources.Get)))
+		a.handleFunc("PATCH /admin/sources/{exchange}", a.requireAdmin(a.rateLimit(costSwitch, a.routes.sources.Reconfigure)))
+		a.handleFunc("POST /admin/sources/{exchange}/pause", a.requireAdmin(a.rateLimit(costSwitch, a.routes.sources.Pause)))
+		a.handleFunc("POST /admin/sources/{exchange}/resume", a.requireAdmin(a.rateLimit(costSwitch, a.routes.sources.Resume)))
+		a.handleFunc("POST /admin/sources/{exchange}/restart", a.requireAdmin(a.rateLimit(costSwitch, a.routes.sources.Restart)))
+	}
+}
+
+// setupMarketRoutes - setups frontend and market data routes
+func (a *API) setupMarketRoutes() {
+	// Frontend
+	a.handle("/", http.FileServer(http.Dir("./frontend")))
 
 	// Market Data API
 	// Latest
-	a.router.HandleFunc("/prices/latest/{symbol}", a.routes.market.LatestPrice)
-	a.router.HandleFunc("/prices/latest/{exchange}/{symbol}", a.routes.market.LatestPriceByExchange)
+	a.handleFunc("/prices/latest/{symbol}", a.requireRead(a.rateLimit(costLatest, a.routes.market.LatestPrice)))
+	a.handleFunc("/prices/latest/{exchange}/{symbol}", a.requireRead(a.rateLimit(costLatest, a.routes.market.LatestPriceByExchange)))
 
 	// Highest
-	a.router.HandleFunc("/prices/highest/{symbol}", a.routes.market.HighestPrice)
-	a.router.HandleFunc("/prices/highest/{exchange}/{symbol}", a.routes.market.HighestPriceByExchange)
+	a.handleFunc("/prices/highest/{symbol}", a.requireRead(a.rateLimit(costStats, a.routes.market.HighestPrice)))
+	a.handleFunc("/prices/highest/{exchange}/{symbol}", a.requireRead(a.rateLimit(costStats, a.routes.market.HighestPriceByExchange)))
 
 	// Lowest
-	a.router.HandleFunc("/prices/lowest/{symbol}", a.routes.market.LowestPrice)
-	a.router.HandleFunc("/prices/lowest/{exchange}/{symbol}", a.routes.market.LowestPriceByExchange)
+	a.handleFunc("/prices/lowest/{symbol}", a.requireRead(a.rateLimit(costStats, a.routes.market.LowestPrice)))
+	a.handleFunc("/prices/lowest/{exchange}/{symbol}", a.requireRead(a.rateLimit(costStats, a.routes.market.LowestPriceByExchange)))
 
 	// Average
-	a.router.HandleFunc("/prices/average/{symbol}", a.routes.market.AveragePrice)
-	a.router.HandleFunc("/prices/average/{exchange}/{symbol}", a.routes.market.AveragePriceByExchange)
+	a.handleFunc("/prices/average/{symbol}", a.requireRead(a.rateLimit(costStats, a.routes.market.AveragePrice)))
+	a.handleFunc("/prices/average/{exchange}/{symbol}", a.requireRead(a.rateLimit(costStats, a.routes.market.AveragePriceByExchange)))
+
+	// Batch of many queries
+	a.handleFunc("POST /prices/batch", a.requireRead(a.rateLimit(costBatch, a.routes.market.PriceBatch)))
+}
+
+// handle registers handler and remembers its pattern to check it against OpenAPI document
+func (a *API) handle(pattern string, handler http.Handler) {
+	a.router.Handle(pattern, handler)
+	a.patterns = append(a.patterns, pattern)
+}
 
-	// Data Mode
-	a.router.HandleFunc("/mode/test", a.routes.mode.TestMode)
-	a.router.HandleFunc("/mode/live", a.routes.mode.LiveMode)
+func (a *API) handleFunc(pattern string, handler http.HandlerFunc) {
+	a.handle(pattern, handler)
 }
 
 var (
 	StatusAvailable          = "available"
 	StatusPartiallyAvailable = "partially_available"
+	StatusDegraded           = "degraded"
 	StatusHealthy            = "healthy"
 	StatusUnhealthy          = "unhealthy"
 )
@@ -81,16 +148,72 @@ func (a *API) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		statusCode = http.StatusServiceUnavailable // 503
 	}
 
+	// Services are up, but some exchanges or symbols do not receive ticks
+	var freshness *domain.Freshness
+	if a.freshness != nil {
+		freshness = a.freshness.Freshness()
+		if status == StatusAvailable && (freshness.Status == types.FreshnessDegraded || freshness.Status == types.FreshnessStale) {
+			status = StatusDegraded
+		}
+	}
+
+	// Feeds with expired certificates can not reconnect
+	var certificates []domain.Certificate
+	if a.certificates != nil {
+		certificates = a.certificates.Certificates()
+		expired := slices.ContainsFunc(certificates, func(c domain.Certificate) bool { return c.Expired })
+		if status == StatusAvailable && expired {
+			status = StatusDegraded
+		}
+	}
+
 	// Prepare response
+	systemInfo := map[string]any{
+		"address":       a.addr,
+		"role":          a.role,
+		"status":        status,
+		"total_healthy": healthyServices,
+		"total":         totalServices,
+		"services":      serviceStatuses,
+	}
+
+	// Data mode is known only by the role that receives data from exchanges
+	if a.modeProvider != nil {
+		systemInfo["data_mode"] = a.modeProvider.Mode()
+		systemInfo["data_mode_state"] = a.modeProvider.State()
+		systemInfo["exchange_modes"] = a.modeProvider.ExchangeModes()
+	}
+
+	if freshness != nil {
+		systemInfo["freshness"] = freshness
+	}
+
+	if certificates != nil {
+		systemInfo["certificates"] = certificates
+	}
+
+	// Ticks are recorded for audit by the role that stores them
+	if a.tickWriter != nil {
+		systemInfo["tick_writer"] = a.tickWriter.TickWriterStats()
+	}
+
+	// Latency of live ticks is measured by the role that receives them
+	if a.feedProvider != nil {
+		systemInfo["feed_latency"] = a.feedProvider.FeedLatency()
+	}
+
+	// Leader election is done by the role running singleton duties
+	if a.leaderProvider != nil {
+		leadership, err := a.leaderProvider.Leadership(r.Context())
+		if err != nil {
+			a.log.Error(r.Context(), "failed to get leadership", "error", err)
+		} else {
+			systemInfo["leader"] = leadership
+		}
+	}
+
 	response := map[string]any{
-		"system_info": map[string]any{
-			"address":       a.addr,
-			"data_mode":     a.modeProvider.Mode(),
-			"status":        status,
-			"total_healthy": healthyServices,
-			"total":         totalServices,
-			"services":      serviceStatuses,
-		},
+		"system_info": systemInfo,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
-	"time"
+	"net/netip"
+	"os"
 
 	"marketflow/config"
 	"marketflow/internal/adapter/http/handler"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
 	"marketflow/internal/ports"
 	"marketflow/pkg/logger"
+	"marketflow/pkg/ratelimit"
 )
 
 const serverIPAddress = "0.0.0.0:%d"
@@ -23,42 +29,116 @@ type Service interface {
 
 type ModeProvider interface {
 	Mode() string
+	State() types.PipelineState
+	ExchangeModes() map[types.Exchange]types.Source
+}
+
+// LeaderProvider reports leader election of singleton duties
+type LeaderProvider interface {
+	Leadership(ctx context.Context) (*domain.Leadership, error)
+}
+
+// FeedProvider reports latency and clock skew of live exchanges
+type FeedProvider interface {
+	FeedLatency() []domain.FeedLatency
+}
+
+// FreshnessProvider reports age of the last ticks of exchanges
+type FreshnessProvider interface {
+	Freshness() *domain.Freshness
+}
+
+// CertificateProvider reports TLS certificates of exchange connections
+type CertificateProvider interface {
+	Certificates() []domain.Certificate
+}
+
+// TickWriterProvider reports ticks persisted for audit and lost on the way
+type TickWriterProvider interface {
+	TickWriterStats() domain.TickWriterStats
 }
 
 type API struct {
-	cfg    config.HTTPServer
-	router *http.ServeMux
-	server *http.Server
+	cfg      config.HTTPServer
+	router   *http.ServeMux
+	server   *http.Server
+	patterns []string // registered route patterns
+
+	cancelBase context.CancelFunc // cancels contexts of in-flight requests
 
 	addr string
+	role types.Role
 
-	routes       *handlers // routes/handlers
-	services     []Service
-	modeProvider ModeProvider
-	log          logger.Logger
+	routes         *handlers // routes/handlers, nil if not running in the role
+	services       []Service
+	modeProvider   ModeProvider
+	leaderProvider LeaderProvider
+	feedProvider   FeedProvider
+	freshness      FreshnessProvider
+	certificates   CertificateProvider
+	tickWriter     TickWriterProvider
+	auth           Authenticator // nil if authentication is disabled
+	authCfg        config.Auth
+	limiter        ratelimit.Store // nil if rate limiting is disabled
+	rateCfg        config.RateLimit
+	trustedProxies []netip.Prefix // parsed by Run
+	log            logger.Logger
 }
 
 type handlers struct {
-	market handler.Market
-	mode   handler.DataMode
+	market   *handler.Market
+	mode     *handler.DataMode
+	tasks    *handler.Tasks
+	sources  *handler.Sources
+	export   *handler.Export
+	archives *handler.Archives
+	coverage *handler.Coverage
 }
 
-func New(
-	cfg config.Config,
-	market ports.Market,
-	manager handler.ModeSwitcher,
-	services []Service,
-	modeProvider ModeProvider,
-	logger logger.Logger,
-) *API {
-	addr := fmt.Sprintf(serverIPAddress, cfg.Server.HTTPServer.Port)
+// Options defines components served by API. Routes of nil components are not registered.
+type Options struct {
+	Market              ports.Market
+	ModeSwitcher        handler.ModeSwitcher
+	Services            []Service
+	ModeProvider        ModeProvider
+	LeaderProvider      LeaderProvider
+	FeedProvider        FeedProvider
+	FreshnessProvider   FreshnessProvider
+	CertificateProvider CertificateProvider
+	TickWriterProvider  TickWriterProvider
+	TaskLister          handler.TaskLister
+	SourceManager       handler.SourceManager
+	Exporter            handler.Exporter
+	ArchiveReader       handler.ArchiveReader
+	Coverage            handler.CoverageReporter
+	Authenticator       Authenticator   // nil disables authentication
+	RateLimiter         ratelimit.Store // nil disables rate limiting
+}
 
-	marketHandler := handler.NewMarket(market, logger)
-	dataModeHandler := handler.NewDataMode(manager, logger)
+func New(cfg config.Config, role types.Role, opts Options, logger logger.Logger) *API {
+	addr := fmt.Sprintf(serverIPAddress, cfg.Server.HTTPServer.Port)
 
-	handlers := &handlers{
-		market: *marketHandler,
-		mode:   *dataModeHandler,
+	handlers := &handlers{}
+	if opts.Market != nil {
+		handlers.market = handler.NewMarket(opts.Market, logger)
+	}
+	if opts.ModeSwitcher != nil {
+		handlers.mode = handler.NewDataMode(opts.ModeSwitcher, logger)
+	}
+	if opts.TaskLister != nil {
+		handlers.tasks = handler.NewTasks(opts.TaskLister, logger)
+	}
+	if opts.SourceManager != nil {
+		handlers.sources = handler.NewSources(opts.SourceManager, logger)
+	}
+	if opts.Exporter != nil {
+		handlers.export = handler.NewExport(opts.Exporter, logger)
+	}
+	if opts.ArchiveReader != nil {
+		handlers.archives = handler.NewArchives(opts.ArchiveReader, logger)
+	}
+	if opts.Coverage != nil {
+		handlers.coverage = handler.NewCoverage(opts.Coverage, logger)
 	}
 
 	// Setup routes
@@ -67,17 +147,43 @@ func New(
 	api := &API{
 		router:   mux,
 		routes:   handlers,
-		services: services,
+		services: opts.Services,
 
-		addr:         addr,
-		cfg:          cfg.Server.HTTPServer,
-		modeProvider: modeProvider,
-		log:          logger,
+		addr:           addr,
+		role:           role,
+		cfg:            cfg.Server.HTTPServer,
+		modeProvider:   opts.ModeProvider,
+		feedProvider:   opts.FeedProvider,
+		freshness:      opts.FreshnessProvider,
+		certificates:   opts.CertificateProvider,
+		tickWriter:     opts.TickWriterProvider,
+		leaderProvider: opts.LeaderProvider,
+		auth:           opts.Authenticator,
+		authCfg:        cfg.Auth,
+		limiter:        opts.RateLimiter,
+		rateCfg:        cfg.RateLimit,
+		log:            logger,
 	}
 
+	if api.cfg.UnixSocket != "" {
+		api.addr = "unix:" + api.cfg.UnixSocket
+	}
+
+	// Requests contexts are derived from base context, it is cancelled if requests do not drain on shutdown
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	api.cancelBase = cancelBase
+
 	api.server = &http.Server{
-		Addr:    api.addr,
-		Handler: api.router,
+		Handler:           api.applyMiddlewares(api.router),
+		ReadTimeout:       api.cfg.ReadTimeout,
+		ReadHeaderTimeout: api.cfg.ReadHeaderTimeout,
+		WriteTimeout:      api.cfg.WriteTimeout,
+		IdleTimeout:       api.cfg.IdleTimeout,
+		MaxHeaderBytes:    api.cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.GetSlogLogger().Handler(), slog.LevelWarn),
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
 	api.setupRoutes()
@@ -85,29 +191,94 @@ func New(
 	return api
 }
 
-func (a *API) Stop() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// Run starts listening and serves requests in background. Listening errors are returned,
+// errors of serving are sent to errCh.
+func (a *API) Run(errCh chan<- error) error {
+	if (a.cfg.TLSCertFile == "") != (a.cfg.TLSKeyFile == "") {
+		return errors.New("both TLS cert and key files must be set to enable TLS")
+	}
+	tlsEnabled := a.cfg.TLSCertFile != ""
 
-	a.log.Info(ctx, "Shutting down HTTP server...", "Address", a.addr)
-	if err := a.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("error shutting down server: %w", err)
+	trustedProxies, err := parseTrustedProxies(a.rateCfg.TrustedProxies)
+	if err != nil {
+		return err
 	}
+	a.trustedProxies = trustedProxies
 
-	return nil
-}
+	ln, err := a.listen()
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", a.addr, err)
+	}
 
-func (a *API) Run(errCh chan<- error) {
 	go func() {
-		a.log.Info(context.Background(), "Started http server", "Address", a.addr)
-		if err := http.ListenAndServe(a.addr, a.applyMiddlewares(a.router)); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			errCh <- fmt.Errorf("failed to start HTTP server: %w", err)
-			return
+		a.log.Info(context.Background(), "Started http server", "Address", a.addr, "tls", tlsEnabled)
+
+		var err error
+		if tlsEnabled {
+			err = a.server.ServeTLS(ln, a.cfg.TLSCertFile, a.cfg.TLSKeyFile)
+		} else {
+			err = a.server.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("failed to serve HTTP: %w", err)
 		}
 	}()
+
+	return nil
+}
+
+// listen listens on unix socket if it is configured, otherwise on TCP port
+func (a *API) listen() (net.Listener, error) {
+	if a.cfg.UnixSocket == "" {
+		return net.Listen("tcp", a.addr)
+	}
+
+	// Removing socket left by previous process, other files are not touched
+	if info, err := os.Stat(a.cfg.UnixSocket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(a.cfg.UnixSocket); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	return net.Listen("unix", a.cfg.UnixSocket)
 }
 
-// applyMiddlewares to wrap default http.ServeMux
+// Stop stops accepting connections and waits until in-flight requests, including streaming ones, are done.
+// If they do not finish within shutdown timeout, their contexts are cancelled and connections are closed.
+func (a *API) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	a.log.Info(ctx, "Shutting down HTTP server...", "Address", a.addr, "timeout", a.cfg.ShutdownTimeout)
+	err := a.server.Shutdown(ctx)
+	if err == nil {
+		a.cancelBase()
+		return nil
+	}
+
+	a.log.Warn(ctx, "HTTP requests did not drain in time, closing connections", "error", err)
+	a.cancelBase()
+	if err := a.server.Close(); err != nil {
+		return fmt.Errorf("error closing server: %w", err)
+	}
+
+	return nil
+}
+
+// applyMiddlewares to wrap default http.ServeMux. First middleware is the outermost.
 func (m *API) applyMiddlewares(next http.Handler) http.Handler {
+	chain := []func(http.Handler) http.Handler{
+		m.RequestIDMiddleware,
+		m.LoggingMiddleware,
+		m.RecoverMiddleware,
+		m.CORSMiddleware,
+	}
+	if m.cfg.Gzip {
+		chain = append(chain, m.GzipMiddleware)
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		next = chain[i](next)
+	}
 	return next
 }
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+// Create stores key with hash of its value
+func (r *APIKeyRepo) Create(ctx context.Context, key *domain.APIKey, hash string) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		key.Name,
+		key.Prefix,
+		hash,
+		scopesToStrings(key.Scopes),
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash returns key by hash of its value, domain.ErrNotFound if there is no such key
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT id, name, prefix, scopes, created_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1`,
+		hash,
+	)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// List returns all keys including revoked ones
+func (r *APIKeyRepo) List(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, name, prefix, scopes, created_at, revoked_at
+		FROM api_keys
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, ErrScanFailed
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Revoke marks key as revoked, domain.ErrNotFound if there is no active key with the id
+func (r *APIKeyRepo) Revoke(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var (
+		key       domain.APIKey
+		scopes    []string
+		revokedAt *time.Time
+	)
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, types.Scope(scope))
+	}
+	if revokedAt != nil {
+		key.RevokedAt = *revokedAt
+	}
+
+	return &key, nil
+}
+
+func scopesToStrings(scopes []types.Scope) []string {
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		result = append(result, string(scope))
+	}
+	return result
+}
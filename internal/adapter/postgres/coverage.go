@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CoverageRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewCoverageRepository(db *pgxpool.Pool) *CoverageRepo {
+	return &CoverageRepo{db: db}
+}
+
+// CoveredMinutes returns minutes in [from, to) with live ticks referenced by aggregated stats: minutes of the first,
+// the last, the lowest and the highest tick of every stat. Stats window is a minute, so its ticks fall into
+// the minutes of its first and last tick at most. Stats stored without tick reference cover the minute
+// they were stored in. Stats window ends at stat timestamp, so stats stored a minute after to are read too.
+func (r *CoverageRepo) CoveredMinutes(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time) ([]time.Time, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT DISTINCT date_trunc('minute', v.at) AS minute
+		FROM aggregated_prices a
+		CROSS JOIN LATERAL (VALUES
+			(COALESCE(a.ticks_from, a.timestamp AT TIME ZONE 'UTC')),
+			(COALESCE(a.ticks_to, a.timestamp AT TIME ZONE 'UTC')),
+			(a.min_price_at),
+			(a.max_price_at)
+		) AS v(at)
+		WHERE a.source = $1
+		AND a.exchange = $2
+		AND a.pair_name = $3
+		AND a.timestamp >= $4
+		AND a.timestamp < $5
+		AND v.at >= $6
+		AND v.at < $7
+		ORDER BY minute`,
+		types.SourceLive, exchange, symbol, from.UTC(), to.Add(time.Minute).UTC(), from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query covered minutes: %w", err)
+	}
+
+	minutes, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return minutes, nil
+}
+
+// ReplaceGaps replaces gaps of exchange and symbol starting in [from, to) with given ones in transaction.
+// Gap running over from is cut at it, and continued by the given gap starting at from if there is one,
+// so rescanning overlapping windows never duplicates gaps.
+func (r *CoverageRepo) ReplaceGaps(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time, gaps []domain.Gap) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE data_gaps SET gap_end = $3
+		WHERE exchange = $1 AND pair_name = $2 AND gap_start < $3 AND gap_end > $3`,
+		exchange, symbol, from,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cut gap before window: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM data_gaps
+		WHERE exchange = $1 AND pair_name = $2 AND gap_start >= $3 AND gap_start < $4`,
+		exchange, symbol, from, to,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete gaps of window: %w", err)
+	}
+
+	for _, gap := range gaps {
+		if gap.From.Equal(from) {
+			tag, err := tx.Exec(ctx, `
+				UPDATE data_gaps SET gap_end = $4, detected_at = now()
+				WHERE exchange = $1 AND pair_name = $2 AND gap_end = $3`,
+				exchange, symbol, from, gap.To,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to extend gap: %w", err)
+			}
+			if tag.RowsAffected() > 0 {
+				continue
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO data_gaps (exchange, pair_name, gap_start, gap_end, cause, detail)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			exchange, symbol, gap.From, gap.To, gap.Cause, gap.Detail,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert gap: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -30,16 +30,31 @@ func (r *MarketRepo) StoreStats(ctx context.Context, stats []*domain.PriceStats)
 	batch := &pgx.Batch{}
 
 	for _, stat := range stats {
+		// Reference to raw ticks is NULL for stats computed without it
+		var ticksFrom, ticksTo, minAt, maxAt *time.Time
+		var tickCount *int
+		if stat.Ticks != nil {
+			ticksFrom, ticksTo, tickCount = &stat.Ticks.From, &stat.Ticks.To, &stat.Ticks.Count
+			minAt, maxAt = &stat.Ticks.MinAt, &stat.Ticks.MaxAt
+		}
+
 		batch.Queue(`
 			INSERT INTO aggregated_prices 
-				(pair_name, exchange, timestamp, min_price, max_price, average_price)
-			VALUES ($1, $2, $3, $4, $5, $6)`,
+				(pair_name, exchange, timestamp, min_price, max_price, average_price, source,
+				 ticks_from, ticks_to, tick_count, min_price_at, max_price_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
 			stat.Pair,
 			stat.Exchange,
 			stat.Timestamp,
 			stat.Min,
 			stat.Max,
 			stat.Average,
+			types.SourceOrLive(stat.Source),
+			ticksFrom,
+			ticksTo,
+			tickCount,
+			minAt,
+			maxAt,
 		)
 	}
 
@@ -55,153 +70,206 @@ func (r *MarketRepo) StoreStats(ctx context.Context, stats []*domain.PriceStats)
 	return nil
 }
 
+// Get*Stat methods use live stats only, so synthetic data never contaminates live analytics
+
 // GetHighestStat returns highest price across exchanges in given period
 func (r *MarketRepo) GetHighestStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error) {
-	timeThreshold := time.Now().Add(-period)
+	return r.getStat(ctx, domain.PriceQuery{Metric: types.MetricHighest, Exchange: exchange, Symbol: pair, Period: period})
+}
 
-	var query string
-	var args []any
+// GetLowestStat returns lowest price across exchanges in given period
+func (r *MarketRepo) GetLowestStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error) {
+	return r.getStat(ctx, domain.PriceQuery{Metric: types.MetricLowest, Exchange: exchange, Symbol: pair, Period: period})
+}
 
-	if exchange == types.AllExchanges {
-		query = `
-            SELECT 
-                $1::text as pair_name,
-                'ALL' as exchange,
-                MAX(max_price) as max_price,
-                MAX(timestamp) as timestamp
-            FROM aggregated_prices
-            WHERE pair_name = $1
-            AND timestamp >= $2`
-		args = []any{pair, timeThreshold}
-	} else {
-		query = `
-            SELECT 
-                $1::text as pair_name,
-                $2::text as exchange,
-                max_price,
-                timestamp
-            FROM aggregated_prices
-            WHERE pair_name = $1
-            AND exchange = $2
-            AND timestamp >= $3
-            ORDER BY max_price DESC
-            LIMIT 1`
-		args = []any{pair, exchange, timeThreshold}
+// GetAverageStat returns avarage price accross exchanges in given period
+func (r *MarketRepo) GetAverageStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error) {
+	return r.getStat(ctx, domain.PriceQuery{Metric: types.MetricAverage, Exchange: exchange, Symbol: pair, Period: period})
+}
+
+// GetStatsBatch returns stats of query metrics in the same order, queried in single round trip.
+// Stats are nil for queries without data. Failure of any query fails the whole batch.
+func (r *MarketRepo) GetStatsBatch(ctx context.Context, queries []domain.PriceQuery) ([]*domain.PriceStats, error) {
+	if len(queries) == 0 {
+		return nil, nil
 	}
 
-	var stats domain.PriceStats
-	err := r.db.QueryRow(ctx, query, args...).Scan(
-		&stats.Pair,
-		&stats.Exchange,
-		&stats.Max,
-		&stats.Timestamp,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+	batch := &pgx.Batch{}
+	for _, q := range queries {
+		query, args, err := statQuery(q)
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to get highest stat: %w", err)
+		batch.Queue(query, args...)
 	}
 
-	return &stats, nil
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	stats := make([]*domain.PriceStats, len(queries))
+	for i, q := range queries {
+		stat, err := scanStat(br.QueryRow(), q.Metric)
+		if err != nil {
+			return nil, err
+		}
+		stats[i] = stat
+	}
+
+	return stats, nil
 }
 
-// GetLowestStat returns lowest price across exchanges in given period
-func (r *MarketRepo) GetLowestStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error) {
-	timeThreshold := time.Now().Add(-period)
+func (r *MarketRepo) getStat(ctx context.Context, q domain.PriceQuery) (*domain.PriceStats, error) {
+	query, args, err := statQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	return scanStat(r.db.QueryRow(ctx, query, args...), q.Metric)
+}
 
-	var query string
-	var args []any
+// statQuery returns query of live stat of the metric in query period. Its row is pair, exchange,
+// metric value and timestamp, values are NULL if stats across exchanges have no data.
+func statQuery(q domain.PriceQuery) (string, []any, error) {
+	timeThreshold := time.Now().Add(-q.Period)
 
-	if exchange == types.AllExchanges {
-		query = `
+	var column, aggregate, order string
+	switch q.Metric {
+	case types.MetricHighest:
+		column, aggregate, order = "max_price", "MAX(max_price)", "ORDER BY max_price DESC LIMIT 1"
+	case types.MetricLowest:
+		column, aggregate, order = "min_price", "MIN(min_price)", "ORDER BY min_price ASC LIMIT 1"
+	case types.MetricAverage:
+		// average of exchange is aggregated as well
+		column, aggregate = "AVG(average_price)", "AVG(average_price)"
+	default:
+		return "", nil, domain.ErrInvalidMetric
+	}
+
+	if q.Exchange == types.AllExchanges {
+		return fmt.Sprintf(`
             SELECT 
                 $1::text as pair_name,
                 'ALL' as exchange,
-                MIN(min_price) as min_price,
+                %s,
                 MAX(timestamp) as timestamp
             FROM aggregated_prices
             WHERE pair_name = $1
-            AND timestamp >= $2`
-		args = []any{pair, timeThreshold}
-	} else {
-		query = `
+            AND source = 'live'
+            AND timestamp >= $2`, aggregate), []any{q.Symbol, timeThreshold}, nil
+	}
+
+	timestamp := "timestamp"
+	if order == "" {
+		timestamp = "MAX(timestamp) as timestamp"
+	}
+	return fmt.Sprintf(`
             SELECT 
                 $1::text as pair_name,
                 $2::text as exchange,
-                min_price,
-                timestamp
+                %s,
+                %s
             FROM aggregated_prices
             WHERE pair_name = $1
+            AND source = 'live'
             AND exchange = $2
             AND timestamp >= $3
-            ORDER BY min_price ASC
-            LIMIT 1`
-		args = []any{pair, exchange, timeThreshold}
-	}
+            %s`, column, timestamp, order), []any{q.Symbol, q.Exchange, timeThreshold}, nil
+}
 
-	var stats domain.PriceStats
-	err := r.db.QueryRow(ctx, query, args...).Scan(
-		&stats.Pair,
-		&stats.Exchange,
-		&stats.Min,
-		&stats.Timestamp,
+// scanStat scans row of statQuery into stats with the metric set, nil if there is no data
+func scanStat(row pgx.Row, metric types.Metric) (*domain.PriceStats, error) {
+	var (
+		stats     domain.PriceStats
+		value     *float64
+		timestamp *time.Time
 	)
+	err := row.Scan(&stats.Pair, &stats.Exchange, &value, &timestamp)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to get lowest stat: %w", err)
+		return nil, fmt.Errorf("failed to get %s stat: %w", metric, err)
+	}
+	if value == nil || timestamp == nil {
+		return nil, nil
+	}
+	stats.Timestamp = *timestamp
+
+	switch metric {
+	case types.MetricHighest:
+		stats.Max = *value
+	case types.MetricLowest:
+		stats.Min = *value
+	case types.MetricAverage:
+		stats.Average = *value
 	}
 
 	return &stats, nil
 }
 
-// GetAverageStat returns avarage price accross exchanges in given period
-func (r *MarketRepo) GetAverageStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error) {
-	timeThreshold := time.Now().Add(-period)
+// statsFetchSize is a number of rows fetched from cursor at once by IterateStats
+const statsFetchSize = 1000
 
-	var query string
-	var args []any
+// IterateStats calls fn for stats matching filter ordered by timestamp. Rows are read through server-side cursor
+// in chunks, so exporting long periods never loads the whole result into memory. Empty exchanges or symbols match any.
+func (r *MarketRepo) IterateStats(ctx context.Context, filter domain.ExportFilter, fn func(*domain.PriceStats) error) error {
+	// Cursors live inside transaction, it is only read so it is always rolled back
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	if exchange == types.AllExchanges {
-		query = `
-            SELECT 
-                $1::text as pair_name,
-                'ALL' as exchange,
-                AVG(average_price) as average_price,
-                MAX(timestamp) as timestamp
-            FROM aggregated_prices
-            WHERE pair_name = $1
-            AND timestamp >= $2`
-		args = []any{pair, timeThreshold}
-	} else {
-		query = `
-            SELECT 
-                $1::text as pair_name,
-                $2::text as exchange,
-                AVG(average_price) as average_price,
-                MAX(timestamp) as timestamp
-            FROM aggregated_prices
-            WHERE pair_name = $1
-            AND exchange = $2
-            AND timestamp >= $3`
-		args = []any{pair, exchange, timeThreshold}
+	exchanges := make([]string, len(filter.Exchanges))
+	for i, exchange := range filter.Exchanges {
+		exchanges[i] = string(exchange)
+	}
+	symbols := make([]string, len(filter.Symbols))
+	for i, symbol := range filter.Symbols {
+		symbols[i] = string(symbol)
 	}
 
-	var stats domain.PriceStats
-	err := r.db.QueryRow(ctx, query, args...).Scan(
-		&stats.Pair,
-		&stats.Exchange,
-		&stats.Average,
-		&stats.Timestamp,
+	_, err = tx.Exec(ctx, `
+		DECLARE export_stats NO SCROLL CURSOR FOR
+		SELECT pair_name, exchange, timestamp, min_price, max_price, average_price, source
+		FROM aggregated_prices
+		WHERE (cardinality($1::text[]) = 0 OR exchange = ANY($1))
+		AND (cardinality($2::text[]) = 0 OR pair_name = ANY($2))
+		AND timestamp >= $3
+		AND timestamp < $4
+		ORDER BY timestamp, exchange, pair_name`,
+		exchanges, symbols, filter.From, filter.To,
 	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to get average stat: %w", err)
+		return fmt.Errorf("failed to declare cursor: %w", err)
 	}
 
-	return &stats, nil
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM export_stats", statsFetchSize))
+		if err != nil {
+			return fmt.Errorf("failed to fetch stats: %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+
+			stats := new(domain.PriceStats)
+			if err := rows.Scan(&stats.Pair, &stats.Exchange, &stats.Timestamp, &stats.Min, &stats.Max, &stats.Average, &stats.Source); err != nil {
+				rows.Close()
+				return fmt.Errorf("%w: %w", ErrScanFailed, err)
+			}
+			if err := fn(stats); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to fetch stats: %w", err)
+		}
+
+		if fetched < statsFetchSize {
+			return nil
+		}
+	}
 }
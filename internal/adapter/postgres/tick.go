@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ticksTable           = "ticks"
+	tickPartitionPrefix  = "ticks_"
+	tickPartitionLayout  = "20060102"
+	tickPartitionDefault = "ticks_default"
+)
+
+var tickColumns = []string{"pair_name", "exchange", "timestamp", "price", "source"}
+
+type TickRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewTickRepository(db *pgxpool.Pool) *TickRepo {
+	return &TickRepo{db: db}
+}
+
+// CopyTicks inserts ticks with COPY protocol, rows are routed to daily partitions by Postgres
+func (r *TickRepo) CopyTicks(ctx context.Context, ticks []*domain.PriceData) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+
+	_, err := r.db.CopyFrom(ctx, pgx.Identifier{ticksTable}, tickColumns,
+		pgx.CopyFromSlice(len(ticks), func(i int) ([]any, error) {
+			t := ticks[i]
+			return []any{t.Symbol, t.Exchange, t.Timestamp, t.Price, types.SourceOrLive(t.Source)}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy %d ticks: %w", len(ticks), err)
+	}
+
+	return nil
+}
+
+// EnsureTickPartitions creates missing daily partitions of UTC days in [from, to]
+func (r *TickRepo) EnsureTickPartitions(ctx context.Context, from, to time.Time) error {
+	for day := truncateDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		name := pgx.Identifier{tickPartitionPrefix + day.Format(tickPartitionLayout)}.Sanitize()
+
+		_, err := r.db.Exec(ctx, fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+			name, ticksTable, day.Format(time.RFC3339), day.AddDate(0, 0, 1).Format(time.RFC3339),
+		))
+		if err != nil {
+			return fmt.Errorf("failed to create tick partition %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// DropTickPartitionsBefore drops daily partitions of UTC days before the given one
+// and deletes older ticks from default partition
+func (r *TickRepo) DropTickPartitionsBefore(ctx context.Context, before time.Time) ([]string, error) {
+	before = truncateDay(before)
+
+	rows, err := r.db.Query(ctx, `
+		SELECT child.relname
+		FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = $1`,
+		ticksTable,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tick partitions: %w", err)
+	}
+	partitions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tick partitions: %w", err)
+	}
+
+	var dropped []string
+	for _, name := range partitions {
+		day, err := time.Parse(tickPartitionLayout, strings.TrimPrefix(name, tickPartitionPrefix))
+		if err != nil || !day.Before(before) {
+			continue // default partition or partition to keep
+		}
+
+		if _, err := r.db.Exec(ctx, "DROP TABLE IF EXISTS "+pgx.Identifier{name}.Sanitize()); err != nil {
+			return dropped, fmt.Errorf("failed to drop tick partition %s: %w", name, err)
+		}
+		dropped = append(dropped, name)
+	}
+
+	_, err = r.db.Exec(ctx, "DELETE FROM "+pgx.Identifier{tickPartitionDefault}.Sanitize()+" WHERE timestamp < $1", before)
+	if err != nil {
+		return dropped, fmt.Errorf("failed to delete old ticks from default partition: %w", err)
+	}
+
+	return dropped, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// stagingTTL is how long staged ticks and watermarks are kept if archiver does not run,
+// after that archiving starts from the history left in Redis
+const stagingTTL = 24 * time.Hour
+
+// stageScript copies history members newer than watermark and not newer than upTo to sorted sets of their hours,
+// then moves watermark. Copying is idempotent, so a run interrupted before watermark update is safely repeated.
+// Staging keys are derived from prefix, so the script is meant for standalone Redis.
+// KEYS[1] - history key, KEYS[2] - watermark key,
+// ARGV[1] - initial watermark, ARGV[2] - upTo, ARGV[3] - staging key prefix, ARGV[4] - TTL in seconds.
+// Returns watermark.
+var stageScript = goredis.NewScript(`
+local watermark = tonumber(redis.call('GET', KEYS[2]) or ARGV[1])
+local upTo = tonumber(ARGV[2])
+if upTo <= watermark then
+	return watermark
+end
+
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. watermark, upTo, 'WITHSCORES')
+local touched = {}
+for i = 1, #members, 2 do
+	local score = tonumber(members[i + 1])
+	local key = ARGV[3] .. string.format('%d', math.floor(score / 3600000))
+	redis.call('ZADD', key, score, members[i])
+	touched[key] = true
+end
+
+for key in pairs(touched) do
+	redis.call('EXPIRE', key, ARGV[4])
+end
+redis.call('SET', KEYS[2], ARGV[2], 'EX', ARGV[4])
+
+return upTo
+`)
+
+// ArchiveStaging keeps raw ticks in Redis until their hour is written to archive
+type ArchiveStaging struct {
+	cache *Cache
+}
+
+func NewArchiveStaging(cache *Cache) *ArchiveStaging {
+	return &ArchiveStaging{cache: cache}
+}
+
+// Stage copies history of exchange and symbol in (watermark, upTo] to staging of their hours. Returns watermark.
+func (s *ArchiveStaging) Stage(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, upTo time.Time) (time.Time, error) {
+	keys := []string{
+		s.cache.createHistoryKeyByExchangeAndSymbol(exchange, symbol),
+		fmt.Sprintf("archive:watermark:%s:%s", exchange, symbol),
+	}
+	// watermark is exclusive, so tick at from is staged
+	initial := from.UnixMilli() - 1
+
+	watermark, err := stageScript.Run(ctx, s.cache.client, keys,
+		initial, upTo.UnixMilli(), stagingPrefix(exchange, symbol), int(stagingTTL.Seconds()),
+	).Int64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("stage script failed for %s %s: %w", exchange, symbol, err)
+	}
+
+	return time.UnixMilli(watermark), nil
+}
+
+// StagedHours returns hours of exchange and symbol with staged ticks
+func (s *ArchiveStaging) StagedHours(ctx context.Context, exchange types.Exchange, symbol types.Symbol) ([]time.Time, error) {
+	prefix := stagingPrefix(exchange, symbol)
+
+	var hours []time.Time
+	iter := s.cache.client.Scan(ctx, 0, prefix+"*", scanCount).Iterator()
+	for iter.Next(ctx) {
+		index, err := strconv.ParseInt(strings.TrimPrefix(iter.Val(), prefix), 10, 64)
+		if err != nil {
+			continue // not a staging key
+		}
+		hours = append(hours, time.UnixMilli(index*time.Hour.Milliseconds()).UTC())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan staging keys: %w", err)
+	}
+
+	return hours, nil
+}
+
+// StagedTicks returns staged ticks of the hour ordered by timestamp
+func (s *ArchiveStaging) StagedTicks(ctx context.Context, exchange types.Exchange, symbol types.Symbol, hour time.Time) ([]*domain.PriceData, error) {
+	key := stagingKey(exchange, symbol, hour)
+
+	values, err := s.cache.client.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staging key %s: %w", key, err)
+	}
+
+	ticks := make([]*domain.PriceData, 0, len(values))
+	for _, v := range values {
+		data, err := decodeMember(v, symbol)
+		if err != nil {
+			continue // skip corrupted entries
+		}
+		ticks = append(ticks, data)
+	}
+
+	return ticks, nil
+}
+
+// DeleteStaged deletes staged ticks of the hour after they are archived
+func (s *ArchiveStaging) DeleteStaged(ctx context.Context, exchange types.Exchange, symbol types.Symbol, hour time.Time) error {
+	key := stagingKey(exchange, symbol, hour)
+	if err := s.cache.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete staging key %s: %w", key, err)
+	}
+	return nil
+}
+
+func stagingPrefix(exchange types.Exchange, symbol types.Symbol) string {
+	return fmt.Sprintf("archive:staging:%s:%s:", exchange, symbol)
+}
+
+// stagingKey returns key of the hour, hours are numbered from unix epoch
+func stagingKey(exchange types.Exchange, symbol types.Symbol, hour time.Time) string {
+	return stagingPrefix(exchange, symbol) + strconv.FormatInt(hour.UnixMilli()/time.Hour.Milliseconds(), 10)
+}
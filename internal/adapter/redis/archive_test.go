@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+)
+
+func TestArchiveStaging(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestCache(t)
+	staging := NewArchiveStaging(cache)
+
+	hour := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	tick := func(at time.Duration, price float64) *domain.PriceData {
+		return &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: price, Timestamp: hour.Add(at), Source: types.SourceLive}
+	}
+	storeTicks(t, cache, tick(0, 1), tick(30*time.Minute, 2), tick(59*time.Minute, 3), tick(70*time.Minute, 4))
+
+	// tick at from is staged, ticks after upTo wait for the next run
+	watermark, err := staging.Stage(ctx, types.Exchange1, types.BTCUSDT, hour, hour.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !watermark.Equal(hour.Add(time.Hour)) {
+		t.Fatalf("watermark %v, want upTo", watermark)
+	}
+	assertStaged(t, staging, hour, 1, 2, 3)
+
+	// staging again is idempotent, watermark only moves forward
+	if watermark, err = staging.Stage(ctx, types.Exchange1, types.BTCUSDT, hour, hour.Add(30*time.Minute)); err != nil || !watermark.Equal(hour.Add(time.Hour)) {
+		t.Fatalf("watermark %v, %v, must not move back", watermark, err)
+	}
+	if _, err := staging.Stage(ctx, types.Exchange1, types.BTCUSDT, hour, hour.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	assertStaged(t, staging, hour, 1, 2, 3)
+
+	hours, err := staging.StagedHours(ctx, types.Exchange1, types.BTCUSDT)
+	if err != nil || len(hours) != 2 {
+		t.Fatalf("staged hours %v, %v, want two hours", hours, err)
+	}
+
+	if err := staging.DeleteStaged(ctx, types.Exchange1, types.BTCUSDT, hour); err != nil {
+		t.Fatal(err)
+	}
+	assertStaged(t, staging, hour)
+	assertStaged(t, staging, hour.Add(time.Hour), 4)
+
+	// other symbols are not staged with it
+	if hours, err := staging.StagedHours(ctx, types.Exchange1, types.ETHUSDT); err != nil || len(hours) != 0 {
+		t.Fatalf("staged hours of other symbol %v, %v", hours, err)
+	}
+}
+
+func assertStaged(t *testing.T, staging *ArchiveStaging, hour time.Time, prices ...float64) {
+	t.Helper()
+	ticks, err := staging.StagedTicks(context.Background(), types.Exchange1, types.BTCUSDT, hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks) != len(prices) {
+		t.Fatalf("staged %d ticks of %v, want %v", len(ticks), hour, prices)
+	}
+	for i, p := range ticks {
+		if p.Price != prices[i] || p.Symbol != types.BTCUSDT || !p.Timestamp.Truncate(time.Hour).Equal(hour) {
+			t.Fatalf("staged tick %d %+v, want price %g in hour %v", i, p, prices[i], hour)
+		}
+	}
+}
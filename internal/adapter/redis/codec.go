@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+)
+
+// History members are stored as "price|unix_millis|exchange|source".
+// The format is decoded by hand so reading a window never goes through reflection.
+const memberSeparator = "|"
+
+var ErrInvalidMember = errors.New("invalid history member")
+
+// encodeMember returns compact sorted set member for given price data
+func encodeMember(p *domain.PriceData) string {
+	buf := make([]byte, 0, 48)
+	buf = strconv.AppendFloat(buf, p.Price, 'f', -1, 64)
+	buf = append(buf, memberSeparator...)
+	buf = strconv.AppendInt(buf, p.Timestamp.UnixMilli(), 10)
+	buf = append(buf, memberSeparator...)
+	buf = append(buf, p.Exchange...)
+	buf = append(buf, memberSeparator...)
+	buf = append(buf, types.SourceOrLive(p.Source)...)
+	return string(buf)
+}
+
+// decodeMember parses member created by encodeMember
+func decodeMember(member string, symbol types.Symbol) (*domain.PriceData, error) {
+	priceStr, rest, ok := strings.Cut(member, memberSeparator)
+	if !ok {
+		return nil, ErrInvalidMember
+	}
+	tsStr, rest, ok := strings.Cut(rest, memberSeparator)
+	if !ok {
+		return nil, ErrInvalidMember
+	}
+	// members written before sources were introduced have no source
+	exchange, source, _ := strings.Cut(rest, memberSeparator)
+
+	price, err := strconv.ParseFloat(priceStr, 64)
+	if err != nil {
+		return nil, ErrInvalidMember
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidMember
+	}
+
+	return &domain.PriceData{
+		Symbol:    symbol,
+		Exchange:  types.Exchange(exchange),
+		Price:     price,
+		Timestamp: time.UnixMilli(ts),
+		Source:    types.SourceOrLive(types.Source(source)),
+	}, nil
+}
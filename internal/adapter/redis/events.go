@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// eventsKey is a sorted set of pipeline events scored by unix milliseconds
+const eventsKey = "pipeline:events"
+
+// Events keeps incidents of data pipeline shared by all instances, events older than retention are trimmed on write
+type Events struct {
+	client    *goredis.Client
+	retention time.Duration
+}
+
+// NewEvents returns Events sharing connection with given cache
+func NewEvents(cache *Cache, retention time.Duration) *Events {
+	return &Events{
+		client:    cache.client,
+		retention: retention,
+	}
+}
+
+func (e *Events) RecordEvent(ctx context.Context, event domain.PipelineEvent) error {
+	member, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	pipe := e.client.TxPipeline()
+	pipe.ZAdd(ctx, eventsKey, goredis.Z{Score: float64(event.At.UnixMilli()), Member: member})
+	pipe.ZRemRangeByScore(ctx, eventsKey, "-inf", "("+strconv.FormatInt(event.At.Add(-e.retention).UnixMilli(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+
+	return nil
+}
+
+func (e *Events) GetEvents(ctx context.Context, from, to time.Time) ([]domain.PipelineEvent, error) {
+	values, err := e.client.ZRangeByScore(ctx, eventsKey, &goredis.ZRangeBy{
+		Min: strconv.FormatInt(from.UnixMilli(), 10),
+		Max: strconv.FormatInt(to.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis query failed for key %s: %w", eventsKey, err)
+	}
+
+	events := make([]domain.PipelineEvent, 0, len(values))
+	for _, v := range values {
+		var event domain.PipelineEvent
+		if err := json.Unmarshal([]byte(v), &event); err != nil {
+			continue // skip corrupted entries
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
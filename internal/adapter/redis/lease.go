@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// renewLeaseScript prolongs lease only if it is still owned by the holder.
+// KEYS[1] - lease key, ARGV[1] - holder, ARGV[2] - ttl in milliseconds.
+var renewLeaseScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes lease only if it is still owned by the holder.
+// KEYS[1] - lease key, ARGV[1] - holder.
+var releaseLeaseScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Lease is an expiring lock in Redis, used for leader election between instances
+type Lease struct {
+	client *goredis.Client
+}
+
+// NewLease returns Lease sharing connection with given cache
+func NewLease(cache *Cache) *Lease {
+	return &Lease{
+		client: cache.client,
+	}
+}
+
+// Acquire takes the lease if nobody holds it
+func (l *Lease) Acquire(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	ok, err := l.client.SetNX(ctx, key, holder, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", key, err)
+	}
+	return ok, nil
+}
+
+// Renew prolongs the lease. Returns false if the lease is lost.
+func (l *Lease) Renew(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	res, err := renewLeaseScript.Run(ctx, l.client, []string{key}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease %s: %w", key, err)
+	}
+	return res == 1, nil
+}
+
+// Release gives up the lease if it is held by the holder
+func (l *Lease) Release(ctx context.Context, key, holder string) error {
+	if err := releaseLeaseScript.Run(ctx, l.client, []string{key}, holder).Err(); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", key, err)
+	}
+	return nil
+}
+
+// Holder returns current holder of the lease and time left until it expires.
+// Empty holder means nobody holds the lease.
+func (l *Lease) Holder(ctx context.Context, key string) (string, time.Duration, error) {
+	pipe := l.client.Pipeline()
+	getCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return "", 0, fmt.Errorf("failed to get lease %s: %w", key, err)
+	}
+
+	holder, err := getCmd.Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", 0, nil
+	} else if err != nil {
+		return "", 0, fmt.Errorf("failed to get lease %s: %w", key, err)
+	}
+
+	return holder, ttlCmd.Val(), nil
+}
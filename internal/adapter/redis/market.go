@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"sort"
+	"strconv"
 	"time"
 
 	"marketflow/internal/domain"
@@ -16,6 +16,12 @@ import (
 
 var ErrNotFound = errors.New("value with given key not found")
 
+// scanCount is a hint for SCAN batch size and pipeline flush size
+const scanCount = 100
+
+// historyChunk is a number of history members read at once by scanHistory
+const historyChunk = 1000
+
 // SetLatest saves PriceData into Redis 2 keys(by exchange and symbol, and by symbol only) with given TTL(Time-To-Live)
 func (c *Cache) SetLatest(ctx context.Context, latest *domain.PriceData, ttl time.Duration) error {
 	key := c.createKeyByExchangeAndSymbol(latest.Exchange, latest.Symbol) // Key by exchange and symbol
@@ -41,12 +47,7 @@ func (c *Cache) SetLatest(ctx context.Context, latest *domain.PriceData, ttl tim
 
 // GetLatest returns PriceData by given key (exchange, pair)
 func (c *Cache) GetLatest(ctx context.Context, exchange types.Exchange, symbol types.Symbol) (*domain.PriceData, error) {
-	var key string
-	if exchange == types.AllExchanges {
-		key = c.createKeyBySymbol(symbol)
-	} else {
-		key = c.createKeyByExchangeAndSymbol(exchange, symbol)
-	}
+	key := c.latestKey(exchange, symbol)
 
 	val, err := c.client.Get(ctx, key).Result()
 	if err == goredis.Nil {
@@ -63,23 +64,49 @@ func (c *Cache) GetLatest(ctx context.Context, exchange types.Exchange, symbol t
 	return data, nil
 }
 
-func (c *Cache) GetPriceInPeriod(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) ([]*domain.PriceData, error) {
-	now := time.Now()
-	start := now.Add(-period).UnixMilli()
-	end := now.UnixMilli()
+// GetLatestBatch returns latest price data of every query with single MGET, in the same order.
+// Price is nil if there is no data or cached value is corrupted.
+func (c *Cache) GetLatestBatch(ctx context.Context, queries []domain.PriceQuery) ([]*domain.PriceData, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
 
-	// Determine which key to query based on exchange type
-	var key string
-	if exchange == types.AllExchanges {
-		key = c.createHistoryKeyBySymbol(symbol) // Use combined symbol key
-	} else {
-		key = c.createHistoryKeyByExchangeAndSymbol(exchange, symbol) // Use exchange-specific key
+	keys := make([]string, len(queries))
+	for i, q := range queries {
+		keys[i] = c.latestKey(q.Exchange, q.Symbol)
+	}
+
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest keys: %w", err)
 	}
 
-	// Execute Redis query
+	prices := make([]*domain.PriceData, len(values))
+	for i, v := range values {
+		val, ok := v.(string) // nil for missing key
+		if !ok {
+			continue
+		}
+
+		data := new(domain.PriceData)
+		if err := json.Unmarshal([]byte(val), data); err != nil {
+			continue // skip corrupted entries
+		}
+		prices[i] = data
+	}
+
+	return prices, nil
+}
+
+// GetPriceInPeriod returns history of prices in given period ordered by timestamp
+func (c *Cache) GetPriceInPeriod(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) ([]*domain.PriceData, error) {
+	key := c.historyKey(exchange, symbol)
+	start, end := periodScores(period)
+
+	// Sorted set is ordered by score (timestamp), so no extra sorting needed
 	values, err := c.client.ZRangeByScore(ctx, key, &goredis.ZRangeBy{
-		Min: fmt.Sprintf("%d", start),
-		Max: fmt.Sprintf("%d", end),
+		Min: start,
+		Max: end,
 	}).Result()
 	if err != nil {
 		return nil, fmt.Errorf("redis query failed for key %s: %w", key, err)
@@ -87,26 +114,316 @@ func (c *Cache) GetPriceInPeriod(ctx context.Context, exchange types.Exchange, s
 
 	prices := make([]*domain.PriceData, 0, len(values))
 	for _, v := range values {
-		data := new(domain.PriceData)
-		if err := json.Unmarshal([]byte(v), data); err != nil {
-			continue // skip corrupted entries (consider logging this)
+		data, err := decodeMember(v, symbol)
+		if err != nil {
+			continue // skip corrupted entries
 		}
 		prices = append(prices, data)
 	}
 
-	sort.Slice(prices, func(i, j int) bool {
-		return prices[i].Timestamp.Before(prices[j].Timestamp)
+	return prices, nil
+}
+
+// IterateHistory calls fn for prices of exchange and symbol in [from, to) ordered by timestamp.
+// History is read in chunks, so large windows are never loaded at once.
+func (c *Cache) IterateHistory(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time, fn func(*domain.PriceData) error) error {
+	start := strconv.FormatInt(from.UnixMilli(), 10)
+	end := "(" + strconv.FormatInt(to.UnixMilli(), 10)
+
+	return c.scanHistory(ctx, c.historyKey(exchange, symbol), start, end, func(member string) error {
+		data, err := decodeMember(member, symbol)
+		if err != nil {
+			return nil // skip corrupted entries
+		}
+		return fn(data)
 	})
+}
 
-	return prices, nil
+// scanHistory calls fn for members of key with scores in [start, end] in order, end may be exclusive.
+// Chunks are paged by score: the next chunk starts at the last score read and skips members of it already read,
+// so Redis finds every chunk by score instead of walking the range again from its start.
+func (c *Cache) scanHistory(ctx context.Context, key, start, end string, fn func(member string) error) error {
+	rangeBy := &goredis.ZRangeBy{Min: start, Max: end, Count: historyChunk}
+	skip := 0 // members at score Min read by previous chunk
+
+	for {
+		members, err := c.client.ZRangeByScoreWithScores(ctx, key, rangeBy).Result()
+		if err != nil {
+			return fmt.Errorf("redis query failed for key %s: %w", key, err)
+		}
+
+		for _, z := range members[min(skip, len(members)):] {
+			member, _ := z.Member.(string)
+			if err := fn(member); err != nil {
+				return err
+			}
+		}
+
+		if int64(len(members)) < rangeBy.Count {
+			return nil
+		}
+
+		// chunk is read by Count grown by skipped members, so it always has new ones
+		last := members[len(members)-1].Score
+		skip = 0
+		for i := len(members) - 1; i >= 0 && members[i].Score == last; i-- {
+			skip++
+		}
+		rangeBy.Min = strconv.FormatFloat(last, 'f', -1, 64)
+		rangeBy.Count = int64(skip + historyChunk)
+	}
 }
 
-// StoreHistory saves price data to Redis in both exchange-specific and symbol-only sorted sets
-func (c *Cache) StoreHistory(ctx context.Context, p *domain.PriceData) error {
-	value, err := json.Marshal(p)
+// GetStatsInPeriod returns min, max and average prices in given period, computed on the Redis side for short periods.
+// Only prices of given source are used, empty source matches any source.
+// Average carries exchange and timestamp of the latest price in period. Returns nils if there is no data.
+func (c *Cache) GetStatsInPeriod(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration, source types.Source) (min, max, avg *domain.PriceData, err error) {
+	start, end := periodScores(period)
+
+	stats, err := c.windowStats(ctx, c.historyKey(exchange, symbol), symbol, start, end, period, source)
 	if err != nil {
-		return fmt.Errorf("failed to marshal PriceData: %w", err)
+		return nil, nil, nil, err
+	}
+	return stats.prices()
+}
+
+// GetStatsInRange computes min, max and average prices of ticks with timestamps in [from, to),
+// so consecutive windows never count a tick twice.
+// Returned stats reference the ticks by timestamps of the first and the last of them,
+// so stored aggregates can be traced back to raw ticks and tell minutes that had ticks.
+func (c *Cache) GetStatsInRange(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time, source types.Source) (*domain.PriceStats, error) {
+	start := strconv.FormatInt(from.UnixMilli(), 10)
+	end := "(" + strconv.FormatInt(to.UnixMilli(), 10) // exclusive
+
+	stats, err := c.windowStats(ctx, c.historyKey(exchange, symbol), symbol, start, end, to.Sub(from), source)
+	if err != nil || stats == nil {
+		return nil, err
+	}
+
+	return &domain.PriceStats{
+		Exchange:  exchange,
+		Pair:      symbol,
+		Timestamp: to,
+		Average:   stats.average,
+		Min:       stats.min.Price,
+		Max:       stats.max.Price,
+		Source:    source,
+		Ticks: &domain.TickRange{
+			From:  stats.first.Timestamp,
+			To:    stats.last.Timestamp,
+			Count: stats.count,
+			MinAt: stats.min.Timestamp,
+			MaxAt: stats.max.Timestamp,
+		},
+	}, nil
+}
+
+// GetStatsBatch computes min, max and average prices of every query, in the same order. Queries of the same
+// exchange, symbol and period are computed once. Short periods are computed by script in single pipeline,
+// longer ones are read in chunks one by one. Errors of single queries are set to their stats.
+func (c *Cache) GetStatsBatch(ctx context.Context, queries []domain.PriceQuery, source types.Source) ([]domain.PeriodStats, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	// Index of unique query by history key and period
+	type statsKey struct {
+		key    string
+		period time.Duration
+	}
+	uniqueIndex := make(map[statsKey]int, len(queries))
+	unique := make([]domain.PriceQuery, 0, len(queries))
+	for _, q := range queries {
+		k := statsKey{c.historyKey(q.Exchange, q.Symbol), q.Period}
+		if _, ok := uniqueIndex[k]; !ok {
+			uniqueIndex[k] = len(unique)
+			unique = append(unique, q)
+		}
+	}
+
+	var short []domain.PriceQuery
+	for _, q := range unique {
+		if q.Period <= scriptWindow {
+			short = append(short, q)
+		}
+	}
+
+	cmds, err := c.runStatsPipeline(ctx, short, source)
+	if err != nil && goredis.HasErrorPrefix(err, "NOSCRIPT") {
+		// Script cache is empty after Redis restart, loading script once and retrying
+		if err := periodStatsScript.Load(ctx, c.client).Err(); err != nil {
+			return nil, fmt.Errorf("failed to load stats script: %w", err)
+		}
+		cmds, err = c.runStatsPipeline(ctx, short, source)
+	}
+	// Replies with errors of single scripts are reported per query, other errors fail the whole batch
+	var replyErr goredis.Error
+	if err != nil && !errors.As(err, &replyErr) {
+		return nil, fmt.Errorf("stats pipeline failed: %w", err)
+	}
+
+	uniqueStats := make([]domain.PeriodStats, len(unique))
+	for i, q := range unique {
+		key := c.historyKey(q.Exchange, q.Symbol)
+
+		var stats *windowStats
+		if q.Period <= scriptWindow {
+			var res []string
+			res, err = cmds[0].StringSlice()
+			cmds = cmds[1:]
+			if err != nil {
+				err = fmt.Errorf("stats script failed for key %s: %w", key, err)
+			} else {
+				stats, err = decodeStats(res, q.Symbol)
+			}
+		} else {
+			start, end := periodScores(q.Period)
+			stats, err = c.chunkedStats(ctx, key, q.Symbol, start, end, source)
+		}
+		if err != nil {
+			uniqueStats[i].Err = err
+			continue
+		}
+		uniqueStats[i].Min, uniqueStats[i].Max, uniqueStats[i].Average, uniqueStats[i].Err = stats.prices()
+	}
+
+	stats := make([]domain.PeriodStats, len(queries))
+	for i, q := range queries {
+		stats[i] = uniqueStats[uniqueIndex[statsKey{c.historyKey(q.Exchange, q.Symbol), q.Period}]]
+	}
+
+	return stats, nil
+}
+
+// runStatsPipeline runs stats script for every query in single round trip.
+// Returned error is the first error of commands, commands hold their own errors.
+func (c *Cache) runStatsPipeline(ctx context.Context, queries []domain.PriceQuery, source types.Source) ([]*goredis.Cmd, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+	pipe := c.client.Pipeline()
+
+	cmds := make([]*goredis.Cmd, len(queries))
+	for i, q := range queries {
+		start, end := periodScores(q.Period)
+		cmds[i] = periodStatsScript.EvalSha(ctx, pipe, []string{c.historyKey(q.Exchange, q.Symbol)}, start, end, string(source))
+	}
+
+	_, err := pipe.Exec(ctx)
+	return cmds, err
+}
+
+// scriptWindow is the longest window computed by periodStatsScript. Script blocks Redis while it reads
+// the whole window, at a thousand ticks per second a minute is 60k members read in tens of milliseconds,
+// so longer windows are read in chunks and computed by client, letting other commands run between chunks.
+const scriptWindow = time.Minute
+
+// windowStats are stats of history window: min, max, first and last prices, average and count of prices
+type windowStats struct {
+	min, max, first, last *domain.PriceData
+	average               float64
+	count                 int
+}
+
+// prices returns min, max and average prices, average carries exchange and timestamp of the last price.
+// Returns nils if window has no prices.
+func (s *windowStats) prices() (min, max, avg *domain.PriceData, err error) {
+	if s == nil {
+		return nil, nil, nil, nil
+	}
+	avg = new(domain.PriceData)
+	*avg = *s.last
+	avg.Price = s.average
+	return s.min, s.max, avg, nil
+}
+
+// windowStats computes stats of members of source with scores in [start, end], end may be exclusive.
+// Windows up to scriptWindow are computed by script, longer ones in chunks. Returns nil if there is no data.
+func (c *Cache) windowStats(ctx context.Context, key string, symbol types.Symbol, start, end string, window time.Duration, source types.Source) (*windowStats, error) {
+	if window > scriptWindow {
+		return c.chunkedStats(ctx, key, symbol, start, end, source)
+	}
+
+	res, err := periodStatsScript.Run(ctx, c.client, []string{key}, start, end, string(source)).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("stats script failed for key %s: %w", key, err)
+	}
+	return decodeStats(res, symbol)
+}
+
+// chunkedStats computes the same stats as periodStatsScript reading members in chunks
+func (c *Cache) chunkedStats(ctx context.Context, key string, symbol types.Symbol, start, end string, source types.Source) (*windowStats, error) {
+	stats := &windowStats{}
+	var sum float64
+	err := c.scanHistory(ctx, key, start, end, func(member string) error {
+		p, err := decodeMember(member, symbol)
+		if err != nil || (source != "" && p.Source != source) {
+			return nil // skipping corrupted entries as script does
+		}
+
+		stats.count++
+		sum += p.Price
+		if stats.first == nil {
+			stats.first = p
+		}
+		stats.last = p
+		if stats.min == nil || p.Price < stats.min.Price {
+			stats.min = p
+		}
+		if stats.max == nil || p.Price > stats.max.Price {
+			stats.max = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	if stats.count == 0 {
+		return nil, nil
+	}
+	stats.average = sum / float64(stats.count)
+	return stats, nil
+}
+
+// decodeStats decodes reply of periodStatsScript, returns nil if there is no data
+func decodeStats(res []string, symbol types.Symbol) (*windowStats, error) {
+	if len(res) == 0 {
+		return nil, nil
+	}
+	if len(res) != 6 {
+		return nil, fmt.Errorf("unexpected stats script reply length %d", len(res))
+	}
+
+	var (
+		stats windowStats
+		err   error
+	)
+	if stats.min, err = decodeMember(res[0], symbol); err != nil {
+		return nil, err
+	}
+	if stats.max, err = decodeMember(res[1], symbol); err != nil {
+		return nil, err
+	}
+	if stats.last, err = decodeMember(res[2], symbol); err != nil {
+		return nil, err
+	}
+	if stats.average, err = strconv.ParseFloat(res[3], 64); err != nil {
+		return nil, fmt.Errorf("invalid average in stats script reply: %w", err)
+	}
+	if stats.count, err = strconv.Atoi(res[4]); err != nil {
+		return nil, fmt.Errorf("invalid count in stats script reply: %w", err)
+	}
+	if stats.first, err = decodeMember(res[5], symbol); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// StoreHistory saves price data to Redis in both exchange-specific and symbol-only sorted sets
+func (c *Cache) StoreHistory(ctx context.Context, p *domain.PriceData) error {
+	value := encodeMember(p)
 	score := float64(p.Timestamp.UnixMilli())
 
 	// Create pipeline for atomic operations
@@ -132,23 +449,101 @@ func (c *Cache) StoreHistory(ctx context.Context, p *domain.PriceData) error {
 	return nil
 }
 
-// DeleteExpiredHistory deletes keys in history.* sorted sets that older than 5 minutes
-func (c *Cache) DeleteExpiredHistory(ctx context.Context) error {
-	retentionPeriod := c.cfg.HistoryDeleteDuration
-	cutoff := time.Now().Add(-retentionPeriod).UnixMilli()
+// StoreBatch sets latest prices and adds history members of batch in single pipeline.
+// Latest prices are coalesced by exchange and symbol by caller, key by symbol is set once to the last of its prices.
+// History members are added to each key with single ZADD.
+func (c *Cache) StoreBatch(ctx context.Context, latest, history []*domain.PriceData, latestTTL time.Duration) error {
+	pipe := c.client.Pipeline()
 
-	// getting all keys that has history:*
-	keys, err := c.client.Keys(ctx, "history:*").Result()
-	if err != nil {
-		return fmt.Errorf("failed to get history keys: %w", err)
+	lastOfSymbol := make(map[types.Symbol]int, len(latest))
+	for i, p := range latest {
+		lastOfSymbol[p.Symbol] = i
 	}
+	for i, p := range latest {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal PriceData: %w", err)
+		}
 
+		pipe.Set(ctx, c.createKeyByExchangeAndSymbol(p.Exchange, p.Symbol), data, latestTTL)
+		if lastOfSymbol[p.Symbol] == i {
+			pipe.Set(ctx, c.createKeyBySymbol(p.Symbol), data, latestTTL)
+		}
+	}
+
+	// Members grouped by key, keys in order of their first member
+	var keys []string
+	members := make(map[string][]goredis.Z)
+	add := func(key string, z goredis.Z) {
+		if _, ok := members[key]; !ok {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], z)
+	}
+	for _, p := range history {
+		z := goredis.Z{Score: float64(p.Timestamp.UnixMilli()), Member: encodeMember(p)}
+		add(c.createHistoryKeyByExchangeAndSymbol(p.Exchange, p.Symbol), z)
+		add(c.createHistoryKeyBySymbol(p.Symbol), z)
+	}
 	for _, key := range keys {
-		err := c.client.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", cutoff)).Err()
-		if err != nil {
-			continue
+		pipe.ZAdd(ctx, key, members[key]...)
+		pipe.Expire(ctx, key, time.Hour)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis pipeline failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredHistory deletes members in history:* sorted sets that older than retention period.
+// Keys are iterated with SCAN, so Redis is never blocked on large keyspaces.
+func (c *Cache) DeleteExpiredHistory(ctx context.Context) error {
+	retentionPeriod := c.cfg.HistoryDeleteDuration
+	cutoff := strconv.FormatInt(time.Now().Add(-retentionPeriod).UnixMilli(), 10)
+
+	iter := c.client.Scan(ctx, 0, "history:*", scanCount).Iterator()
+
+	pipe := c.client.Pipeline()
+	for iter.Next(ctx) {
+		pipe.ZRemRangeByScore(ctx, iter.Val(), "0", cutoff)
+
+		if pipe.Len() >= scanCount {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return fmt.Errorf("failed to delete expired history: %w", err)
+			}
 		}
 	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan history keys: %w", err)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired history: %w", err)
+	}
 
 	return nil
 }
+
+// latestKey returns latest price key for given exchange, or symbol-only key for AllExchanges
+func (c *Cache) latestKey(exchange types.Exchange, symbol types.Symbol) string {
+	if exchange == types.AllExchanges {
+		return c.createKeyBySymbol(symbol)
+	}
+	return c.createKeyByExchangeAndSymbol(exchange, symbol)
+}
+
+// historyKey returns history key for given exchange, or symbol-only key for AllExchanges
+func (c *Cache) historyKey(exchange types.Exchange, symbol types.Symbol) string {
+	if exchange == types.AllExchanges {
+		return c.createHistoryKeyBySymbol(symbol)
+	}
+	return c.createHistoryKeyByExchangeAndSymbol(exchange, symbol)
+}
+
+// periodScores returns min and max scores of the period ending now
+func periodScores(period time.Duration) (start, end string) {
+	now := time.Now()
+	return strconv.FormatInt(now.Add(-period).UnixMilli(), 10), strconv.FormatInt(now.UnixMilli(), 10)
+}
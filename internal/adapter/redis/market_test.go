@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestCache(t *testing.T) (*Cache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	cache, err := NewClient(context.Background(), config.Redis{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache, mr
+}
+
+func storeTicks(t *testing.T, cache *Cache, ticks ...*domain.PriceData) {
+	t.Helper()
+	for _, p := range ticks {
+		if err := cache.StoreHistory(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetStatsInRangeReferencesTicks(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestCache(t)
+
+	minute := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tick := func(at time.Duration, price float64, source types.Source) *domain.PriceData {
+		return &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: price, Timestamp: minute.Add(at), Source: source}
+	}
+	storeTicks(t, cache,
+		tick(10*time.Second, 101, types.SourceLive),
+		tick(20*time.Second, 99, types.SourceLive),
+		tick(40*time.Second, 103, types.SourceLive),
+		tick(50*time.Second, 1, types.SourceTest),
+	)
+
+	// window runs into the next minute which has no ticks, it must not be reported as covered
+	stats, err := cache.GetStatsInRange(ctx, types.Exchange1, types.BTCUSDT, minute.Add(15*time.Second), minute.Add(75*time.Second), types.SourceLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats == nil || stats.Ticks == nil {
+		t.Fatal("expected stats with ticks")
+	}
+
+	ticks := stats.Ticks
+	if !ticks.From.Equal(minute.Add(20*time.Second)) || !ticks.To.Equal(minute.Add(40*time.Second)) {
+		t.Fatalf("ticks range [%v, %v], want first and last tick", ticks.From, ticks.To)
+	}
+	if ticks.Count != 2 || !ticks.MinAt.Equal(minute.Add(20*time.Second)) || !ticks.MaxAt.Equal(minute.Add(40*time.Second)) {
+		t.Fatalf("unexpected ticks %+v", ticks)
+	}
+	if stats.Min != 99 || stats.Max != 103 || stats.Average != 101 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	for _, at := range []time.Time{ticks.From, ticks.To, ticks.MinAt, ticks.MaxAt} {
+		if !at.Truncate(time.Minute).Equal(minute) {
+			t.Fatalf("tick at %v is out of the only minute with ticks", at)
+		}
+	}
+
+	stats, err = cache.GetStatsInRange(ctx, types.Exchange1, types.BTCUSDT, minute.Add(time.Minute), minute.Add(2*time.Minute), types.SourceLive)
+	if err != nil || stats != nil {
+		t.Fatalf("expected no stats for minute without ticks, got %+v, %v", stats, err)
+	}
+}
+
+func TestGetStatsInRangeIsHalfOpen(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestCache(t)
+
+	minute := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	storeTicks(t, cache, &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: 100, Timestamp: minute.Add(time.Minute), Source: types.SourceLive})
+
+	// tick at the boundary of consecutive windows is counted by the second one only
+	first, err := cache.GetStatsInRange(ctx, types.Exchange1, types.BTCUSDT, minute, minute.Add(time.Minute), types.SourceLive)
+	if err != nil || first != nil {
+		t.Fatalf("tick at window end must not be counted, got %+v, %v", first, err)
+	}
+	second, err := cache.GetStatsInRange(ctx, types.Exchange1, types.BTCUSDT, minute.Add(time.Minute), minute.Add(2*time.Minute), types.SourceLive)
+	if err != nil || second == nil || second.Ticks.Count != 1 {
+		t.Fatalf("tick at window start must be counted, got %+v, %v", second, err)
+	}
+}
+
+func TestChunkedStatsMatchScript(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestCache(t)
+
+	// more ticks than a chunk, some of other source
+	minute := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var ticks []*domain.PriceData
+	for i := range 2*historyChunk + 10 {
+		source := types.SourceLive
+		if i%7 == 0 {
+			source = types.SourceTest
+		}
+		ticks = append(ticks, &domain.PriceData{
+			Exchange: types.Exchange1, Symbol: types.BTCUSDT, Source: source,
+			Price: float64(1000 + (i*37)%501), Timestamp: minute.Add(time.Duration(i) * 20 * time.Millisecond),
+		})
+	}
+	storeTicks(t, cache, ticks...)
+
+	key := cache.historyKey(types.Exchange1, types.BTCUSDT)
+	start := strconv.FormatInt(minute.UnixMilli(), 10)
+	end := "(" + strconv.FormatInt(minute.Add(time.Minute).UnixMilli(), 10)
+
+	for _, source := range []types.Source{types.SourceLive, ""} {
+		script, err := cache.windowStats(ctx, key, types.BTCUSDT, start, end, time.Second, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunked, err := cache.windowStats(ctx, key, types.BTCUSDT, start, end, time.Hour, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if script.count != chunked.count || script.min.Price != chunked.min.Price || script.max.Price != chunked.max.Price ||
+			!script.min.Timestamp.Equal(chunked.min.Timestamp) || !script.max.Timestamp.Equal(chunked.max.Timestamp) ||
+			!script.first.Timestamp.Equal(chunked.first.Timestamp) || !script.last.Timestamp.Equal(chunked.last.Timestamp) ||
+			math.Abs(script.average-chunked.average) > 1e-9 {
+			t.Fatalf("source %q: chunked stats %+v differ from script %+v", source, chunked, script)
+		}
+	}
+}
+
+func TestIterateHistoryPagesByScore(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestCache(t)
+
+	// more ticks in one millisecond than a chunk, then chunks ending in the middle of milliseconds
+	minute := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var ticks []*domain.PriceData
+	for i := range 3*historyChunk + 10 {
+		at := minute
+		if i > historyChunk+historyChunk/2 {
+			at = minute.Add(time.Duration(i/3) * time.Millisecond)
+		}
+		ticks = append(ticks, &domain.PriceData{
+			Exchange: types.Exchange1, Symbol: types.BTCUSDT, Source: types.SourceLive, Price: float64(i + 1), Timestamp: at,
+		})
+	}
+	storeTicks(t, cache, ticks...)
+
+	seen := make(map[float64]bool)
+	var last time.Time
+	err := cache.IterateHistory(ctx, types.Exchange1, types.BTCUSDT, minute, minute.Add(time.Minute), func(p *domain.PriceData) error {
+		if seen[p.Price] || p.Timestamp.Before(last) {
+			t.Fatalf("tick %g at %v read twice or out of order", p.Price, p.Timestamp)
+		}
+		seen[p.Price], last = true, p.Timestamp
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(ticks) {
+		t.Fatalf("read %d of %d ticks", len(seen), len(ticks))
+	}
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"marketflow/pkg/ratelimit"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// takeTokensScript is a token bucket shared by all instances, Redis time is used as the clock.
+// KEYS[1] - bucket key, ARGV[1] - rate per second, ARGV[2] - burst, ARGV[3] - cost.
+// Returns {allowed, tokens left} with tokens as string to keep fraction.
+var takeTokensScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(now - ts, 0) / 1000 * rate)
+
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// RateLimitStore keeps token buckets in Redis, so limits hold across replicas
+type RateLimitStore struct {
+	client *goredis.Client
+}
+
+// NewRateLimitStore returns RateLimitStore sharing connection with given cache
+func NewRateLimitStore(cache *Cache) *RateLimitStore {
+	return &RateLimitStore{
+		client: cache.client,
+	}
+}
+
+func (s *RateLimitStore) Take(ctx context.Context, key string, cost int, limit ratelimit.Limit) (ratelimit.Result, error) {
+	res, err := takeTokensScript.Run(ctx, s.client, []string{rateLimitKeyPrefix + key}, limit.Rate, limit.Burst, cost).Slice()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("failed to take tokens: %w", err)
+	}
+	if len(res) != 2 {
+		return ratelimit.Result{}, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	tokensStr, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("invalid tokens %q: %w", tokensStr, err)
+	}
+
+	return ratelimit.NewResult(allowed == 1, tokens, cost, limit), nil
+}
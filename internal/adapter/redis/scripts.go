@@ -0,0 +1,42 @@
+package redis
+
+import goredis "github.com/redis/go-redis/v9"
+
+// periodStatsScript computes min, max and average of history members inside Redis,
+// so only three members travel over the wire instead of the whole window.
+// Script blocks Redis while it runs, so it is used for windows up to scriptWindow only.
+// KEYS[1] - history key, ARGV[1] - start score, ARGV[2] - end score,
+// ARGV[3] - source filter, empty string matches any source.
+// Returns {min_member, max_member, last_member, average, count, first_member} or empty array.
+var periodStatsScript = goredis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], ARGV[1], ARGV[2])
+local filter = ARGV[3]
+local count, sum = 0, 0
+local minPrice, maxPrice, minMember, maxMember, firstMember, lastMember
+
+for _, member in ipairs(members) do
+	local priceStr, source = string.match(member, '^([^|]*)|[^|]*|[^|]*|?([^|]*)$')
+	if source == '' then
+		source = 'live'
+	end
+	local price = tonumber(priceStr)
+	if price and (filter == '' or filter == source) then
+		count = count + 1
+		sum = sum + price
+		firstMember = firstMember or member
+		lastMember = member
+		if minPrice == nil or price < minPrice then
+			minPrice, minMember = price, member
+		end
+		if maxPrice == nil or price > maxPrice then
+			maxPrice, maxMember = price, member
+		end
+	end
+end
+
+if count == 0 then
+	return {}
+end
+
+return {minMember, maxMember, lastMember, string.format('%.17g', sum / count), tostring(count), firstMember}
+`)
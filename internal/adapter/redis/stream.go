@@ -0,0 +1,189 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidStreamEntry = errors.New("invalid stream entry")
+
+const streamKeyPrefix = "stream:"
+
+// Stream is a durable ingestion buffer on per-exchange Redis Streams
+type Stream struct {
+	client *goredis.Client
+
+	cfg config.Stream
+}
+
+// NewStream returns Stream sharing connection with given cache
+func NewStream(cache *Cache, cfg config.Stream) *Stream {
+	return &Stream{
+		client: cache.client,
+		cfg:    cfg,
+	}
+}
+
+// Publish appends validated price data to per-exchange stream (XADD)
+func (s *Stream) Publish(ctx context.Context, p *domain.PriceData) error {
+	key := s.createStreamKey(p.Exchange)
+
+	err := s.client.XAdd(ctx, &goredis.XAddArgs{
+		Stream: key,
+		MaxLen: s.cfg.MaxLen,
+		Approx: true,
+		Values: []any{
+			"symbol", string(p.Symbol),
+			"price", strconv.FormatFloat(p.Price, 'f', -1, 64),
+			"ts", strconv.FormatInt(p.Timestamp.UnixMilli(), 10),
+			"source", string(types.SourceOrLive(p.Source)),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to add to stream %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// EnsureGroup creates consumer group for exchange stream if it does not exist yet
+func (s *Stream) EnsureGroup(ctx context.Context, exchange types.Exchange, group string) error {
+	key := s.createStreamKey(exchange)
+
+	err := s.client.XGroupCreateMkStream(ctx, key, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create group %s for stream %s: %w", group, key, err)
+	}
+
+	return nil
+}
+
+// Read reads entries from exchange streams as a member of consumer group.
+// If pending is true, entries already delivered to this consumer but not acknowledged are returned.
+func (s *Stream) Read(ctx context.Context, group, consumer string, exchanges []types.Exchange, pending bool) ([]domain.StreamTick, error) {
+	id := ">"
+	block := s.cfg.Block
+	if pending {
+		id = "0"
+		block = -1 // no blocking for history of pending entries
+	}
+
+	streams := make([]string, 0, len(exchanges)*2)
+	for _, exchange := range exchanges {
+		streams = append(streams, s.createStreamKey(exchange))
+	}
+	for range exchanges {
+		streams = append(streams, id)
+	}
+
+	res, err := s.client.XReadGroup(ctx, &goredis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  streams,
+		Count:    s.cfg.BatchSize,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read streams: %w", err)
+	}
+
+	var ticks []domain.StreamTick
+	for _, stream := range res {
+		exchange := types.Exchange(strings.TrimPrefix(stream.Stream, streamKeyPrefix))
+		ticks = append(ticks, decodeStreamMessages(exchange, stream.Messages)...)
+	}
+
+	return ticks, nil
+}
+
+// Claim transfers entries that were pending longer than minIdle (e.g. owned by crashed consumer) to given consumer
+func (s *Stream) Claim(ctx context.Context, exchange types.Exchange, group, consumer string, minIdle time.Duration) ([]domain.StreamTick, error) {
+	key := s.createStreamKey(exchange)
+
+	var ticks []domain.StreamTick
+	start := "0-0"
+	for {
+		messages, next, err := s.client.XAutoClaim(ctx, &goredis.XAutoClaimArgs{
+			Stream:   key,
+			Group:    group,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Start:    start,
+			Count:    s.cfg.BatchSize,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim pending entries of %s: %w", key, err)
+		}
+
+		ticks = append(ticks, decodeStreamMessages(exchange, messages)...)
+
+		if next == "0-0" || len(messages) == 0 {
+			return ticks, nil
+		}
+		start = next
+	}
+}
+
+// Ack acknowledges processed entries of exchange stream
+func (s *Stream) Ack(ctx context.Context, exchange types.Exchange, group string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	key := s.createStreamKey(exchange)
+	if err := s.client.XAck(ctx, key, group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to ack entries of %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// decodeStreamMessages maps stream messages to ticks. Corrupted entries keep only id, so they can be acknowledged.
+func decodeStreamMessages(exchange types.Exchange, messages []goredis.XMessage) []domain.StreamTick {
+	ticks := make([]domain.StreamTick, 0, len(messages))
+	for _, msg := range messages {
+		price, _ := decodeStreamValues(exchange, msg.Values)
+		ticks = append(ticks, domain.StreamTick{ID: msg.ID, Exchange: exchange, Price: price})
+	}
+	return ticks
+}
+
+func decodeStreamValues(exchange types.Exchange, values map[string]any) (*domain.PriceData, error) {
+	symbol, _ := values["symbol"].(string)
+	priceStr, _ := values["price"].(string)
+	tsStr, _ := values["ts"].(string)
+	source, _ := values["source"].(string)
+
+	price, err := strconv.ParseFloat(priceStr, 64)
+	if err != nil {
+		return nil, ErrInvalidStreamEntry
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidStreamEntry
+	}
+
+	return &domain.PriceData{
+		Symbol:    types.Symbol(symbol),
+		Exchange:  exchange,
+		Price:     price,
+		Timestamp: time.UnixMilli(ts),
+		Source:    types.SourceOrLive(types.Source(source)),
+	}, nil
+}
+
+func (s *Stream) createStreamKey(exchange types.Exchange) string {
+	return streamKeyPrefix + string(exchange)
+}
@@ -6,115 +6,380 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"marketflow/config"
+	"marketflow/internal/adapter/archive"
 	"marketflow/internal/adapter/exchange"
+	"marketflow/internal/adapter/http/handler"
 	httpserver "marketflow/internal/adapter/http/server"
 	repo "marketflow/internal/adapter/postgres"
 	"marketflow/internal/adapter/redis"
+	"marketflow/internal/domain"
 	"marketflow/internal/domain/types"
 	"marketflow/internal/ports"
 	"marketflow/internal/service"
 	"marketflow/pkg/logger"
 	"marketflow/pkg/postgres"
+	"marketflow/pkg/ratelimit"
 )
 
 const serviceName = "marketflow"
 
+// App runs components of the given role. Components of different roles
+// coordinate only through Redis and Postgres, so each role can be scaled independently.
 type App struct {
+	role types.Role
+
 	httpServer      *httpserver.API
-	postgresDB      *postgres.PostgreDB
+	postgresDB      *postgres.PostgreDB // nil for ingest role without authentication and tick persistence
 	redis           *redis.Cache
-	exchangeManager ports.ExchangeManager
-	scheduler       ports.Sheduler
-
-	log logger.Logger
+	exchangeManager ports.ExchangeManager // ingest role
+	aggregator      ports.Aggregator      // aggregate role, runs only on leader
+	streamConsumer  ports.StreamConsumer  // aggregate role with enabled streams
+	scheduler       ports.Sheduler        // aggregate role, runs only on leader
+	leaderElector   ports.LeaderElector   // aggregate role
+	tickWriter      *service.TickWriter   // role running collector or aggregate role with persisted ticks
+
+	cancel context.CancelFunc
+	log    logger.Logger
 }
 
 func NewApplication(ctx context.Context, config config.Config, logger logger.Logger) (*App, error) {
 	const fn = "app.NewApplication"
 
 	log := logger.GetSlogLogger().With("fn", fn)
-	// Postgres database
-	db, err := postgres.New(ctx, config.Postgres)
-	if err != nil {
-		log.Error("failed to connect postgres", "dsn", config.Postgres.Dsn, "error", err)
-		return nil, fmt.Errorf("failed to connect postgres: %v", err)
+
+	role := types.Role(config.Role)
+	if !types.IsValidRole(config.Role) {
+		return nil, fmt.Errorf("invalid role %q, available roles %v", config.Role, types.ValidRoles)
+	}
+
+	app := &App{
+		role: role,
+		log:  logger,
 	}
 
 	// Redis client
 	cache, err := redis.NewClient(ctx, config.Redis)
 	if err != nil {
-		log.Error("failed to connect postgres", "address", config.Redis.Addr, "error", err)
+		log.Error("failed to connect redis", "address", config.Redis.Addr, "error", err)
 		return nil, fmt.Errorf("failed to connect redis: %v", err)
 	}
+	app.redis = cache
+
+	// List of running services for healthcheck
+	serviceList := []httpserver.Service{cache}
+
+	// Raw ticks are recorded by collector storing them to the cache,
+	// it runs in ingest role or in aggregate role consuming streams
+	recordsTicks := config.Ticks.Enabled &&
+		(role.RunsIngest() && !config.DataManager.Stream.Enabled || role.RunsAggregate() && config.DataManager.Stream.Enabled)
+
+	// Postgres database is not used by ingestion, unless API keys are checked or ticks are persisted
+	var marketRepo *repo.MarketRepo
+	var coverageRepo *repo.CoverageRepo
+	var authenticator httpserver.Authenticator
+	var tickRecorder ports.TickRecorder
+	if role.RunsAggregate() || role.RunsAPI() || config.Auth.Enabled || recordsTicks {
+		db, err := postgres.New(ctx, config.Postgres)
+		if err != nil {
+			log.Error("failed to connect postgres", "dsn", config.Postgres.Dsn, "error", err)
+			return nil, fmt.Errorf("failed to connect postgres: %v", err)
+		}
+		app.postgresDB = db
+		serviceList = append(serviceList, db)
+
+		// repository
+		marketRepo = repo.NewMarketRepository(db.Pool)
+		coverageRepo = repo.NewCoverageRepository(db.Pool)
+
+		if config.Auth.Enabled {
+			authenticator = service.NewAPIKeys(repo.NewAPIKeyRepository(db.Pool), config.Auth.CacheTTL, logger)
+		}
+
+		// Aggregate role maintains partitions even if ticks are recorded by ingest role
+		if config.Ticks.Enabled && (recordsTicks || role.RunsAggregate()) {
+			if config.Ticks.BatchSize <= 0 || config.Ticks.BufferSize <= 0 || config.Ticks.FlushInterval <= 0 || config.Ticks.MaxRetries < 0 {
+				return nil, fmt.Errorf("ticks batch size, buffer size and flush interval must be positive, max retries must not be negative")
+			}
+			app.tickWriter = service.NewTickWriter(repo.NewTickRepository(db.Pool), config.Ticks, logger)
+			if recordsTicks {
+				tickRecorder = app.tickWriter
+				serviceList = append(serviceList, app.tickWriter)
+			}
+		}
+	}
 
-	// Define data sources
-	exchange1 := exchange.NewExchange(types.Exchange1, config.DataManager.Exchanges.Exchange1Addr, logger)
-	exchange2 := exchange.NewExchange(types.Exchange2, config.DataManager.Exchanges.Exchange2Addr, logger)
-	exchange3 := exchange.NewExchange(types.Exchange3, config.DataManager.Exchanges.Exchange3Addr, logger)
-
-	// Test mode data sources
-	// exchange1 := exchange.GenerateTestData()
-	// exchange2 := exchange.GenerateTestData()
-	// exchange3 := exchange.GenerateTestData()
+	if !config.Auth.Enabled {
+		log.Warn("authentication is disabled, mode switching and admin routes are public")
+	}
 
-	liveSources := []ports.ExchangeSource{
-		exchange1,
-		exchange2,
-		exchange3,
+	// Durable ingestion buffer
+	stream := redis.NewStream(cache, config.DataManager.Stream)
+
+	// Incidents explaining gaps in data, recorded by ingest and aggregate roles
+	events := redis.NewEvents(cache, config.Coverage.EventRetention)
+
+	var (
+		modeSwitcher      handler.ModeSwitcher
+		modeProvider      httpserver.ModeProvider
+		leaderProvider    httpserver.LeaderProvider
+		feedProvider      httpserver.FeedProvider
+		freshnessProvider httpserver.FreshnessProvider
+		certProvider      httpserver.CertificateProvider
+		tickProvider      httpserver.TickWriterProvider
+		taskLister        handler.TaskLister
+		sourceManager     handler.SourceManager
+		archiveReader     handler.ArchiveReader
+	)
+
+	// Ticks lost by writer are reported by the role recording them
+	if tickRecorder != nil {
+		tickProvider = app.tickWriter
 	}
 
-	// repository
-	repo := repo.NewMarketRepository(db.Pool)
+	// Ingest role measures freshness of feeds, API role marks stale latest prices by the same thresholds
+	if config.Freshness.ExchangeStaleAfter <= 0 || config.Freshness.SymbolStaleAfter <= 0 {
+		return nil, fmt.Errorf("freshness thresholds must be positive")
+	}
 
-	// Market service
-	market := service.NewMarket(repo, cache, logger)
+	// Archiver drains history of aggregate role and serves archives of API role
+	var archiver *service.Archiver
+	if config.Archive.Enabled && (role.RunsAggregate() || role.RunsAPI()) {
+		if config.Archive.Interval <= 0 || config.Archive.Interval >= config.Redis.HistoryDeleteDuration {
+			return nil, fmt.Errorf("archive interval must be positive and shorter than Redis history retention %s", config.Redis.HistoryDeleteDuration)
+		}
+
+		store, err := archive.NewStore(ctx, config.Archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init archive store: %w", err)
+		}
+		archiver = service.NewArchiver(redis.NewArchiveStaging(cache), store, archive.NewParquetEncoder(), config.Archive, config.Redis.HistoryDeleteDuration, logger)
+	}
 
-	// ExchangeManager
-	exchangeManager := service.NewExchangeManager(true, liveSources, repo, cache, config.DataManager, logger)
+	if role.RunsIngest() {
+		bp := config.DataManager.Backpressure
+		for _, policy := range []string{bp.WorkersPolicy, bp.ProcessedPolicy, bp.CollectorPolicy} {
+			if !types.IsValidBackpressurePolicy(policy) {
+				return nil, fmt.Errorf("invalid backpressure policy %q, available policies %v", policy, types.ValidBackpressurePolicies)
+			}
+		}
+		if bp.WorkersSize <= 0 || bp.ProcessedSize <= 0 || bp.CollectorSize <= 0 || bp.Watermark <= 0 || bp.Watermark > 1 {
+			return nil, fmt.Errorf("backpressure buffer sizes must be positive and watermark must be in (0, 1]")
+		}
+		if config.DataManager.Feed.SkewWindow <= 0 || config.DataManager.Feed.RebaseThreshold < 0 {
+			return nil, fmt.Errorf("feed skew window must be positive and rebase threshold must not be negative")
+		}
+
+		// Venues differ in transport and feed format, every exchange is read with its configured ones
+		sources, err := exchange.NewLiveSources(config.DataManager.Exchanges, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		// Define data sources
+		exchange1 := sources.New(types.Exchange1, config.DataManager.Exchanges.Exchange1Addr)
+		exchange2 := sources.New(types.Exchange2, config.DataManager.Exchanges.Exchange2Addr)
+		exchange3 := sources.New(types.Exchange3, config.DataManager.Exchanges.Exchange3Addr)
+
+		liveSources := []ports.ExchangeSource{
+			exchange1,
+			exchange2,
+			exchange3,
+		}
+
+		// ExchangeManager
+		exchangeManager := service.NewExchangeManager(types.SourceLive, liveSources, sources, cache, stream, tickRecorder, events, config.DataManager, config.Freshness, logger)
+		app.exchangeManager = exchangeManager
+		modeSwitcher = exchangeManager
+		modeProvider = exchangeManager
+		feedProvider = exchangeManager
+		freshnessProvider = exchangeManager
+		certProvider = sources
+		sourceManager = exchangeManager
+
+		// Feeds are healthy while they send ticks, connection alone does not tell it
+		for _, feed := range exchangeManager.FeedHealth() {
+			serviceList = append(serviceList, feed)
+		}
+	}
 
-	// Scheduler
-	scheduler := service.NewScheduler(ctx, logger)
-	scheduler.AddTask("Delete expired exchange history", types.TaskTypeInterval, config.Redis.HistoryDeleteDuration, cache.DeleteExpiredHistory)
+	// Coverage is scanned by aggregate role and reported by API role
+	var coverage *service.Coverage
+	if role.RunsAggregate() || role.RunsAPI() {
+		if config.Coverage.ScanInterval <= 0 || config.Coverage.ScanWindow < config.Coverage.ScanInterval || config.Coverage.Settle < 0 {
+			return nil, fmt.Errorf("coverage scan interval must be positive and not longer than scan window, settle must not be negative")
+		}
+		coverage = service.NewCoverage(coverageRepo, cache, events, config.Coverage, logger)
+	}
 
-	// List of all services for healthcheck
-	serviceList := []httpserver.Service{
-		exchange1,
-		exchange2,
-		exchange3,
-		db,
-		cache,
+	if role.RunsAggregate() {
+		app.aggregator = service.NewAggregator(marketRepo, cache, events, config.DataManager.Aggregator, logger)
+
+		// Stream consumer stores prices published by ingestion
+		if config.DataManager.Stream.Enabled {
+			app.streamConsumer = service.NewStreamConsumer(stream, cache, tickRecorder, config.DataManager.Stream, config.DataManager.Collector, logger)
+		}
+
+		// Scheduler
+		scheduler := service.NewScheduler(ctx, logger)
+		err = scheduler.AddTask(domain.Task{
+			Name:     "Delete expired exchange history",
+			Type:     types.TaskTypeInterval,
+			Interval: config.Redis.HistoryDeleteDuration,
+			Retries:  2,
+			Backoff:  time.Second,
+			Handler:  cache.DeleteExpiredHistory,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add task: %w", err)
+		}
+		if app.tickWriter != nil {
+			err = scheduler.AddTask(domain.Task{
+				Name:     "Maintain tick partitions",
+				Type:     types.TaskTypeInterval,
+				Interval: time.Hour,
+				Retries:  2,
+				Backoff:  time.Second,
+				Handler:  app.tickWriter.MaintainPartitions,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to add task: %w", err)
+			}
+		}
+		if archiver != nil {
+			err = scheduler.AddTask(domain.Task{
+				Name:     "Archive exchange history",
+				Type:     types.TaskTypeInterval,
+				Interval: config.Archive.Interval,
+				Retries:  2,
+				Backoff:  time.Second,
+				Handler:  archiver.Run,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to add task: %w", err)
+			}
+		}
+		err = scheduler.AddTask(domain.Task{
+			Name:     "Detect data gaps",
+			Type:     types.TaskTypeInterval,
+			Interval: config.Coverage.ScanInterval,
+			Retries:  2,
+			Backoff:  time.Second,
+			Handler:  coverage.Scan,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add task: %w", err)
+		}
+		app.scheduler = scheduler
+		taskLister = scheduler
+
+		// Only one instance runs aggregator and scheduler
+		leaderElector := service.NewLeaderElector(
+			redis.NewLease(cache),
+			config.DataManager.Leader,
+			func(ctx context.Context) {
+				app.aggregator.Start(ctx)
+				app.scheduler.Start()
+			},
+			app.scheduler.Close,
+			logger,
+		)
+		app.leaderElector = leaderElector
+		leaderProvider = leaderElector
 	}
 
-	// REST API server
-	httpServer := httpserver.New(config, market, exchangeManager, serviceList, exchangeManager, logger)
+	// Market service
+	var market ports.Market
+	var exporter handler.Exporter
+	var coverageReporter handler.CoverageReporter
+	if role.RunsAPI() {
+		market = service.NewMarket(marketRepo, cache, config.Freshness.SymbolStaleAfter, logger)
+		exporter = service.NewExport(cache, marketRepo, logger)
+		coverageReporter = coverage
+		if archiver != nil {
+			archiveReader = archiver
+		}
+	}
 
-	app := &App{
-		httpServer:      httpServer,
-		postgresDB:      db,
-		exchangeManager: exchangeManager,
-		redis:           cache,
-		scheduler:       scheduler,
-		log:             logger,
+	// Rate limiter of REST API
+	var rateLimiter ratelimit.Store
+	if config.RateLimit.Enabled {
+		switch config.RateLimit.Store {
+		case "memory":
+			rateLimiter = ratelimit.NewMemory()
+		case "redis":
+			rateLimiter = redis.NewRateLimitStore(cache)
+		default:
+			return nil, fmt.Errorf("invalid rate limit store %q, available stores [memory redis]", config.RateLimit.Store)
+		}
+		if config.RateLimit.Rate <= 0 || config.RateLimit.Burst <= 0 {
+			return nil, fmt.Errorf("rate limit rate and burst must be positive")
+		}
 	}
+
+	// REST API server, other roles serve only health check
+	app.httpServer = httpserver.New(config, role, httpserver.Options{
+		Market:              market,
+		ModeSwitcher:        modeSwitcher,
+		Services:            serviceList,
+		ModeProvider:        modeProvider,
+		LeaderProvider:      leaderProvider,
+		FeedProvider:        feedProvider,
+		FreshnessProvider:   freshnessProvider,
+		CertificateProvider: certProvider,
+		TickWriterProvider:  tickProvider,
+		TaskLister:          taskLister,
+		SourceManager:       sourceManager,
+		Exporter:            exporter,
+		ArchiveReader:       archiveReader,
+		Coverage:            coverageReporter,
+		Authenticator:       authenticator,
+		RateLimiter:         rateLimiter,
+	}, logger)
+
 	return app, nil
 }
 
 func (app *App) close(ctx context.Context) {
-	app.scheduler.Close()
-
-	// Closing http server
+	// Closing http server first, in-flight requests drain while storages are still available
 	if err := app.httpServer.Stop(); err != nil {
 		app.log.Warn(ctx, "failed to shutdown HTTP service", "error", err)
 	}
 
-	if err := app.exchangeManager.Close(); err != nil {
-		app.log.Warn(ctx, "failed to shutdown exchange manager", "error", err)
+	// Stopping singleton duties and releasing leadership
+	if app.leaderElector != nil {
+		if err := app.leaderElector.Close(); err != nil {
+			app.log.Warn(ctx, "failed to shutdown leader elector", "error", err)
+		}
+	}
+
+	if app.exchangeManager != nil {
+		if err := app.exchangeManager.Close(); err != nil {
+			app.log.Warn(ctx, "failed to shutdown exchange manager", "error", err)
+		}
+	}
+
+	if app.streamConsumer != nil {
+		if err := app.streamConsumer.Cancel(); err != nil {
+			app.log.Warn(ctx, "failed to shutdown stream consumer", "error", err)
+		}
 	}
 
+	// Collectors are stopped, writing recorded ticks
+	if app.tickWriter != nil {
+		if err := app.tickWriter.Cancel(); err != nil {
+			app.log.Warn(ctx, "failed to shutdown tick writer", "error", err)
+		}
+	}
+
+	app.cancel()
+
 	// Closing database connection
-	app.postgresDB.Pool.Close()
+	if app.postgresDB != nil {
+		app.postgresDB.Pool.Close()
+	}
 
 	// Closing redis
 	app.redis.Close()
@@ -125,20 +390,44 @@ func (app *App) Run() error {
 	log := app.log.GetSlogLogger().With("fn", fn)
 
 	errCh := make(chan error, 1)
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	app.cancel = cancel
+
+	// Tick writer is started before collectors recording to it
+	if app.tickWriter != nil {
+		app.tickWriter.Start(ctx)
+	}
+
 	// Running DataManager
-	if err := app.exchangeManager.Start(ctx); err != nil {
-		log.Error("failed to start exchange manager", "error", err)
-		return err
+	if app.exchangeManager != nil {
+		if err := app.exchangeManager.Start(ctx); err != nil {
+			log.Error("failed to start exchange manager", "error", err)
+			return err
+		}
 	}
 
-	// Running http server
-	app.httpServer.Run(errCh)
+	if app.streamConsumer != nil {
+		if err := app.streamConsumer.Start(ctx); err != nil {
+			log.Error("failed to start stream consumer", "error", err)
+			return err
+		}
+	}
 
-	// Running scheduler
-	app.scheduler.Start()
+	// Running aggregator and scheduler once elected as leader
+	if app.leaderElector != nil {
+		if err := app.leaderElector.Start(ctx); err != nil {
+			log.Error("failed to start leader elector", "error", err)
+			return err
+		}
+	}
+
+	// Running http server
+	if err := app.httpServer.Run(errCh); err != nil {
+		log.Error("failed to start http server", "error", err)
+		return err
+	}
 
-	log.InfoContext(ctx, "application started", "name", serviceName)
+	log.InfoContext(ctx, "application started", "name", serviceName, "role", app.role)
 
 	// Waiting signal
 	shutdownCh := make(chan os.Signal, 1)
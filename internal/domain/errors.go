@@ -12,6 +12,22 @@ var (
 	ErrNegativePrice    = errors.New("price cannot be negative")
 	ErrInvalidTimestamp = errors.New("invalid timestamp (zero time)")
 
-	ErrAlreadyOnLiveMode = errors.New("server is already on live mode")
-	ErrAlreadyOnTestMode = errors.New("server is already on test mode")
+	ErrAlreadyOnLiveMode   = errors.New("server is already on live mode")
+	ErrAlreadyOnTestMode   = errors.New("server is already on test mode")
+	ErrAlreadyOnMode       = errors.New("exchange is already on requested mode")
+	ErrInvalidMode         = errors.New("invalid mode")
+	ErrInvalidMetric       = errors.New("invalid metric")
+	ErrInvalidExportFormat = errors.New("invalid export format")
+	ErrManagerStopped      = errors.New("exchange manager is stopped")
+	ErrSourcePaused        = errors.New("exchange source is paused")
+	ErrSourceNotPaused     = errors.New("exchange source is not paused")
+	ErrInvalidAddress      = errors.New("invalid exchange address")
+	ErrNothingToReplay     = errors.New("no recorded ticks to replay")
+
+	ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+	ErrInvalidScope  = errors.New("invalid scope")
+
+	ErrTaskExists   = errors.New("task with given name already exists")
+	ErrTaskNotFound = errors.New("task not found")
+	ErrInvalidTask  = errors.New("invalid task")
 )
@@ -1,8 +1,10 @@
 package domain
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,6 +16,12 @@ type PriceData struct {
 	Exchange  types.Exchange `json:"exchange"`
 	Price     float64        `json:"price"`
 	Timestamp time.Time      `json:"timestamp"`
+	Source    types.Source   `json:"source,omitempty"` // empty means live
+
+	// ReceivedAt is local time the tick was read from live exchange, it is not stored
+	ReceivedAt time.Time `json:"-"`
+	// Stale is set on latest price served by API when it is older than staleness threshold
+	Stale bool `json:"stale,omitempty"`
 }
 
 func (p *PriceData) IsValid() (bool, error) {
@@ -82,4 +90,241 @@ type PriceStats struct {
 	Average   float64        `json:"average,omitempty"`
 	Min       float64        `json:"min,omitempty"`
 	Max       float64        `json:"max,omitempty"`
+	Source    types.Source   `json:"source,omitempty"`
+	Ticks     *TickRange     `json:"ticks,omitempty"` // set by aggregator when stats are stored
+}
+
+// TickRange references raw ticks stats were computed from, ticks of the same exchange, symbol and source
+// with timestamps in [From, To], the first and the last of them. Min and max prices are the ticks at MinAt and MaxAt.
+type TickRange struct {
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Count int       `json:"count"`
+	MinAt time.Time `json:"min_at"`
+	MaxAt time.Time `json:"max_at"`
+}
+
+// StreamTick is a price data read from durable ingestion stream
+type StreamTick struct {
+	ID       string // stream entry id, used to acknowledge processing
+	Exchange types.Exchange
+	Price    *PriceData // nil if entry is corrupted
+}
+
+// Leadership describes current state of leader election for singleton duties
+type Leadership struct {
+	Holder    string    `json:"holder"` // empty if there is no leader
+	IsLeader  bool      `json:"is_leader"`
+	ExpiresAt time.Time `json:"lease_expires_at,omitzero"`
+}
+
+// Task is a job run by scheduler
+type Task struct {
+	Name string
+	Type types.TaskType
+
+	Interval time.Duration // for TaskTypeInterval
+	Cron     string        // for TaskTypeCron, e.g. "0 * * * *"
+	Delay    time.Duration // for TaskTypeOnce
+
+	Timeout time.Duration       // timeout of single attempt, 0 - half of interval for interval tasks, none for others
+	Retries int                 // number of retries after failed attempt
+	Backoff time.Duration       // delay before first retry, doubles with every next retry
+	Overlap types.OverlapPolicy // skip by default
+
+	Handler func(ctx context.Context) error
+}
+
+// TaskInfo is a state of scheduled task
+type TaskInfo struct {
+	Name         string         `json:"name"`
+	Type         types.TaskType `json:"type"`
+	Schedule     string         `json:"schedule"`
+	Running      bool           `json:"running"`
+	Runs         int            `json:"runs"`
+	LastRun      time.Time      `json:"last_run,omitzero"`
+	LastDuration string         `json:"last_duration,omitempty"`
+	NextRun      time.Time      `json:"next_run,omitzero"`
+	LastError    string         `json:"last_error,omitempty"`
+}
+
+// SourceInfo is a state of exchange source
+type SourceInfo struct {
+	Exchange      types.Exchange    `json:"exchange"`
+	Mode          types.Source      `json:"mode"`
+	Address       string            `json:"address,omitempty"` // used in live mode
+	State         types.SourceState `json:"state"`
+	ConnectedAt   time.Time         `json:"connected_at,omitzero"`
+	TicksReceived int64             `json:"ticks_received"`
+	LastTickAt    time.Time         `json:"last_tick_at,omitzero"`
+	LastTickAge   string            `json:"last_tick_age,omitempty"`
+	LastError     string            `json:"last_error,omitempty"`
+}
+
+// TickWriterStats are counters of ticks persisted for audit since start. Dropped ticks did not fit the buffer,
+// discarded ones failed to be written after retries. Attempts are failed attempts of the batch being retried.
+type TickWriterStats struct {
+	Written   int64 `json:"written"`
+	Dropped   int64 `json:"dropped"`
+	Discarded int64 `json:"discarded"`
+	Buffered  int   `json:"buffered"`
+	Failing   bool  `json:"failing"`
+	Attempts  int   `json:"attempts"`
+}
+
+// FeedLatency is latency of ticks from exchange to ingest and clock skew of exchange, in milliseconds.
+// Skew is positive if exchange clock is behind local clock, percentiles are since start.
+type FeedLatency struct {
+	Exchange     types.Exchange `json:"exchange"`
+	Ticks        int64          `json:"ticks"`
+	LatencyP50   float64        `json:"latency_p50_ms"`
+	LatencyP90   float64        `json:"latency_p90_ms"`
+	LatencyP99   float64        `json:"latency_p99_ms"`
+	Skew         float64        `json:"clock_skew_ms"`
+	SkewP50      float64        `json:"clock_skew_p50_ms"`
+	SkewP99      float64        `json:"clock_skew_p99_ms"`
+	Rebasing     bool           `json:"rebasing"` // timestamps are replaced by receive time
+	RebasedTicks int64          `json:"rebased_ticks"`
+}
+
+// Certificate is TLS certificate used for exchange connections: client certificate or CA trusted for exchange.
+// Error is set when files changed but could not be reloaded, the previously loaded certificates stay in use.
+type Certificate struct {
+	Exchange    types.Exchange `json:"exchange"`
+	Kind        string         `json:"kind"` // client or ca
+	File        string         `json:"file"`
+	Subject     string         `json:"subject"`
+	Issuer      string         `json:"issuer"`
+	NotAfter    time.Time      `json:"not_after"`
+	ExpiresIn   string         `json:"expires_in"`
+	ExpiresSoon bool           `json:"expires_soon"`
+	Expired     bool           `json:"expired"`
+	LoadedAt    time.Time      `json:"loaded_at"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// Freshness is age of the last ticks of exchanges and their symbols, measured by tick timestamps
+type Freshness struct {
+	Status    types.FreshnessStatus `json:"status"`
+	Exchanges []ExchangeFreshness   `json:"exchanges"`
+}
+
+type ExchangeFreshness struct {
+	Exchange    types.Exchange        `json:"exchange"`
+	Status      types.FreshnessStatus `json:"status"`
+	LastTickAt  time.Time             `json:"last_tick_at,omitzero"`
+	LastTickAge string                `json:"last_tick_age,omitempty"`
+	Symbols     []SymbolFreshness     `json:"symbols,omitempty"` // not set for paused exchange
+}
+
+type SymbolFreshness struct {
+	Symbol      types.Symbol `json:"symbol"`
+	LastTickAt  time.Time    `json:"last_tick_at,omitzero"`
+	LastTickAge string       `json:"last_tick_age,omitempty"`
+	Stale       bool         `json:"stale"`
+}
+
+// PipelineEvent is an incident of data pipeline that may leave minutes without data
+type PipelineEvent struct {
+	At       time.Time      `json:"at"`
+	Exchange types.Exchange `json:"exchange,omitempty"` // empty if event affects all exchanges
+	Cause    types.GapCause `json:"cause"`
+	Detail   string         `json:"detail,omitempty"`
+}
+
+// Gap is a run of minutes [From, To) without live data of exchange and symbol
+type Gap struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Minutes int            `json:"minutes"`
+	Cause   types.GapCause `json:"cause"`
+	Detail  string         `json:"detail,omitempty"`
+}
+
+// Coverage is a share of minutes in [From, To) with live data of exchange and symbol
+type Coverage struct {
+	Exchange       types.Exchange `json:"exchange"`
+	Symbol         types.Symbol   `json:"symbol"`
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	Minutes        int            `json:"minutes"`
+	CoveredMinutes int            `json:"covered_minutes"`
+	Percent        float64        `json:"coverage_percent"`
+	Gaps           []Gap          `json:"gaps"`
+}
+
+// ModeStatus is a data mode and state of data pipeline
+type ModeStatus struct {
+	Mode      string                          `json:"mode"`
+	State     types.PipelineState             `json:"state"`
+	Exchanges map[types.Exchange]types.Source `json:"exchanges"`
+}
+
+// APIKey is a key granting access to API, only hash of the key is stored
+type APIKey struct {
+	ID        int           `json:"id"`
+	Name      string        `json:"name"`
+	Prefix    string        `json:"prefix"` // first characters of the key to recognize it
+	Scopes    []types.Scope `json:"scopes"`
+	CreatedAt time.Time     `json:"created_at"`
+	RevokedAt time.Time     `json:"revoked_at,omitzero"`
+}
+
+// HasScope reports whether key grants the scope, admin scope grants everything
+func (k *APIKey) HasScope(scope types.Scope) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, types.ScopeAdmin)
+}
+
+// IsRevoked reports whether key was revoked
+func (k *APIKey) IsRevoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// PriceQuery is a single query of batch price request
+type PriceQuery struct {
+	Metric   types.Metric
+	Exchange types.Exchange // AllExchanges for price among all exchanges
+	Symbol   types.Symbol
+	Period   time.Duration // not used by latest metric
+}
+
+// PriceQueryResult is a result of PriceQuery, Latest is set for latest metric, Stats for others
+type PriceQueryResult struct {
+	Latest *PriceData
+	Stats  *PriceStats
+	Err    error
+}
+
+// PeriodStats is a min, max and average price of symbol in period, prices are nil if there is no data
+type PeriodStats struct {
+	Min, Max, Average *PriceData
+	Err               error
+}
+
+// ExportFilter selects exported data, From is inclusive and To is exclusive
+type ExportFilter struct {
+	Exchanges []types.Exchange
+	Symbols   []types.Symbol
+	From, To  time.Time
+}
+
+// ArchiveFile is an hourly file of raw ticks of symbol on exchange
+type ArchiveFile struct {
+	Path      string         `json:"path"` // relative to archive root
+	Exchange  types.Exchange `json:"exchange"`
+	Symbol    types.Symbol   `json:"symbol"`
+	Hour      time.Time      `json:"hour"` // start of the hour in UTC
+	Rows      int            `json:"rows"`
+	Size      int64          `json:"size"`
+	SHA256    string         `json:"sha256"`
+	FirstTick time.Time      `json:"first_tick"`
+	LastTick  time.Time      `json:"last_tick"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ArchiveFilter selects archive files by days, empty exchanges or symbols match all
+type ArchiveFilter struct {
+	Exchanges []types.Exchange
+	Symbols   []types.Symbol
+	From, To  time.Time // days, both inclusive
 }
@@ -0,0 +1,19 @@
+package types
+
+import "slices"
+
+// BackpressurePolicy defines what a pipeline stage does with a tick when its buffer is full
+type BackpressurePolicy string
+
+const (
+	PolicyBlock      BackpressurePolicy = "block"       // sender waits for free slot
+	PolicyDropOldest BackpressurePolicy = "drop-oldest" // oldest buffered tick is dropped
+	PolicyDropNewest BackpressurePolicy = "drop-newest" // new tick is dropped
+	PolicyConflate   BackpressurePolicy = "conflate"    // waiting ticks are replaced by the latest one of the same exchange and symbol
+)
+
+var ValidBackpressurePolicies = []BackpressurePolicy{PolicyBlock, PolicyDropOldest, PolicyDropNewest, PolicyConflate}
+
+func IsValidBackpressurePolicy(s string) bool {
+	return slices.Contains(ValidBackpressurePolicies, BackpressurePolicy(s))
+}
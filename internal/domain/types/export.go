@@ -0,0 +1,17 @@
+package types
+
+import "slices"
+
+// ExportFormat defines format of exported data
+type ExportFormat string
+
+const (
+	FormatCSV    ExportFormat = "csv"
+	FormatNDJSON ExportFormat = "ndjson" // JSON object per line
+)
+
+var ValidExportFormats = []ExportFormat{FormatCSV, FormatNDJSON}
+
+func IsValidExportFormat(s string) bool {
+	return slices.Contains(ValidExportFormats, ExportFormat(s))
+}
@@ -0,0 +1,11 @@
+package types
+
+// FreshnessStatus tells whether ticks keep arriving
+type FreshnessStatus string
+
+const (
+	FreshnessFresh    FreshnessStatus = "fresh"    // recent ticks of every symbol
+	FreshnessDegraded FreshnessStatus = "degraded" // exchange sends ticks, some symbols are stale; some exchanges are stale overall
+	FreshnessStale    FreshnessStatus = "stale"    // no recent ticks
+	FreshnessPaused   FreshnessStatus = "paused"   // exchange is detached by admin, not counted overall
+)
@@ -0,0 +1,14 @@
+package types
+
+// GapCause is a known reason of missing data
+type GapCause string
+
+const (
+	CauseDisconnect        GapCause = "disconnect"         // exchange closed feed or source failed to connect
+	CauseModeSwitch        GapCause = "mode_switch"        // exchange was switched to another data mode
+	CausePaused            GapCause = "paused"             // exchange source was paused by admin
+	CauseRestart           GapCause = "restart"            // exchange source was restarted or reconfigured
+	CauseShutdown          GapCause = "shutdown"           // ingestion was stopped
+	CauseAggregatorFailure GapCause = "aggregator_failure" // aggregated stats were not stored
+	CauseUnknown           GapCause = "unknown"
+)
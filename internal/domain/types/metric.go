@@ -0,0 +1,19 @@
+package types
+
+import "slices"
+
+// Metric defines price value requested from market data
+type Metric string
+
+const (
+	MetricLatest  Metric = "latest"
+	MetricHighest Metric = "highest"
+	MetricLowest  Metric = "lowest"
+	MetricAverage Metric = "average"
+)
+
+var ValidMetrics = []Metric{MetricLatest, MetricHighest, MetricLowest, MetricAverage}
+
+func IsValidMetric(s string) bool {
+	return slices.Contains(ValidMetrics, Metric(s))
+}
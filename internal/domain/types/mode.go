@@ -1,6 +1,65 @@
 package types
 
+import "slices"
+
+const (
+	LiveMode   = "Live"
+	TestMode   = "Test"
+	ReplayMode = "Replay"
+	HybridMode = "Hybrid" // exchanges are in different modes
+)
+
+// Source defines kind of data source of an exchange, which is also the mode of the exchange
+type Source string
+
+const (
+	SourceLive   Source = "live"   // real exchange feed
+	SourceTest   Source = "test"   // synthetic data
+	SourceReplay Source = "replay" // recorded ticks replayed
+)
+
+var ValidSources = []Source{SourceLive, SourceTest, SourceReplay}
+
+func IsValidSource(s string) bool {
+	return slices.Contains(ValidSources, Source(s))
+}
+
+// Mode returns global mode name of the source
+func (s Source) Mode() string {
+	switch s {
+	case SourceTest:
+		return TestMode
+	case SourceReplay:
+		return ReplayMode
+	default:
+		return LiveMode
+	}
+}
+
+// SourceOrLive returns given source, empty source of data stored before sources were tracked means live
+func SourceOrLive(source Source) Source {
+	if source == "" {
+		return SourceLive
+	}
+	return source
+}
+
+// SourceState is a state of single exchange source managed by ExchangeManager
+type SourceState string
+
+const (
+	SourceRunning      SourceState = "running"
+	SourcePaused       SourceState = "paused"       // detached by admin
+	SourceDisconnected SourceState = "disconnected" // feed was closed by exchange
+	SourceFailed       SourceState = "failed"       // source failed to start
+)
+
+// PipelineState is a state of data pipeline managed by ExchangeManager
+type PipelineState string
+
 const (
-	LiveMode = "Live"
-	TestMode = "Test"
+	StateStarting PipelineState = "starting" // new sources are being started
+	StateRunning  PipelineState = "running"
+	StateDraining PipelineState = "draining" // old sources are being drained after switch
+	StateStopped  PipelineState = "stopped"
 )
@@ -0,0 +1,34 @@
+package types
+
+import "slices"
+
+// Role defines which components of application are running in the process
+type Role string
+
+const (
+	RoleAll       Role = "all"       // every component in a single process
+	RoleIngest    Role = "ingest"    // exchange sources, workers and collector
+	RoleAggregate Role = "aggregate" // stream consumer, aggregator and scheduler
+	RoleAPI       Role = "api"       // REST API
+)
+
+var ValidRoles = []Role{RoleAll, RoleIngest, RoleAggregate, RoleAPI}
+
+func IsValidRole(s string) bool {
+	return slices.Contains(ValidRoles, Role(s))
+}
+
+// RunsIngest reports whether role receives data from exchanges
+func (r Role) RunsIngest() bool {
+	return r == RoleAll || r == RoleIngest
+}
+
+// RunsAggregate reports whether role stores aggregated stats and runs scheduled tasks
+func (r Role) RunsAggregate() bool {
+	return r == RoleAll || r == RoleAggregate
+}
+
+// RunsAPI reports whether role serves market data API
+func (r Role) RunsAPI() bool {
+	return r == RoleAll || r == RoleAPI
+}
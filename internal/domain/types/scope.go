@@ -0,0 +1,17 @@
+package types
+
+import "slices"
+
+// Scope defines access granted to API key
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // market data
+	ScopeAdmin Scope = "admin" // mode switching and admin routes, implies read
+)
+
+var ValidScopes = []Scope{ScopeRead, ScopeAdmin}
+
+func IsValidScope(s string) bool {
+	return slices.Contains(ValidScopes, Scope(s))
+}
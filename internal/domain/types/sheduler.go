@@ -2,6 +2,18 @@ package types
 
 type (
 	TaskType string
+
+	// OverlapPolicy defines what to do when task is due while its previous run is not finished
+	OverlapPolicy string
+)
+
+const (
+	TaskTypeInterval TaskType = "Interval" // runs at start and then every interval
+	TaskTypeCron     TaskType = "Cron"     // runs by cron expression
+	TaskTypeOnce     TaskType = "Once"     // runs once after delay
 )
 
-const TaskTypeInterval = "Interval"
+const (
+	OverlapSkip  OverlapPolicy = "skip"  // due run is skipped
+	OverlapQueue OverlapPolicy = "queue" // due run starts right after current one
+)
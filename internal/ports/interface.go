@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"marketflow/internal/domain"
@@ -13,7 +14,63 @@ type Cache interface {
 	SetLatest(ctx context.Context, latest *domain.PriceData, duration time.Duration) error
 	GetLatest(ctx context.Context, exchange types.Exchange, symbol types.Symbol) (*domain.PriceData, error)
 	GetPriceInPeriod(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) ([]*domain.PriceData, error)
+	GetStatsInPeriod(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration, source types.Source) (min, max, avg *domain.PriceData, err error)
+	// GetStatsInRange returns stats of ticks in [from, to) with reference to the ticks, nil if there are no ticks
+	GetStatsInRange(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time, source types.Source) (*domain.PriceStats, error)
+	GetLatestBatch(ctx context.Context, queries []domain.PriceQuery) ([]*domain.PriceData, error)
+	GetStatsBatch(ctx context.Context, queries []domain.PriceQuery, source types.Source) ([]domain.PeriodStats, error)
 	StoreHistory(ctx context.Context, p *domain.PriceData) error
+	// StoreBatch sets latest prices and adds history of batch in single pipeline, latest must have one price per exchange and symbol
+	StoreBatch(ctx context.Context, latest, history []*domain.PriceData, latestTTL time.Duration) error
+	IterateHistory(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time, fn func(*domain.PriceData) error) error
+}
+
+// redis streams
+type TickStream interface {
+	Publish(ctx context.Context, p *domain.PriceData) error
+	EnsureGroup(ctx context.Context, exchange types.Exchange, group string) error
+	Read(ctx context.Context, group, consumer string, exchanges []types.Exchange, pending bool) ([]domain.StreamTick, error)
+	Claim(ctx context.Context, exchange types.Exchange, group, consumer string, minIdle time.Duration) ([]domain.StreamTick, error)
+	Ack(ctx context.Context, exchange types.Exchange, group string, ids ...string) error
+}
+
+// Expiring lock for leader election
+type Lease interface {
+	Acquire(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	Renew(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, key, holder string) error
+	Holder(ctx context.Context, key string) (string, time.Duration, error)
+}
+
+// Raw ticks waiting in Redis to be written to hourly archive files
+type ArchiveStaging interface {
+	// Stage copies history of exchange and symbol newer than watermark and older than upTo to staging of their hours,
+	// and moves watermark to upTo. Watermark starts at from. Returns watermark.
+	Stage(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, upTo time.Time) (time.Time, error)
+	StagedHours(ctx context.Context, exchange types.Exchange, symbol types.Symbol) ([]time.Time, error)
+	StagedTicks(ctx context.Context, exchange types.Exchange, symbol types.Symbol, hour time.Time) ([]*domain.PriceData, error)
+	DeleteStaged(ctx context.Context, exchange types.Exchange, symbol types.Symbol, hour time.Time) error
+}
+
+// Storage of archive files, local disk or S3 compatible
+type ArchiveStore interface {
+	Put(ctx context.Context, path string, data []byte, contentType string) error
+	// Get returns domain.ErrNotFound if there is no file
+	Get(ctx context.Context, path string) (io.ReadCloser, error)
+}
+
+// Encodes ticks into archive file format
+type TickEncoder interface {
+	Encode(w io.Writer, ticks []*domain.PriceData) error
+	Extension() string
+	ContentType() string
+}
+
+// Incidents of data pipeline, used to explain gaps in data
+type PipelineEvents interface {
+	RecordEvent(ctx context.Context, event domain.PipelineEvent) error
+	// GetEvents returns events in [from, to] ordered by time
+	GetEvents(ctx context.Context, from, to time.Time) ([]domain.PipelineEvent, error)
 }
 
 type ExchangeManager interface {
@@ -27,6 +84,33 @@ type MarketRepository interface {
 	GetHighestStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error)
 	GetAverageStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error)
 	GetLowestStat(ctx context.Context, exchange types.Exchange, pair types.Symbol, period time.Duration) (*domain.PriceStats, error)
+	// GetStatsBatch returns stats of query metrics in the same order, nil for queries without data
+	GetStatsBatch(ctx context.Context, queries []domain.PriceQuery) ([]*domain.PriceStats, error)
+	IterateStats(ctx context.Context, filter domain.ExportFilter, fn func(*domain.PriceStats) error) error
+}
+
+// Raw ticks in table partitioned by day
+type TickRepository interface {
+	CopyTicks(ctx context.Context, ticks []*domain.PriceData) error
+	// EnsureTickPartitions creates missing daily partitions of days in [from, to]
+	EnsureTickPartitions(ctx context.Context, from, to time.Time) error
+	// DropTickPartitionsBefore drops daily partitions of days before the given one, returns dropped partitions
+	DropTickPartitionsBefore(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// Minutes with aggregated live data and detected gaps
+type CoverageRepository interface {
+	// CoveredMinutes returns minutes in [from, to) with live ticks referenced by aggregated stats
+	CoveredMinutes(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time) ([]time.Time, error)
+	// ReplaceGaps replaces gaps starting in [from, to) with given ones, merging gap starting at from with the gap it continues
+	ReplaceGaps(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time, gaps []domain.Gap) error
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey, hash string) error
+	GetByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	List(ctx context.Context) ([]*domain.APIKey, error)
+	Revoke(ctx context.Context, id int) error
 }
 
 // ExchangeSource is an interface for Data sources
@@ -42,25 +126,44 @@ type Distributor interface {
 
 type WorkerPool interface {
 	Start(ctx context.Context)
-	Input() chan<- *domain.PriceData
+	Send(ctx context.Context, data *domain.PriceData) bool
 	Output() <-chan *domain.PriceData
 	Close()
 }
 
 type Aggregator interface {
 	Start(ctx context.Context)
-	FanIn(ctx context.Context, inputs ...<-chan *domain.PriceData) <-chan *domain.PriceData
 }
 
 type Collector interface {
 	Start(ctx context.Context, processedPrices <-chan *domain.PriceData)
+	// Done is closed when collector has stopped, after closed input is read and its ticks are stored
+	Done() <-chan struct{}
+	Cancel() error
+}
+
+// Receives every tick stored by collector for audit
+type TickRecorder interface {
+	Record(p *domain.PriceData)
+}
+
+type StreamConsumer interface {
+	Start(ctx context.Context) error
 	Cancel() error
 }
 
+type LeaderElector interface {
+	Start(ctx context.Context) error
+	Close() error
+	Leadership(ctx context.Context) (*domain.Leadership, error)
+}
+
 type Sheduler interface {
 	Start()
 	Close()
-	AddTask(name string, taskType types.TaskType, interval time.Duration, handler func(ctx context.Context) error)
+	AddTask(task domain.Task) error
+	RemoveTask(name string) error
+	Tasks() []domain.TaskInfo
 }
 
 // Service
@@ -70,4 +173,6 @@ type Market interface {
 	GetHighest(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) (*domain.PriceStats, error)
 	GetLowest(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) (*domain.PriceStats, error)
 	GetAverage(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) (*domain.PriceStats, error)
+	// GetBatch returns results of queries in the same order, errors are reported per query.
+	GetBatch(ctx context.Context, queries []domain.PriceQuery) []domain.PriceQueryResult
 }
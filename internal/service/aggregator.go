@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"sync"
 	"time"
 
 	"marketflow/config"
@@ -15,58 +14,24 @@ import (
 type Aggregator struct {
 	storage ports.MarketRepository
 	cache   ports.Cache
+	events  *eventRecorder
 
 	cfg    config.Aggregator
 	logger logger.Logger
 }
 
-func NewAggregator(storage ports.MarketRepository, cache ports.Cache, cfg config.Aggregator, logger logger.Logger) *Aggregator {
+func NewAggregator(storage ports.MarketRepository, cache ports.Cache, events ports.PipelineEvents, cfg config.Aggregator, logger logger.Logger) *Aggregator {
 	return &Aggregator{
 		storage: storage,
 		cache:   cache,
+		events:  newEventRecorder(events, logger),
 		cfg:     cfg,
 
 		logger: logger,
 	}
 }
 
-func (a *Aggregator) FanIn(ctx context.Context, inputs ...<-chan *domain.PriceData) <-chan *domain.PriceData {
-	output := make(chan *domain.PriceData)
-
-	var wg sync.WaitGroup
-
-	for _, ch := range inputs {
-		wg.Add(1)
-
-		go func(in <-chan *domain.PriceData) {
-			defer wg.Done()
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case data, ok := <-in:
-					if !ok {
-						return
-					}
-					select {
-					case output <- data:
-					case <-ctx.Done():
-						return
-					}
-				}
-			}
-		}(ch)
-	}
-
-	go func() {
-		wg.Wait()
-		close(output)
-	}()
-
-	return output
-}
-
+// Start runs aggregation of cached prices every ticker duration
 func (a *Aggregator) Start(ctx context.Context) {
 	go func() {
 		ticker := time.NewTicker(a.cfg.TickerDuration)
@@ -91,35 +56,39 @@ func (s *Aggregator) aggregateAndStore(ctx context.Context) {
 
 	stats := []*domain.PriceStats{}
 
+	// Window is truncated to milliseconds of history scores, so it matches stored ticks exactly.
+	// It is half-open, tick at its end is counted by the next window.
+	to := time.Now().Truncate(time.Millisecond)
+	from := to.Add(-time.Minute)
+
 	for _, exchange := range exchanges {
 		for _, symbol := range symbols {
-			values, err := s.cache.GetPriceInPeriod(ctx, exchange, symbol, time.Minute)
-			if len(values) == 0 {
-				s.logger.Warn(ctx, "No prices found wait for 1 minute", "exchange", exchange, "symbol", symbol, "len", len(values), "err", err)
-				continue
-			}
-			if err != nil {
-				s.logger.Error(ctx, "failed to get prices from cache", "exchange", exchange, "symbol", symbol, "error", err)
-				continue
-			}
-
-			min, max, avg := aggregate(values)
+			found := false
+
+			// Stats are stored per source, so synthetic data never mixes with live
+			for _, source := range types.ValidSources {
+				stat, err := s.cache.GetStatsInRange(ctx, exchange, symbol, from, to, source)
+				if err != nil {
+					s.logger.Error(ctx, "failed to get prices from cache", "exchange", exchange, "symbol", symbol, "source", source, "error", err)
+					continue
+				}
+				if stat == nil {
+					continue
+				}
+				found = true
 
-			stat := &domain.PriceStats{
-				Exchange:  exchange,
-				Pair:      symbol,
-				Timestamp: time.Now(),
-				Average:   avg,
-				Min:       min,
-				Max:       max,
+				stats = append(stats, stat)
 			}
 
-			stats = append(stats, stat)
+			if !found {
+				s.logger.Warn(ctx, "No prices found wait for 1 minute", "exchange", exchange, "symbol", symbol)
+			}
 		}
 	}
 
 	// Saving to the database
 	if err := s.storage.StoreStats(ctx, stats); err != nil {
 		s.logger.Error(ctx, "failed to save stats")
+		s.events.Record("", types.CauseAggregatorFailure, err.Error())
 	}
 }
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+const (
+	apiKeyPrefix      = "mf_"
+	apiKeyBytes       = 32 // random part of the key
+	apiKeyShownPrefix = 10 // characters stored in plain to recognize the key
+	maxCachedAPIKeys  = 10000
+)
+
+// APIKeys issues and verifies API keys. Keys are random, so fast hash is enough to store them.
+type APIKeys struct {
+	repo     ports.APIKeyRepository
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedKey // verified keys by hash
+
+	logger logger.Logger
+}
+
+type cachedKey struct {
+	key       *domain.APIKey
+	expiresAt time.Time
+}
+
+func NewAPIKeys(repo ports.APIKeyRepository, cacheTTL time.Duration, logger logger.Logger) *APIKeys {
+	return &APIKeys{
+		repo:     repo,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedKey),
+		logger:   logger,
+	}
+}
+
+// Create issues new key. Plain key is returned only once, only its hash is stored.
+func (s *APIKeys) Create(ctx context.Context, name string, scopes []types.Scope) (string, *domain.APIKey, error) {
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope required", domain.ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if !types.IsValidScope(string(scope)) {
+			return "", nil, fmt.Errorf("%w %q, available scopes %v", domain.ErrInvalidScope, scope, types.ValidScopes)
+		}
+	}
+
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &domain.APIKey{
+		Name:   name,
+		Prefix: plain[:apiKeyShownPrefix],
+		Scopes: scopes,
+	}
+	if err := s.repo.Create(ctx, key, hashAPIKey(plain)); err != nil {
+		return "", nil, err
+	}
+
+	return plain, key, nil
+}
+
+// Authenticate returns active key by its plain value, domain.ErrInvalidAPIKey if key is unknown or revoked
+func (s *APIKeys) Authenticate(ctx context.Context, plain string) (*domain.APIKey, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	hash := hashAPIKey(plain)
+
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		if cached.key == nil {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return cached.key, nil
+	}
+
+	key, err := s.repo.GetByHash(ctx, hash)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	if key != nil && key.IsRevoked() {
+		key = nil
+	}
+
+	// unknown keys are cached too, so repeated invalid keys do not hit database
+	s.mu.Lock()
+	if len(s.cache) >= maxCachedAPIKeys {
+		s.evictExpired()
+	}
+	if len(s.cache) < maxCachedAPIKeys {
+		s.cache[hash] = cachedKey{key: key, expiresAt: time.Now().Add(s.cacheTTL)}
+	}
+	s.mu.Unlock()
+
+	if key == nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// evictExpired removes expired keys from cache, must be called with mu held
+func (s *APIKeys) evictExpired() {
+	now := time.Now()
+	for hash, cached := range s.cache {
+		if now.After(cached.expiresAt) {
+			delete(s.cache, hash)
+		}
+	}
+}
+
+func (s *APIKeys) List(ctx context.Context) ([]*domain.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+// Revoke revokes key, other instances stop accepting it within cache TTL
+func (s *APIKeys) Revoke(ctx context.Context, id int) error {
+	if err := s.repo.Revoke(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	clear(s.cache)
+	s.mu.Unlock()
+
+	return nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+const (
+	manifestName = "manifest.json"
+	dayLayout    = "2006-01-02"
+)
+
+// manifest lists archive files of a day
+type manifest struct {
+	Date  string               `json:"date"`
+	Files []domain.ArchiveFile `json:"files"`
+}
+
+// Archiver drains raw ticks from Redis history into hourly archive files partitioned by date, exchange and symbol.
+// History lives in Redis only for retention period, so ticks are staged on every run and the hour is written
+// once its end is staged.
+type Archiver struct {
+	staging   ports.ArchiveStaging
+	store     ports.ArchiveStore
+	encoder   ports.TickEncoder
+	delay     time.Duration
+	retention time.Duration // Redis history retention, first run starts from it
+	logger    logger.Logger
+}
+
+func NewArchiver(staging ports.ArchiveStaging, store ports.ArchiveStore, encoder ports.TickEncoder, cfg config.Archive, retention time.Duration, logger logger.Logger) *Archiver {
+	return &Archiver{
+		staging:   staging,
+		store:     store,
+		encoder:   encoder,
+		delay:     cfg.Delay,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Run stages new ticks and writes hours that are completely staged. Failure of one exchange and symbol
+// does not stop others, their staged ticks are kept for the next run.
+func (a *Archiver) Run(ctx context.Context) error {
+	const fn = "Archiver.Run"
+	log := a.logger.GetSlogLogger().With("fn", fn)
+
+	now := time.Now()
+	from, upTo := now.Add(-a.retention), now.Add(-a.delay)
+
+	var errs []error
+	archived := 0
+	for _, exchange := range types.ValidExchanges {
+		for _, symbol := range types.ValidSymbols {
+			watermark, err := a.staging.Stage(ctx, exchange, symbol, from, upTo)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			hours, err := a.staging.StagedHours(ctx, exchange, symbol)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			slices.SortFunc(hours, time.Time.Compare)
+
+			for _, hour := range hours {
+				// Hour is complete when all its ticks are staged
+				if hour.Add(time.Hour).After(watermark) {
+					break
+				}
+				if err := a.archiveHour(ctx, exchange, symbol, hour); err != nil {
+					errs = append(errs, fmt.Errorf("failed to archive %s %s at %s: %w", exchange, symbol, hour.Format(time.RFC3339), err))
+					break // hours are archived in order
+				}
+				archived++
+			}
+		}
+	}
+
+	if archived > 0 {
+		log.InfoContext(ctx, "archived hourly tick files", "files", archived)
+	}
+
+	return errors.Join(errs...)
+}
+
+// archiveHour writes staged ticks of the hour, adds file to manifest and deletes staged ticks.
+// Interrupted archiving is repeated by next run, file and manifest entry are replaced.
+func (a *Archiver) archiveHour(ctx context.Context, exchange types.Exchange, symbol types.Symbol, hour time.Time) error {
+	ticks, err := a.staging.StagedTicks(ctx, exchange, symbol, hour)
+	if err != nil {
+		return err
+	}
+	if len(ticks) == 0 {
+		return a.staging.DeleteStaged(ctx, exchange, symbol, hour)
+	}
+
+	var buf bytes.Buffer
+	if err := a.encoder.Encode(&buf, ticks); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+
+	file := domain.ArchiveFile{
+		Path:      archivePath(exchange, symbol, hour, a.encoder.Extension()),
+		Exchange:  exchange,
+		Symbol:    symbol,
+		Hour:      hour,
+		Rows:      len(ticks),
+		Size:      int64(buf.Len()),
+		SHA256:    hex.EncodeToString(sum[:]),
+		FirstTick: ticks[0].Timestamp.UTC(),
+		LastTick:  ticks[len(ticks)-1].Timestamp.UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := a.store.Put(ctx, file.Path, buf.Bytes(), a.encoder.ContentType()); err != nil {
+		return err
+	}
+	if err := a.addToManifest(ctx, file); err != nil {
+		return err
+	}
+
+	return a.staging.DeleteStaged(ctx, exchange, symbol, hour)
+}
+
+// addToManifest adds file to manifest of its day, replacing entry with the same path.
+// Archiver runs on leader only, so manifest has single writer.
+func (a *Archiver) addToManifest(ctx context.Context, file domain.ArchiveFile) error {
+	m, err := a.manifest(ctx, file.Hour)
+	if err != nil {
+		return err
+	}
+
+	m.Files = slices.DeleteFunc(m.Files, func(f domain.ArchiveFile) bool {
+		return f.Path == file.Path
+	})
+	m.Files = append(m.Files, file)
+	slices.SortFunc(m.Files, func(x, y domain.ArchiveFile) int {
+		return cmp.Or(x.Hour.Compare(y.Hour), cmp.Compare(x.Exchange, y.Exchange), cmp.Compare(x.Symbol, y.Symbol))
+	})
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	return a.store.Put(ctx, manifestPath(file.Hour), data, "application/json")
+}
+
+// manifest returns manifest of the day, empty if nothing was archived that day
+func (a *Archiver) manifest(ctx context.Context, day time.Time) (*manifest, error) {
+	m := &manifest{Date: day.UTC().Format(dayLayout)}
+
+	r, err := a.store.Get(ctx, manifestPath(day))
+	if errors.Is(err, domain.ErrNotFound) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest of %s: %w", m.Date, err)
+	}
+	return m, nil
+}
+
+// List returns archive files of days in filter from their manifests
+func (a *Archiver) List(ctx context.Context, filter domain.ArchiveFilter) ([]domain.ArchiveFile, error) {
+	files := make([]domain.ArchiveFile, 0)
+
+	for day := filter.From.UTC().Truncate(24 * time.Hour); !day.After(filter.To); day = day.Add(24 * time.Hour) {
+		m, err := a.manifest(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range m.Files {
+			if len(filter.Exchanges) > 0 && !slices.Contains(filter.Exchanges, f.Exchange) {
+				continue
+			}
+			if len(filter.Symbols) > 0 && !slices.Contains(filter.Symbols, f.Symbol) {
+				continue
+			}
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
+
+// Open returns content of archive file or manifest and its content type
+func (a *Archiver) Open(ctx context.Context, path string) (io.ReadCloser, string, error) {
+	r, err := a.store.Get(ctx, path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if strings.HasSuffix(path, manifestName) {
+		return r, "application/json", nil
+	}
+	return r, a.encoder.ContentType(), nil
+}
+
+// archivePath returns path of hourly file, e.g. date=2025-01-02/exchange=exchange1/symbol=BTCUSDT/15.parquet
+func archivePath(exchange types.Exchange, symbol types.Symbol, hour time.Time, ext string) string {
+	hour = hour.UTC()
+	return fmt.Sprintf("date=%s/exchange=%s/symbol=%s/%02d%s", hour.Format(dayLayout), exchange, symbol, hour.Hour(), ext)
+}
+
+func manifestPath(day time.Time) string {
+	return fmt.Sprintf("date=%s/%s", day.UTC().Format(dayLayout), manifestName)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+// fakeStaging keeps staged ticks of Exchange1 BTCUSDT by hour, other symbols have none
+type fakeStaging struct {
+	watermark time.Time
+	hours     map[time.Time][]*domain.PriceData
+}
+
+func (s *fakeStaging) Stage(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, upTo time.Time) (time.Time, error) {
+	return s.watermark, nil
+}
+
+func (s *fakeStaging) StagedHours(ctx context.Context, exchange types.Exchange, symbol types.Symbol) ([]time.Time, error) {
+	if exchange != types.Exchange1 || symbol != types.BTCUSDT {
+		return nil, nil
+	}
+	return slices.Collect(maps.Keys(s.hours)), nil
+}
+
+func (s *fakeStaging) StagedTicks(ctx context.Context, exchange types.Exchange, symbol types.Symbol, hour time.Time) ([]*domain.PriceData, error) {
+	return s.hours[hour], nil
+}
+
+func (s *fakeStaging) DeleteStaged(ctx context.Context, exchange types.Exchange, symbol types.Symbol, hour time.Time) error {
+	delete(s.hours, hour)
+	return nil
+}
+
+// memoryStore keeps files in map, puts of paths with failing suffix fail
+type memoryStore struct {
+	files   map[string]string
+	failing string
+}
+
+func (s *memoryStore) Put(ctx context.Context, path string, data []byte, contentType string) error {
+	if s.failing != "" && strings.HasSuffix(path, s.failing) {
+		return errors.New("disk full")
+	}
+	s.files[path] = string(data)
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	data, ok := s.files[path]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+// textEncoder writes prices of ticks, one per line
+type textEncoder struct{}
+
+func (textEncoder) Encode(w io.Writer, ticks []*domain.PriceData) error {
+	for _, p := range ticks {
+		fmt.Fprintln(w, p.Price)
+	}
+	return nil
+}
+
+func (textEncoder) Extension() string   { return ".txt" }
+func (textEncoder) ContentType() string { return "text/plain" }
+
+func TestArchiverRun(t *testing.T) {
+	ctx := context.Background()
+	hour := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	tick := func(at time.Duration, price float64) *domain.PriceData {
+		return &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: price, Timestamp: hour.Add(at)}
+	}
+
+	// the second hour is staged only up to its half
+	staging := &fakeStaging{
+		watermark: hour.Add(90 * time.Minute),
+		hours: map[time.Time][]*domain.PriceData{
+			hour:                 {tick(time.Minute, 100), tick(59*time.Minute, 101)},
+			hour.Add(time.Hour):  {tick(80*time.Minute, 102)},
+			hour.Add(-time.Hour): {},
+		},
+	}
+	store := &memoryStore{files: make(map[string]string)}
+	archiver := NewArchiver(staging, store, textEncoder{}, config.Archive{Delay: time.Second}, time.Hour, logger.InitLogger(ctx, "error"))
+
+	if err := archiver.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "date=2025-01-02/exchange=exchange1/symbol=BTCUSDT/15.txt"
+	if store.files[path] != "100\n101\n" {
+		t.Fatalf("archive file %q", store.files[path])
+	}
+	if _, ok := staging.hours[hour]; ok {
+		t.Fatal("archived hour must be deleted from staging")
+	}
+	if _, ok := staging.hours[hour.Add(-time.Hour)]; ok {
+		t.Fatal("empty hour must be deleted from staging")
+	}
+	if _, ok := staging.hours[hour.Add(time.Hour)]; !ok {
+		t.Fatal("incomplete hour must stay staged")
+	}
+
+	files, err := archiver.List(ctx, domain.ArchiveFilter{From: hour, To: hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("listed %d files, want 1", len(files))
+	}
+	f := files[0]
+	if f.Path != path || f.Rows != 2 || f.Size != 8 || len(f.SHA256) != 64 ||
+		!f.FirstTick.Equal(hour.Add(time.Minute)) || !f.LastTick.Equal(hour.Add(59*time.Minute)) {
+		t.Fatalf("unexpected manifest entry %+v", f)
+	}
+
+	// filter matches manifest entries
+	if files, err := archiver.List(ctx, domain.ArchiveFilter{Symbols: []types.Symbol{types.ETHUSDT}, From: hour, To: hour}); err != nil || len(files) != 0 {
+		t.Fatalf("listed %v, %v for other symbol", files, err)
+	}
+
+	for file, contentType := range map[string]string{path: "text/plain", "date=2025-01-02/manifest.json": "application/json"} {
+		r, gotType, err := archiver.Open(ctx, file)
+		if err != nil || gotType != contentType {
+			t.Fatalf("Open(%s) = %s, %v", file, gotType, err)
+		}
+		r.Close()
+	}
+	if _, _, err := archiver.Open(ctx, "date=2025-01-03/manifest.json"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("missing file got %v", err)
+	}
+}
+
+func TestArchiverKeepsStagedTicksOnFailure(t *testing.T) {
+	ctx := context.Background()
+	hour := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	tick := &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: 100, Timestamp: hour}
+
+	staging := &fakeStaging{
+		watermark: hour.Add(3 * time.Hour),
+		hours: map[time.Time][]*domain.PriceData{
+			hour:                {tick},
+			hour.Add(time.Hour): {tick},
+		},
+	}
+	store := &memoryStore{files: make(map[string]string), failing: "15.txt"}
+	archiver := NewArchiver(staging, store, textEncoder{}, config.Archive{Delay: time.Second}, time.Hour, logger.InitLogger(ctx, "error"))
+
+	if err := archiver.Run(ctx); err == nil {
+		t.Fatal("failed write must be reported")
+	}
+	// hours are archived in order, so the next hour waits for the failed one
+	if len(staging.hours) != 2 || len(store.files) != 0 {
+		t.Fatalf("staged hours %d, files %v, nothing must be archived", len(staging.hours), store.files)
+	}
+
+	store.failing = ""
+	if err := archiver.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(staging.hours) != 0 || len(store.files) != 3 {
+		t.Fatalf("staged hours %d, files %d, both hours and manifest must be written", len(staging.hours), len(store.files))
+	}
+}
@@ -3,26 +3,60 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"marketflow/config"
 	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
 	"marketflow/internal/ports"
 	"marketflow/pkg/logger"
+	"marketflow/pkg/metrics"
 )
 
+// flushTimeout limits writing of single batch, batches are written even when collector is being stopped
+const flushTimeout = 2 * time.Second
+
+var (
+	collectorFlushes     = metrics.NewCounterVec("marketflow_collector_flushes_total", "Batches written by collector by trigger: size, interval or shutdown.", "reason")
+	collectorFlushErrors = metrics.NewCounter("marketflow_collector_flush_errors_total", "Batches collector failed to write.")
+	collectorTicks       = metrics.NewCounter("marketflow_collector_ticks_total", "Ticks written by collector.")
+	collectorCoalesced   = metrics.NewCounter("marketflow_collector_coalesced_latest_total", "Latest price writes skipped because a newer tick of the same exchange and symbol was in the batch.")
+	collectorBatchTicks  = metrics.NewHistogram("marketflow_collector_batch_ticks", "Ticks in written batch.", metrics.ExponentialBuckets(1, 4, 8))
+	collectorFlushTime   = metrics.NewHistogram("marketflow_collector_flush_duration_seconds", "Time of writing batch to Redis.", metrics.ExponentialBuckets(0.0005, 2, 12))
+	collectorPending     = metrics.NewGauge("marketflow_collector_pending_ticks", "Ticks collected and not yet written.")
+	collectorBlocked     = metrics.NewCounter("marketflow_collector_backpressure_seconds_total", "Time collector did not read input because pending ticks reached the limit.")
+	collectorSettings    = metrics.NewGaugeVec("marketflow_collector_setting", "Configured batching of collector: batch_size, flush_interval_seconds, max_pending.", "setting")
+)
+
+// Collector stores processed prices to the cache in micro-batches. Batch is written once it has BatchSize ticks
+// or its first tick waited FlushInterval, in single pipeline with latest prices coalesced per key.
+// While batch is written next one is collected, up to MaxPending ticks, then input is not read until the write is done.
 type Collector struct {
 	cache ports.Cache
+	ticks ports.TickRecorder // nil if raw ticks are not persisted
 
 	cancelFunc context.CancelFunc
 	doneChan   chan struct{}
 
+	cfg    config.Collector
 	logger logger.Logger
 }
 
-func NewCollector(cache ports.Cache, logger logger.Logger) *Collector {
+func NewCollector(cache ports.Cache, ticks ports.TickRecorder, cfg config.Collector, logger logger.Logger) *Collector {
+	// Pending ticks always fit a full batch
+	cfg.BatchSize = max(cfg.BatchSize, 1)
+	cfg.MaxPending = max(cfg.MaxPending, cfg.BatchSize)
+
+	collectorSettings.With("batch_size").Set(float64(cfg.BatchSize))
+	collectorSettings.With("flush_interval_seconds").Set(cfg.FlushInterval.Seconds())
+	collectorSettings.With("max_pending").Set(float64(cfg.MaxPending))
+
 	return &Collector{
 		cache:    cache,
+		ticks:    ticks,
 		doneChan: make(chan struct{}),
+		cfg:      cfg,
 		logger:   logger,
 	}
 }
@@ -41,43 +75,167 @@ func (c *Collector) run(ctx context.Context, processedPrices <-chan *domain.Pric
 	const fn = "collector.run"
 	log := c.logger.GetSlogLogger().With("fn", fn)
 
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+	timer := time.NewTimer(c.cfg.FlushInterval)
+	timer.Stop()
+	defer timer.Stop()
+
+	var (
+		pending      = make([]*domain.PriceData, 0, c.cfg.BatchSize)
+		due          bool          // first pending tick waited flush interval
+		flushing     chan struct{} // closed when batch is written, nil if no batch is being written
+		blockedSince time.Time
+		count        int
+	)
+
+	// flush starts writing pending ticks, batches are written one at a time to keep order of latest prices
+	flush := func(reason string) {
+		batch := pending
+		pending = make([]*domain.PriceData, 0, c.cfg.BatchSize)
+		due = false
+		timer.Stop()
+		collectorPending.Set(0)
+
+		done := make(chan struct{})
+		flushing = done
+		go func() {
+			defer close(done)
+
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+			defer cancel()
+
+			collectorFlushes.With(reason).Inc()
+			if err := c.storeBatch(flushCtx, batch); err != nil {
+				log.Error("failed to store prices", "ticks", len(batch), "error", err)
+			}
+		}()
+	}
 
-	var count int
-	// var lastPrice *domain.PriceData
+	// shutdown writes what is collected after batch being written
+	shutdown := func() {
+		if flushing != nil {
+			<-flushing
+		}
+		if len(pending) > 0 {
+			flush("shutdown")
+			<-flushing
+		}
+	}
 
 	for {
+		// Backpressure: pending ticks are at limit, input is read after batch is written
+		input := processedPrices
+		if len(pending) >= c.cfg.MaxPending {
+			input = nil
+			if blockedSince.IsZero() {
+				blockedSince = time.Now()
+			}
+		}
+
 		select {
 		case <-ctx.Done():
-			log.Info("shutting down collector")
+			shutdown()
+			log.Info("shutting down collector", "total_prices", count)
 			return
 
-		case price, ok := <-processedPrices:
+		case price, ok := <-input:
 			if !ok {
+				shutdown()
 				log.Info("collector's input channel closed", "total_prices", count)
 				return
 			}
+			if !blockedSince.IsZero() {
+				collectorBlocked.Add(time.Since(blockedSince).Seconds())
+				blockedSince = time.Time{}
+			}
 
 			count++
-			// lastPrice = price
+			pending = append(pending, price)
+			collectorPending.Set(float64(len(pending)))
+			if len(pending) == 1 {
+				timer.Reset(c.cfg.FlushInterval)
+			}
 
-			if err := c.cache.SetLatest(ctx, price, time.Minute); err != nil {
-				log.Error("cache store failed", "error", err)
+			if len(pending) >= c.cfg.BatchSize && flushing == nil {
+				flush("size")
 			}
 
-			if err := c.cache.StoreHistory(ctx, price); err != nil {
-				log.Error("history store failed", "error", err)
+		case <-timer.C:
+			due = true
+			if flushing == nil {
+				flush("interval")
 			}
 
-		case <-ticker.C:
-			// log.Info("processing status",
-			// 	"total_processed", count,
-			// 	"last_price", lastPrice)
+		case <-flushing:
+			flushing = nil
+
+			// Ticks collected while batch was written
+			switch {
+			case len(pending) >= c.cfg.BatchSize:
+				flush("size")
+			case len(pending) > 0 && due:
+				flush("interval")
+			}
 		}
 	}
 }
 
+// storeBatch saves prices as latest prices and to the history in single pipeline,
+// stored ticks are recorded for audit
+func (c *Collector) storeBatch(ctx context.Context, batch []*domain.PriceData) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	latest := coalesceLatest(batch)
+
+	start := time.Now()
+	err := c.cache.StoreBatch(ctx, latest, batch, time.Minute)
+	collectorFlushTime.Observe(time.Since(start).Seconds())
+	if err != nil {
+		collectorFlushErrors.Inc()
+		return fmt.Errorf("batch store failed: %w", err)
+	}
+
+	collectorBatchTicks.Observe(float64(len(batch)))
+	collectorTicks.Add(float64(len(batch)))
+	collectorCoalesced.Add(float64(len(batch) - len(latest)))
+
+	if c.ticks != nil {
+		for _, price := range batch {
+			c.ticks.Record(price)
+		}
+	}
+
+	return nil
+}
+
+// coalesceLatest returns the last price of every exchange and symbol, in order of arrival
+func coalesceLatest(batch []*domain.PriceData) []*domain.PriceData {
+	type key struct {
+		exchange types.Exchange
+		symbol   types.Symbol
+	}
+
+	last := make(map[key]int, len(batch))
+	for i, price := range batch {
+		last[key{price.Exchange, price.Symbol}] = i
+	}
+
+	latest := make([]*domain.PriceData, 0, len(last))
+	for i, price := range batch {
+		if last[key{price.Exchange, price.Symbol}] == i {
+			latest = append(latest, price)
+		}
+	}
+
+	return latest
+}
+
+// Done is closed when collector has stopped
+func (c *Collector) Done() <-chan struct{} {
+	return c.doneChan
+}
+
 // Cancel gracefully shutdowns collector
 func (c *Collector) Cancel() error {
 	if c.cancelFunc != nil {
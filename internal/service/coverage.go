@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// Coverage finds minutes without live data of exchange and symbol. Minute is covered if aggregated stats reference
+// its ticks or Redis history has its ticks, so recent minutes are covered before they are aggregated.
+// Gaps are explained by pipeline events from the last covered minute to the end of the gap.
+type Coverage struct {
+	repo    ports.CoverageRepository
+	history ports.Cache
+	events  ports.PipelineEvents
+
+	cfg    config.Coverage
+	logger logger.Logger
+}
+
+func NewCoverage(repo ports.CoverageRepository, history ports.Cache, events ports.PipelineEvents, cfg config.Coverage, logger logger.Logger) *Coverage {
+	return &Coverage{
+		repo:    repo,
+		history: history,
+		events:  events,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+// Report returns coverage of minutes in [from, to), the current minute is not complete and is never reported
+func (c *Coverage) Report(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time) (*domain.Coverage, error) {
+	now := time.Now().UTC()
+	if to.After(now) {
+		to = now
+	}
+	from, to = from.UTC().Truncate(time.Minute), to.UTC().Truncate(time.Minute)
+
+	report := &domain.Coverage{
+		Exchange: exchange,
+		Symbol:   symbol,
+		From:     from,
+		To:       to,
+		Gaps:     []domain.Gap{},
+	}
+	if !from.Before(to) {
+		return report, nil
+	}
+
+	covered, err := c.coveredMinutes(ctx, exchange, symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := c.events.GetEvents(ctx, from.Add(-time.Minute), to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline events: %w", err)
+	}
+
+	var gap *domain.Gap
+	for minute := from; minute.Before(to); minute = minute.Add(time.Minute) {
+		report.Minutes++
+		if _, ok := covered[minute]; ok {
+			report.CoveredMinutes++
+			if gap != nil {
+				report.Gaps = append(report.Gaps, c.explain(*gap, exchange, events))
+				gap = nil
+			}
+			continue
+		}
+
+		if gap == nil {
+			gap = &domain.Gap{From: minute}
+		}
+		gap.To = minute.Add(time.Minute)
+		gap.Minutes++
+	}
+	if gap != nil {
+		report.Gaps = append(report.Gaps, c.explain(*gap, exchange, events))
+	}
+
+	report.Percent = float64(report.CoveredMinutes) / float64(report.Minutes) * 100
+	return report, nil
+}
+
+// coveredMinutes returns minutes with aggregated stats or live ticks in Redis history
+func (c *Coverage) coveredMinutes(ctx context.Context, exchange types.Exchange, symbol types.Symbol, from, to time.Time) (map[time.Time]struct{}, error) {
+	minutes, err := c.repo.CoveredMinutes(ctx, exchange, symbol, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aggregated minutes: %w", err)
+	}
+
+	covered := make(map[time.Time]struct{}, len(minutes))
+	for _, minute := range minutes {
+		covered[minute.UTC()] = struct{}{}
+	}
+
+	err = c.history.IterateHistory(ctx, exchange, symbol, from, to, func(p *domain.PriceData) error {
+		if p.Source == "" || p.Source == types.SourceLive {
+			covered[p.Timestamp.UTC().Truncate(time.Minute)] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	return covered, nil
+}
+
+// explain sets cause of the first event of the exchange or of all exchanges from the minute before gap to its end
+func (c *Coverage) explain(gap domain.Gap, exchange types.Exchange, events []domain.PipelineEvent) domain.Gap {
+	gap.Cause = types.CauseUnknown
+	for _, event := range events {
+		if event.Exchange != "" && event.Exchange != exchange {
+			continue
+		}
+		if event.At.Before(gap.From.Add(-time.Minute)) || !event.At.Before(gap.To) {
+			continue
+		}
+
+		gap.Cause, gap.Detail = event.Cause, event.Detail
+		break
+	}
+	return gap
+}
+
+// Scan detects gaps of all exchanges and symbols in the scan window ending settle period ago and records them.
+// Failure of one exchange and symbol does not stop others.
+func (c *Coverage) Scan(ctx context.Context) error {
+	const fn = "Coverage.Scan"
+	log := c.logger.GetSlogLogger().With("fn", fn)
+
+	to := time.Now().UTC().Add(-c.cfg.Settle).Truncate(time.Minute)
+	from := to.Add(-c.cfg.ScanWindow)
+
+	var errs []error
+	gaps := 0
+	for _, exchange := range types.ValidExchanges {
+		for _, symbol := range types.ValidSymbols {
+			report, err := c.Report(ctx, exchange, symbol, from, to)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", exchange, symbol, err))
+				continue
+			}
+
+			if err := c.repo.ReplaceGaps(ctx, exchange, symbol, report.From, report.To, report.Gaps); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", exchange, symbol, err))
+				continue
+			}
+			gaps += len(report.Gaps)
+		}
+	}
+
+	if gaps > 0 {
+		log.WarnContext(ctx, "data gaps detected", "gaps", gaps, "from", from, "to", to)
+	}
+
+	return errors.Join(errs...)
+}
+
+// eventRecorder records pipeline events without failing the operation they describe
+type eventRecorder struct {
+	events ports.PipelineEvents
+	logger logger.Logger
+}
+
+func newEventRecorder(events ports.PipelineEvents, logger logger.Logger) *eventRecorder {
+	return &eventRecorder{
+		events: events,
+		logger: logger,
+	}
+}
+
+// Record records event happened now, exchange is empty for events affecting all exchanges.
+// Nothing is recorded if there is no events storage.
+func (r *eventRecorder) Record(exchange types.Exchange, cause types.GapCause, detail string) {
+	if r.events == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	event := domain.PipelineEvent{At: time.Now(), Exchange: exchange, Cause: cause, Detail: detail}
+	if err := r.events.RecordEvent(ctx, event); err != nil {
+		r.logger.Warn(ctx, "failed to record pipeline event", "exchange", exchange, "cause", cause, "error", err)
+	}
+}
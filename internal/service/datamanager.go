@@ -3,6 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"marketflow/config"
 	"marketflow/internal/adapter/exchange"
@@ -12,81 +16,265 @@ import (
 	"marketflow/pkg/logger"
 )
 
-// ExchangeManager manages all working process related to exchanges
+// ExchangeManager manages all working process related to exchanges.
+// Each exchange has its own pipeline, so exchanges can be switched between modes,
+// paused, restarted and reconfigured independently.
 type ExchangeManager struct {
-	exchangeSources []ports.ExchangeSource
-	distributors    []ports.Distributor
-	workerPools     []ports.WorkerPool
-	aggregator      ports.Aggregator
-	collector       ports.Collector
+	// mu serializes Start, Close, mode switches and source management
+	mu sync.Mutex
+
+	initialSources []ports.ExchangeSource
+	initialMode    types.Source
+	liveSources    *exchange.LiveSources // creates live sources with transport and decoder of exchange
+	collector      ports.Collector
+
+	// out is a long-lived buffer read by collector, pipelines forward their data into it,
+	// so sources can be replaced without restarting the collector
+	out    *stageQueue
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	cache  ports.Cache
+	stream ports.TickStream
+	ticks  ports.TickRecorder // nil if raw ticks are not persisted
+	feed   *FeedMonitor
+	events *eventRecorder
+
+	// stateMu guards fields below, pipelines are changed holding both mu and stateMu
+	stateMu    sync.RWMutex
+	state      types.PipelineState
+	pipelines  map[types.Exchange]*pipeline // running pipelines, paused exchanges have none
+	modes      map[types.Exchange]types.Source
+	addrs      map[types.Exchange]string
+	lastErrors map[types.Exchange]string
+	failed     map[types.Exchange]bool // exchanges without pipeline whose last start failed
+
+	cfg          config.DataManager
+	freshnessCfg config.Freshness
+	logger       logger.Logger
+}
 
-	store ports.MarketRepository
-	cache ports.Cache
+// pipeline is a source with its worker pool, forwarding processed data to manager
+type pipeline struct {
+	source     ports.ExchangeSource
+	workerPool ports.WorkerPool
 
-	isLive bool
+	connectedAt time.Time
+	ticks       atomic.Int64
+	lastTick    atomic.Int64 // unix nanoseconds
+	symbolTicks sync.Map     // types.Symbol -> *atomic.Int64, unix nanoseconds of the last tick
+	draining    atomic.Bool  // source is being closed by manager
+	closed      atomic.Bool  // feed was closed by source itself
 
-	cfg    config.DataManager
-	logger logger.Logger
+	cancel context.CancelFunc
+	done   chan struct{} // closed when all data of the pipeline is forwarded
 }
 
+// NewExchangeManager creates manager running given sources, mode is the kind of the sources
 func NewExchangeManager(
-	islive bool,
+	mode types.Source,
 	exchanges []ports.ExchangeSource,
-	store ports.MarketRepository,
+	liveSources *exchange.LiveSources,
 	cache ports.Cache,
+	stream ports.TickStream,
+	ticks ports.TickRecorder,
+	events ports.PipelineEvents,
 
 	cfg config.DataManager,
+	freshnessCfg config.Freshness,
 	logger logger.Logger,
 ) *ExchangeManager {
 	return &ExchangeManager{
-		exchangeSources: exchanges,
-		store:           store,
-		cache:           cache,
-
-		isLive: islive,
-		cfg:    cfg,
-		logger: logger,
+		initialSources: exchanges,
+		initialMode:    mode,
+		liveSources:    liveSources,
+		cache:          cache,
+		stream:         stream,
+		ticks:          ticks,
+		feed:           NewFeedMonitor(cfg.Feed, logger),
+		events:         newEventRecorder(events, logger),
+
+		state:     types.StateStopped,
+		pipelines: make(map[types.Exchange]*pipeline),
+		modes:     make(map[types.Exchange]types.Source),
+		addrs: map[types.Exchange]string{
+			types.Exchange1: cfg.Exchanges.Exchange1Addr,
+			types.Exchange2: cfg.Exchanges.Exchange2Addr,
+			types.Exchange3: cfg.Exchanges.Exchange3Addr,
+		},
+		lastErrors:   make(map[types.Exchange]string),
+		failed:       make(map[types.Exchange]bool),
+		cfg:          cfg,
+		freshnessCfg: freshnessCfg,
+		logger:       logger,
 	}
 }
 
+// Start starts exchange sources, worker pools and collector
 func (m *ExchangeManager) Start(ctx context.Context) error {
-	m.initCollectorAndAggregator()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setState(types.StateStarting)
 
-	for _, source := range m.exchangeSources {
-		m.logger.Info(ctx, "starting source", "name", source.Name())
-		pricesCh, err := source.Start(ctx)
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	bp := m.cfg.Backpressure
+	m.out = newStageQueue(stageCollector, "", types.BackpressurePolicy(bp.CollectorPolicy), bp.CollectorSize, bp.Watermark, m.logger)
+	m.out.Start(m.ctx)
+
+	for _, source := range m.initialSources {
+		name := types.Exchange(source.Name())
+		p, err := m.startPipeline(name, source)
 		if err != nil {
-			return fmt.Errorf("failed to start source: %w", err)
+			for exchange, started := range m.pipelines {
+				started.source.Close()
+				started.cancel()
+				m.detach(exchange)
+			}
+			m.cancel()
+			m.setState(types.StateStopped)
+			return err
 		}
+		m.attach(name, p, m.initialMode)
+	}
 
-		workerPool := NewWorkerPool(source.Name(), m.cfg.Distributor.WorkerCount, m.logger)
-		m.workerPools = append(m.workerPools, workerPool)
+	// starting collector
+	m.initCollector()
+	m.collector.Start(m.ctx, m.out.Out())
+
+	m.setState(types.StateRunning)
+	return nil
+}
 
-		distributor := NewDistriubtor(workerPool, pricesCh)
-		m.distributors = append(m.distributors, distributor)
+// startPipeline starts given source with its worker pool
+func (m *ExchangeManager) startPipeline(name types.Exchange, source ports.ExchangeSource) (*pipeline, error) {
+	const fn = "ExchangeManager.startPipeline"
+	log := m.logger.GetSlogLogger().With("fn", fn, "name", name)
+
+	ctx, cancel := context.WithCancel(m.ctx)
+
+	log.Info("starting source")
+	pricesCh, err := source.Start(ctx)
+	if err != nil {
+		cancel()
+		err = fmt.Errorf("failed to start source %s: %w", name, err)
+		m.setFailed(name, err.Error())
+		m.events.Record(name, types.CauseDisconnect, err.Error())
+		return nil, err
+	}
 
-		workerPool.Start(ctx)
-		distributor.FanOut(ctx)
+	p := &pipeline{
+		source:      source,
+		connectedAt: time.Now(),
+		cancel:      cancel,
+		done:        make(chan struct{}),
 	}
 
-	merged := m.aggregator.FanIn(ctx, m.getWorkerPoolOutputs()...)
+	// counting ticks received from the source and measuring their latency
+	tracked := make(chan *domain.PriceData)
+	go func() {
+		defer close(tracked)
+		for data := range pricesCh {
+			p.ticks.Add(1)
+			// timestamp is rebased by feed monitor first, so freshness sees the time stored with the tick
+			m.feed.Observe(data)
+			p.touch(data)
+
+			select {
+			case tracked <- data:
+			case <-ctx.Done():
+				return
+			}
+		}
 
-	// starting collector
-	m.collector.Start(ctx, merged)
+		if !p.draining.Load() && ctx.Err() == nil {
+			p.closed.Store(true)
+			m.setLastError(name, "feed closed by exchange")
+			m.events.Record(name, types.CauseDisconnect, "feed closed by exchange")
+			log.Warn("exchange feed closed")
+		}
+	}()
+
+	workerPool := NewWorkerPool(source.Name(), m.cfg.Distributor.WorkerCount, m.cfg.Backpressure, m.logger)
+	distributor := NewDistriubtor(workerPool, tracked)
+	p.workerPool = workerPool
+
+	workerPool.Start(ctx)
+	distributor.FanOut(ctx)
+
+	// forwarding to collector until pipeline is drained
+	go func() {
+		defer close(p.done)
+		for data := range workerPool.Output() {
+			if !m.out.Send(m.ctx, data) {
+				return
+			}
+		}
+	}()
 
-	// starting aggregator
-	m.aggregator.Start(ctx)
+	return p, nil
+}
 
-	return nil
+// drainPipeline closes source and waits until data already received is processed.
+// Pipeline is cancelled if it does not drain within timeout. Returns whether pipeline drained.
+func (m *ExchangeManager) drainPipeline(p *pipeline) bool {
+	const fn = "ExchangeManager.drainPipeline"
+	log := m.logger.GetSlogLogger().With("fn", fn, "name", p.source.Name())
+
+	p.draining.Store(true)
+	if err := p.source.Close(); err != nil {
+		log.Warn("failed to close exchange", "error", err)
+	}
+
+	drained := true
+	select {
+	case <-p.done:
+		log.Info("pipeline drained")
+	case <-time.After(m.cfg.DrainTimeout):
+		log.Warn("pipeline drain timed out, dropping in-flight data", "timeout", m.cfg.DrainTimeout)
+		drained = false
+	}
+
+	p.cancel()
+	return drained
 }
 
 func (m *ExchangeManager) Close() error {
 	const fn = "ExchangeManager.Close"
 	log := m.logger.GetSlogLogger().With("fn", fn)
 
-	for _, source := range m.exchangeSources {
-		if err := source.Close(); err != nil {
-			log.Warn("failed to close exchange", "error", err)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.State() == types.StateStopped {
+		return nil
+	}
+
+	m.setState(types.StateDraining)
+	m.events.Record("", types.CauseShutdown, "exchange manager stopped")
+	var wg sync.WaitGroup
+	var stuck atomic.Bool
+	for exchange, p := range m.pipelines {
+		m.detach(exchange)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !m.drainPipeline(p) {
+				stuck.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Collector stores ticks left in its buffer and stops once the buffer is closed.
+	// Pipeline which did not drain may still send to the buffer, so it is not closed then.
+	if !stuck.Load() {
+		m.out.Close()
+		select {
+		case <-m.collector.Done():
+		case <-time.After(m.cfg.DrainTimeout):
+			log.Warn("collector did not store buffered data in time, dropping it", "timeout", m.cfg.DrainTimeout)
 		}
 	}
 
@@ -94,68 +282,409 @@ func (m *ExchangeManager) Close() error {
 		log.Warn("failed to cancel collector", "error", err)
 	}
 
-	for _, pool := range m.workerPools {
-		pool.Close()
+	m.cancel()
+	m.setState(types.StateStopped)
+
+	return nil
+}
+
+// SwitchToTest switches all exchanges to synthetic data
+func (m *ExchangeManager) SwitchToTest() (*domain.ModeStatus, error) {
+	return m.switchAll(types.SourceTest, domain.ErrAlreadyOnTestMode)
+}
+
+// SwitchToLive switches all exchanges to live data
+func (m *ExchangeManager) SwitchToLive() (*domain.ModeStatus, error) {
+	return m.switchAll(types.SourceLive, domain.ErrAlreadyOnLiveMode)
+}
+
+// switchAll switches every exchange not yet in the mode. Returns errAlready if all exchanges are in the mode.
+func (m *ExchangeManager) switchAll(mode types.Source, errAlready error) (*domain.ModeStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.State() == types.StateStopped {
+		return nil, domain.ErrManagerStopped
+	}
+
+	switched := 0
+	for _, exchange := range m.exchanges() {
+		if m.ExchangeMode(exchange) == mode {
+			continue
+		}
+		if err := m.switchExchange(exchange, mode); err != nil {
+			return nil, err
+		}
+		switched++
+	}
+
+	if switched == 0 {
+		return nil, errAlready
+	}
+
+	return m.status(), nil
+}
+
+// SwitchExchange switches single exchange to the mode
+func (m *ExchangeManager) SwitchExchange(exchange types.Exchange, mode types.Source) (*domain.ModeStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExchange(exchange); err != nil {
+		return nil, err
+	}
+	if !types.IsValidSource(string(mode)) {
+		return nil, domain.ErrInvalidMode
+	}
+	if m.ExchangeMode(exchange) == mode {
+		return nil, fmt.Errorf("%w: %s is on %s mode", domain.ErrAlreadyOnMode, exchange, mode)
+	}
+
+	if err := m.switchExchange(exchange, mode); err != nil {
+		return nil, err
+	}
+
+	return m.status(), nil
+}
+
+// switchExchange replaces source of the exchange with the source of the mode.
+// Paused exchange only remembers the mode, it is used on resume. Must be called with mu held.
+func (m *ExchangeManager) switchExchange(exchange types.Exchange, mode types.Source) error {
+	if _, running := m.pipelines[exchange]; !running {
+		m.setMode(exchange, mode)
+		return nil
+	}
+
+	from := m.ExchangeMode(exchange)
+	if err := m.replaceSource(exchange, mode); err != nil {
+		return err
 	}
+	m.events.Record(exchange, types.CauseModeSwitch, fmt.Sprintf("%s to %s", from, mode))
 
+	m.logger.Info(m.ctx, "switched exchange mode", "exchange", exchange, "mode", mode)
 	return nil
 }
 
-func (m *ExchangeManager) SwitchToTest() error {
-	if !m.isLive {
-		return domain.ErrAlreadyOnTestMode
+// replaceSource replaces source of the exchange make-before-break: new source is started first,
+// old one is drained afterwards. If new source fails to start, old one keeps running.
+// Must be called with mu held.
+func (m *ExchangeManager) replaceSource(exchange types.Exchange, mode types.Source) error {
+	m.setState(types.StateStarting)
+	p, err := m.startPipeline(exchange, m.newSource(exchange, mode))
+	if err != nil {
+		m.setState(types.StateRunning)
+		return err
 	}
-	m.Close()
 
-	testSources := []ports.ExchangeSource{
-		exchange.NewTestExchange(types.Exchange1),
-		exchange.NewTestExchange(types.Exchange2),
-		exchange.NewTestExchange(types.Exchange3),
+	old := m.pipelines[exchange]
+	m.attach(exchange, p, mode)
+
+	if old != nil {
+		m.setState(types.StateDraining)
+		m.drainPipeline(old)
+	}
+	m.setState(types.StateRunning)
+
+	return nil
+}
+
+// Pause detaches source of the exchange, data already received is processed
+func (m *ExchangeManager) Pause(exchange types.Exchange) (*domain.SourceInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExchange(exchange); err != nil {
+		return nil, err
+	}
+	p, running := m.pipelines[exchange]
+	if !running {
+		return nil, domain.ErrSourcePaused
 	}
-	// switching to test sources
-	m.exchangeSources = testSources
 
-	m.isLive = false
+	m.detach(exchange)
+	m.drainPipeline(p)
+	m.events.Record(exchange, types.CausePaused, "")
 
-	return m.Start(context.Background())
+	m.logger.Info(m.ctx, "paused exchange source", "exchange", exchange)
+	return m.sourceInfo(exchange), nil
 }
 
-func (m *ExchangeManager) SwitchToLive() error {
-	if m.isLive {
-		return domain.ErrAlreadyOnLiveMode
+// Resume starts source of paused exchange in its current mode
+func (m *ExchangeManager) Resume(exchange types.Exchange) (*domain.SourceInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExchange(exchange); err != nil {
+		return nil, err
+	}
+	if _, running := m.pipelines[exchange]; running {
+		return nil, domain.ErrSourceNotPaused
 	}
-	m.Close()
 
-	liveSources := []ports.ExchangeSource{
-		exchange.NewExchange(types.Exchange1, m.cfg.Exchanges.Exchange1Addr, m.logger),
-		exchange.NewExchange(types.Exchange2, m.cfg.Exchanges.Exchange2Addr, m.logger),
-		exchange.NewExchange(types.Exchange3, m.cfg.Exchanges.Exchange3Addr, m.logger),
+	if err := m.replaceSource(exchange, m.ExchangeMode(exchange)); err != nil {
+		return nil, err
 	}
-	// switching to live sources
-	m.exchangeSources = liveSources
 
-	m.isLive = true
+	m.logger.Info(m.ctx, "resumed exchange source", "exchange", exchange)
+	return m.sourceInfo(exchange), nil
+}
 
-	return m.Start(context.Background())
+// Restart reconnects source of the exchange, paused exchange is resumed
+func (m *ExchangeManager) Restart(exchange types.Exchange) (*domain.SourceInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExchange(exchange); err != nil {
+		return nil, err
+	}
+
+	if err := m.replaceSource(exchange, m.ExchangeMode(exchange)); err != nil {
+		return nil, err
+	}
+	m.events.Record(exchange, types.CauseRestart, "")
+
+	m.logger.Info(m.ctx, "restarted exchange source", "exchange", exchange)
+	return m.sourceInfo(exchange), nil
 }
 
+// Reconfigure changes address of live exchange. Running live source is reconnected to the new address,
+// address is reverted if it fails.
+func (m *ExchangeManager) Reconfigure(exchange types.Exchange, addr string) (*domain.SourceInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExchange(exchange); err != nil {
+		return nil, err
+	}
+	if err := m.liveSources.ValidateAddr(exchange, addr); err != nil {
+		return nil, err
+	}
+
+	old := m.exchangeAddr(exchange)
+	m.setAddr(exchange, addr)
+
+	_, running := m.pipelines[exchange]
+	if running && m.ExchangeMode(exchange) == types.SourceLive {
+		if err := m.replaceSource(exchange, types.SourceLive); err != nil {
+			m.setAddr(exchange, old)
+			return nil, err
+		}
+		m.events.Record(exchange, types.CauseRestart, "reconnected to "+addr)
+	}
+
+	m.logger.Info(m.ctx, "reconfigured exchange source", "exchange", exchange, "address", addr)
+	return m.sourceInfo(exchange), nil
+}
+
+// checkExchange checks that manager is running and manages the exchange
+func (m *ExchangeManager) checkExchange(exchange types.Exchange) error {
+	if m.State() == types.StateStopped {
+		return domain.ErrManagerStopped
+	}
+	if m.ExchangeMode(exchange) == "" {
+		return domain.ErrInvalidExchange
+	}
+	return nil
+}
+
+// exchanges returns managed exchanges in stable order
+func (m *ExchangeManager) exchanges() []types.Exchange {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+
+	var exchanges []types.Exchange
+	for _, exchange := range types.ValidExchanges {
+		if _, ok := m.modes[exchange]; ok {
+			exchanges = append(exchanges, exchange)
+		}
+	}
+	return exchanges
+}
+
+// attach makes pipeline the running pipeline of the exchange
+func (m *ExchangeManager) attach(exchange types.Exchange, p *pipeline, mode types.Source) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.pipelines[exchange] = p
+	m.modes[exchange] = mode
+	delete(m.lastErrors, exchange)
+	delete(m.failed, exchange)
+}
+
+func (m *ExchangeManager) detach(exchange types.Exchange) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	delete(m.pipelines, exchange)
+}
+
+// Sources returns state of all exchange sources
+func (m *ExchangeManager) Sources() []domain.SourceInfo {
+	var sources []domain.SourceInfo
+	for _, exchange := range m.exchanges() {
+		sources = append(sources, *m.sourceInfo(exchange))
+	}
+	return sources
+}
+
+// Source returns state of exchange source
+func (m *ExchangeManager) Source(exchange types.Exchange) (*domain.SourceInfo, error) {
+	if m.ExchangeMode(exchange) == "" {
+		return nil, domain.ErrInvalidExchange
+	}
+	return m.sourceInfo(exchange), nil
+}
+
+func (m *ExchangeManager) sourceInfo(exchange types.Exchange) *domain.SourceInfo {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+
+	info := &domain.SourceInfo{
+		Exchange:  exchange,
+		Mode:      m.modes[exchange],
+		State:     types.SourcePaused,
+		LastError: m.lastErrors[exchange],
+	}
+	if info.Mode == types.SourceLive {
+		info.Address = m.addrs[exchange]
+	}
+
+	p, running := m.pipelines[exchange]
+	if !running {
+		if m.failed[exchange] {
+			info.State = types.SourceFailed
+		}
+		return info
+	}
+
+	info.State = types.SourceRunning
+	if p.closed.Load() {
+		info.State = types.SourceDisconnected
+	}
+	info.ConnectedAt = p.connectedAt
+	info.TicksReceived = p.ticks.Load()
+	if lastTick := p.lastTick.Load(); lastTick != 0 {
+		info.LastTickAt = time.Unix(0, lastTick)
+		info.LastTickAge = time.Since(info.LastTickAt).Round(time.Millisecond).String()
+	}
+
+	return info
+}
+
+func (m *ExchangeManager) setLastError(exchange types.Exchange, err string) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.lastErrors[exchange] = err
+}
+
+// setFailed records error of source that failed to start, exchange without running pipeline is reported failed
+func (m *ExchangeManager) setFailed(exchange types.Exchange, err string) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.lastErrors[exchange] = err
+	if _, running := m.pipelines[exchange]; !running {
+		m.failed[exchange] = true
+	}
+}
+
+func (m *ExchangeManager) setAddr(exchange types.Exchange, addr string) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.addrs[exchange] = addr
+}
+
+// newSource creates source of the exchange for the mode
+func (m *ExchangeManager) newSource(name types.Exchange, mode types.Source) ports.ExchangeSource {
+	switch mode {
+	case types.SourceTest:
+		return exchange.NewTestExchange(name)
+	case types.SourceReplay:
+		return exchange.NewReplayExchange(name, m.cache, m.cfg.ReplayWindow, m.logger)
+	default:
+		return m.liveSources.New(name, m.exchangeAddr(name))
+	}
+}
+
+func (m *ExchangeManager) exchangeAddr(name types.Exchange) string {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.addrs[name]
+}
+
+// Mode returns global data mode, Hybrid if exchanges are in different modes
 func (m *ExchangeManager) Mode() string {
-	if m.isLive {
-		return types.LiveMode
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+
+	var mode types.Source
+	for _, exchangeMode := range m.modes {
+		if mode != "" && mode != exchangeMode {
+			return types.HybridMode
+		}
+		mode = exchangeMode
+	}
+
+	if mode == "" {
+		return m.initialMode.Mode()
 	}
+	return mode.Mode()
+}
+
+// ExchangeModes returns mode of every running exchange
+func (m *ExchangeManager) ExchangeModes() map[types.Exchange]types.Source {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return maps.Clone(m.modes)
+}
+
+// ExchangeMode returns mode of the exchange
+func (m *ExchangeManager) ExchangeMode(exchange types.Exchange) types.Source {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.modes[exchange]
+}
+
+func (m *ExchangeManager) setMode(exchange types.Exchange, mode types.Source) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.modes[exchange] = mode
+}
+
+// Status returns data mode of manager and all exchanges
+func (m *ExchangeManager) Status() *domain.ModeStatus {
+	return m.status()
+}
+
+func (m *ExchangeManager) status() *domain.ModeStatus {
+	return &domain.ModeStatus{
+		Mode:      m.Mode(),
+		State:     m.State(),
+		Exchanges: m.ExchangeModes(),
+	}
+}
+
+// State returns state of data pipeline
+func (m *ExchangeManager) State() types.PipelineState {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.state
+}
 
-	return types.TestMode
+// FeedLatency returns latency and clock skew of live exchanges
+func (m *ExchangeManager) FeedLatency() []domain.FeedLatency {
+	return m.feed.Stats()
 }
 
-func (m *ExchangeManager) initCollectorAndAggregator() {
-	m.collector = NewCollector(m.cache, m.logger)
-	m.aggregator = NewAggregator(m.store, m.cache, m.cfg.Aggregator, m.logger)
+func (m *ExchangeManager) setState(state types.PipelineState) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.state = state
 }
 
-func (m *ExchangeManager) getWorkerPoolOutputs() []<-chan *domain.PriceData {
-	var chans []<-chan *domain.PriceData
-	for _, pool := range m.workerPools {
-		chans = append(chans, pool.Output())
+func (m *ExchangeManager) initCollector() {
+	if m.cfg.Stream.Enabled {
+		// Prices go through durable streams, StreamConsumer stores them
+		m.collector = NewStreamPublisher(m.stream, m.logger)
+		return
 	}
-	return chans
+	m.collector = NewCollector(m.cache, m.ticks, m.cfg.Collector, m.logger)
 }
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/adapter/exchange"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/envcfg"
+	"marketflow/pkg/logger"
+)
+
+// idleSource starts and sends nothing until closed
+type idleSource struct {
+	name types.Exchange
+	once sync.Once
+	ch   chan *domain.PriceData
+}
+
+func (s *idleSource) Name() string { return string(s.name) }
+
+func (s *idleSource) Start(ctx context.Context) (<-chan *domain.PriceData, error) {
+	s.ch = make(chan *domain.PriceData)
+	return s.ch, nil
+}
+
+func (s *idleSource) Close() error {
+	s.once.Do(func() { close(s.ch) })
+	return nil
+}
+
+// burstSource sends buffered ticks, they can still be read after it is closed
+type burstSource struct {
+	idleSource
+	ticks int
+}
+
+func (s *burstSource) Start(ctx context.Context) (<-chan *domain.PriceData, error) {
+	s.ch = make(chan *domain.PriceData, s.ticks)
+	for i := range s.ticks {
+		s.ch <- &domain.PriceData{Exchange: s.name, Symbol: types.BTCUSDT, Price: float64(i + 1), Timestamp: time.Now()}
+	}
+	return s.ch, nil
+}
+
+// closedAddr returns address nothing listens on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestSourceFailedToStart(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+
+	var cfg config.DataManager
+	if err := envcfg.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Exchanges.Exchange1Addr = closedAddr(t)
+	live, err := exchange.NewLiveSources(cfg.Exchanges, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewExchangeManager(types.SourceLive, []ports.ExchangeSource{&idleSource{name: types.Exchange1}}, live, nil, nil, nil, nil, cfg, config.Freshness{}, log)
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if _, err := m.Pause(types.Exchange1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Resume(types.Exchange1); err == nil {
+		t.Fatal("resume must fail, nothing listens on the address")
+	}
+	info, err := m.Source(types.Exchange1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.State != types.SourceFailed || info.LastError == "" {
+		t.Fatalf("source that failed to start must be reported failed with error, got %+v", info)
+	}
+
+	// source started later is running again
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	if _, err := m.Reconfigure(types.Exchange1, listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = m.Resume(types.Exchange1); err != nil {
+		t.Fatal(err)
+	}
+	if info.State != types.SourceRunning || info.LastError != "" {
+		t.Fatalf("resumed source must be running without error, got %+v", info)
+	}
+}
+
+func TestCloseStoresBufferedTicks(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+
+	var cfg config.DataManager
+	if err := envcfg.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	// slow collector leaves most ticks in its buffer when manager is closed
+	cfg.Collector.BatchSize, cfg.Collector.MaxPending = 10, 10
+	cache := &storeCache{delay: time.Millisecond}
+
+	const ticks = 1000
+	source := &burstSource{idleSource: idleSource{name: types.Exchange1}, ticks: ticks}
+	m := NewExchangeManager(types.SourceTest, []ports.ExchangeSource{source}, nil, cache, nil, nil, nil, cfg, config.Freshness{}, log)
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.stored != ticks {
+		t.Fatalf("stored %d of %d ticks, ticks drained on close must be stored", cache.stored, ticks)
+	}
+}
@@ -7,7 +7,8 @@ import (
 )
 
 type workerPool interface {
-	Input() chan<- *domain.PriceData
+	Send(ctx context.Context, data *domain.PriceData) bool
+	Close()
 }
 
 // Distributor
@@ -23,9 +24,12 @@ func NewDistriubtor(workerPool *WorkerPool, in <-chan *domain.PriceData) *Distri
 	}
 }
 
-// FanOut reads data from incoming channel. And sends to pool of workers
+// FanOut reads data from incoming channel. And sends to pool of workers.
+// Worker pool input is closed when incoming channel is closed, so workers drain buffered data and stop.
 func (d *Distributor) FanOut(ctx context.Context) {
 	go func() {
+		defer d.workerPool.Close()
+
 		for {
 			select {
 			case <-ctx.Done():
@@ -34,10 +38,8 @@ func (d *Distributor) FanOut(ctx context.Context) {
 				if !ok {
 					return // input channel closed
 				}
-				select {
-				case d.workerPool.Input() <- data:
-					// sent successfully
-				case <-ctx.Done():
+				// Policy of worker pool input decides if full pool stalls the exchange
+				if !d.workerPool.Send(ctx, data) {
 					return
 				}
 			}
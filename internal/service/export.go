@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// exportFlushRows is a number of rows after which written data is flushed to the client
+const exportFlushRows = 500
+
+var (
+	tickColumns  = []string{"exchange", "symbol", "timestamp", "price", "source"}
+	statsColumns = []string{"exchange", "symbol", "timestamp", "min", "max", "average", "source"}
+)
+
+// Export writes historical data as CSV or NDJSON
+type Export struct {
+	cache   ports.Cache
+	storage ports.MarketRepository
+	logger  logger.Logger
+}
+
+func NewExport(cache ports.Cache, storage ports.MarketRepository, logger logger.Logger) *Export {
+	return &Export{
+		cache:   cache,
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+// Ticks writes ticks from Redis history, grouped by exchange and symbol and ordered by timestamp inside a group.
+// Empty exchanges or symbols in filter match all of them. Returns number of written rows.
+func (s *Export) Ticks(ctx context.Context, filter domain.ExportFilter, format types.ExportFormat, w io.Writer) (int, error) {
+	const fn = "Export.Ticks"
+	log := s.logger.GetSlogLogger().With("fn", fn)
+
+	exchanges := filter.Exchanges
+	if len(exchanges) == 0 {
+		exchanges = types.ValidExchanges
+	}
+	symbols := filter.Symbols
+	if len(symbols) == 0 {
+		symbols = types.ValidSymbols
+	}
+
+	ew, err := newExportWriter(w, format, tickColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, exchange := range exchanges {
+		for _, symbol := range symbols {
+			err := s.cache.IterateHistory(ctx, exchange, symbol, filter.From, filter.To, func(p *domain.PriceData) error {
+				return ew.write(p, []string{
+					string(p.Exchange),
+					string(p.Symbol),
+					formatTime(p.Timestamp),
+					formatFloat(p.Price),
+					string(p.Source),
+				})
+			})
+			if err != nil {
+				log.ErrorContext(ctx, "failed to export ticks", "exchange", exchange, "symbol", symbol, "error", err)
+				return ew.rows, err
+			}
+		}
+	}
+
+	return ew.rows, ew.flush()
+}
+
+// Stats writes aggregated stats from Postgres ordered by timestamp. Returns number of written rows.
+func (s *Export) Stats(ctx context.Context, filter domain.ExportFilter, format types.ExportFormat, w io.Writer) (int, error) {
+	const fn = "Export.Stats"
+	log := s.logger.GetSlogLogger().With("fn", fn)
+
+	ew, err := newExportWriter(w, format, statsColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.storage.IterateStats(ctx, filter, func(p *domain.PriceStats) error {
+		return ew.write(p, []string{
+			string(p.Exchange),
+			string(p.Pair),
+			formatTime(p.Timestamp),
+			formatFloat(p.Min),
+			formatFloat(p.Max),
+			formatFloat(p.Average),
+			string(p.Source),
+		})
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to export stats", "error", err)
+		return ew.rows, err
+	}
+
+	return ew.rows, ew.flush()
+}
+
+// exportWriter encodes rows in given format and flushes them every exportFlushRows rows
+type exportWriter struct {
+	w      io.Writer
+	format types.ExportFormat
+	csv    *csv.Writer
+	json   *json.Encoder
+	rows   int
+}
+
+func newExportWriter(w io.Writer, format types.ExportFormat, columns []string) (*exportWriter, error) {
+	ew := &exportWriter{w: w, format: format}
+
+	switch format {
+	case types.FormatCSV:
+		ew.csv = csv.NewWriter(w)
+		if err := ew.csv.Write(columns); err != nil {
+			return nil, err
+		}
+	case types.FormatNDJSON:
+		ew.json = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidExportFormat, format)
+	}
+
+	return ew, nil
+}
+
+// write writes value as JSON line or record as CSV row
+func (ew *exportWriter) write(value any, record []string) error {
+	var err error
+	if ew.csv != nil {
+		err = ew.csv.Write(record)
+	} else {
+		err = ew.json.Encode(value)
+	}
+	if err != nil {
+		return err
+	}
+
+	ew.rows++
+	if ew.rows%exportFlushRows == 0 {
+		return ew.flush()
+	}
+	return nil
+}
+
+// flush sends buffered rows to writer, and further to the client if writer can be flushed
+func (ew *exportWriter) flush() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		if err := ew.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if f, ok := ew.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"marketflow/internal/domain"
+)
+
+// FanIn merges multiple input channels into a single output channel
+func FanIn(ctx context.Context, inputs ...<-chan *domain.PriceData) <-chan *domain.PriceData {
+	output := make(chan *domain.PriceData)
+
+	var wg sync.WaitGroup
+
+	for _, ch := range inputs {
+		wg.Add(1)
+
+		go func(in <-chan *domain.PriceData) {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case data, ok := <-in:
+					if !ok {
+						return
+					}
+					select {
+					case output <- data:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		wg.Wait()
+		close(output)
+	}()
+
+	return output
+}
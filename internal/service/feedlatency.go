@@ -0,0 +1,161 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/metrics"
+)
+
+var (
+	feedLatencyBuckets = metrics.ExponentialBuckets(0.001, 2, 14) // 1ms to ~8s
+
+	feedLatency = metrics.NewHistogramVec("marketflow_feed_latency_seconds",
+		"Time from exchange timestamp of tick to its receiving, includes clock skew of exchange.", feedLatencyBuckets, "exchange")
+	feedSkew = metrics.NewGaugeVec("marketflow_feed_clock_skew_seconds",
+		"Estimated clock skew of exchange, lowest receive delay within skew window. Positive if exchange clock is behind.", "exchange")
+	feedSkewAbs = metrics.NewHistogramVec("marketflow_feed_clock_skew_abs_seconds",
+		"Absolute clock skew of exchange estimated when tick was received.", feedLatencyBuckets, "exchange")
+	feedRebased = metrics.NewCounterVec("marketflow_feed_rebased_ticks_total",
+		"Ticks with timestamp replaced by receive time because clock skew exceeded threshold.", "exchange")
+)
+
+// FeedMonitor measures latency of live ticks and clock skew of exchanges from receive time stamped by exchange source.
+// One-way delay can not tell network delay from clock difference, so skew is estimated as the lowest delay
+// within skew window, the delay of the fastest tick. When threshold is set and skew exceeds it,
+// tick timestamps are rebased to receive time.
+type FeedMonitor struct {
+	mu    sync.Mutex
+	feeds map[types.Exchange]*feedState
+
+	cfg    config.Feed
+	logger logger.Logger
+}
+
+type feedState struct {
+	ticks   int64
+	rebased int64
+
+	windowStart time.Time
+	windowMin   time.Duration // lowest delay in current window
+	skew        time.Duration // estimate of the last complete window
+	hasSkew     bool
+	rebasing    bool
+
+	latency *metrics.Histogram
+	skewAbs *metrics.Histogram
+}
+
+// estimate returns skew of the last complete window, until the first window is complete, the lowest delay so far
+func (s *feedState) estimate() time.Duration {
+	if s.hasSkew {
+		return s.skew
+	}
+	return s.windowMin
+}
+
+func NewFeedMonitor(cfg config.Feed, logger logger.Logger) *FeedMonitor {
+	return &FeedMonitor{
+		feeds:  make(map[types.Exchange]*feedState),
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Observe measures tick received from live exchange, ticks without receive time are ignored
+func (f *FeedMonitor) Observe(p *domain.PriceData) {
+	if p.ReceivedAt.IsZero() || p.Timestamp.IsZero() {
+		return
+	}
+	delay := p.ReceivedAt.Sub(p.Timestamp)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.feeds[p.Exchange]
+	if !ok {
+		s = &feedState{
+			windowStart: p.ReceivedAt,
+			windowMin:   delay,
+			latency:     feedLatency.With(string(p.Exchange)),
+			skewAbs:     feedSkewAbs.With(string(p.Exchange)),
+		}
+		f.feeds[p.Exchange] = s
+	}
+
+	s.ticks++
+	s.latency.Observe(max(delay, 0).Seconds())
+
+	s.windowMin = min(s.windowMin, delay)
+	if p.ReceivedAt.Sub(s.windowStart) >= f.cfg.SkewWindow {
+		s.skew, s.hasSkew = s.windowMin, true
+		s.windowStart, s.windowMin = p.ReceivedAt, delay
+	}
+
+	skew := s.estimate()
+	feedSkew.With(string(p.Exchange)).Set(skew.Seconds())
+	s.skewAbs.Observe(skew.Abs().Seconds())
+
+	rebasing := f.cfg.RebaseThreshold > 0 && skew.Abs() > f.cfg.RebaseThreshold
+	if rebasing != s.rebasing {
+		s.rebasing = rebasing
+		if rebasing {
+			f.logger.Warn(context.Background(), "exchange clock skew exceeds threshold, rebasing timestamps to receive time",
+				"exchange", p.Exchange, "skew", skew, "threshold", f.cfg.RebaseThreshold)
+		} else {
+			f.logger.Info(context.Background(), "exchange clock skew is back within threshold, using exchange timestamps",
+				"exchange", p.Exchange, "skew", skew, "threshold", f.cfg.RebaseThreshold)
+		}
+	}
+
+	if rebasing {
+		p.Timestamp = p.ReceivedAt
+		s.rebased++
+		feedRebased.With(string(p.Exchange)).Inc()
+	}
+}
+
+// Stats returns latency and skew of exchanges that sent live ticks, sorted by exchange
+func (f *FeedMonitor) Stats() []domain.FeedLatency {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats := make([]domain.FeedLatency, 0, len(f.feeds))
+	for exchange, s := range f.feeds {
+		skew := s.estimate()
+		stats = append(stats, domain.FeedLatency{
+			Exchange:     exchange,
+			Ticks:        s.ticks,
+			LatencyP50:   quantileMs(s.latency, 0.5),
+			LatencyP90:   quantileMs(s.latency, 0.9),
+			LatencyP99:   quantileMs(s.latency, 0.99),
+			Skew:         float64(skew) / float64(time.Millisecond),
+			SkewP50:      quantileMs(s.skewAbs, 0.5),
+			SkewP99:      quantileMs(s.skewAbs, 0.99),
+			Rebasing:     s.rebasing,
+			RebasedTicks: s.rebased,
+		})
+	}
+
+	slices.SortFunc(stats, func(a, b domain.FeedLatency) int {
+		return cmp.Compare(a.Exchange, b.Exchange)
+	})
+	return stats
+}
+
+// quantileMs returns quantile of histogram in seconds as milliseconds, 0 if histogram is empty
+func quantileMs(h *metrics.Histogram, q float64) float64 {
+	v := h.Quantile(q)
+	if math.IsNaN(v) {
+		return 0
+	}
+	return v * 1000
+}
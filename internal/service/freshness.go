@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+)
+
+// touch records timestamp of tick of the symbol, the clock latest prices are marked stale by.
+// Out of order ticks do not move the time back, ticks without timestamp are ignored.
+func (p *pipeline) touch(data *domain.PriceData) {
+	if data.Timestamp.IsZero() {
+		return
+	}
+	at := data.Timestamp.UnixNano()
+	storeLater(&p.lastTick, at)
+
+	last, ok := p.symbolTicks.Load(data.Symbol)
+	if !ok {
+		last, _ = p.symbolTicks.LoadOrStore(data.Symbol, new(atomic.Int64))
+	}
+	storeLater(last.(*atomic.Int64), at)
+}
+
+func storeLater(v *atomic.Int64, at int64) {
+	for {
+		old := v.Load()
+		if at <= old || v.CompareAndSwap(old, at) {
+			return
+		}
+	}
+}
+
+// symbolTick returns timestamp of the last tick of the symbol, zero if there was none
+func (p *pipeline) symbolTick(symbol types.Symbol) time.Time {
+	last, ok := p.symbolTicks.Load(symbol)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(0, last.(*atomic.Int64).Load())
+}
+
+// Freshness returns age of the last ticks of exchanges and their symbols. Exchange is stale when no tick
+// was received for ExchangeStaleAfter, degraded when some of its symbols had no tick for SymbolStaleAfter.
+// Ages are counted by tick timestamps like stale latest prices, and from connection at most,
+// so a started source is not stale until thresholds pass.
+func (m *ExchangeManager) Freshness() *domain.Freshness {
+	now := time.Now()
+	freshness := &domain.Freshness{Status: types.FreshnessPaused, Exchanges: []domain.ExchangeFreshness{}}
+
+	var active, fresh, stale int
+	for _, exchange := range m.exchanges() {
+		f := m.exchangeFreshness(exchange, now)
+		freshness.Exchanges = append(freshness.Exchanges, *f)
+
+		switch f.Status {
+		case types.FreshnessPaused:
+			continue
+		case types.FreshnessFresh:
+			fresh++
+		case types.FreshnessStale:
+			stale++
+		}
+		active++
+	}
+
+	switch {
+	case active == 0:
+	case fresh == active:
+		freshness.Status = types.FreshnessFresh
+	case stale == active:
+		freshness.Status = types.FreshnessStale
+	default:
+		freshness.Status = types.FreshnessDegraded
+	}
+
+	return freshness
+}
+
+func (m *ExchangeManager) exchangeFreshness(exchange types.Exchange, now time.Time) *domain.ExchangeFreshness {
+	m.stateMu.RLock()
+	p, running := m.pipelines[exchange]
+	m.stateMu.RUnlock()
+
+	f := &domain.ExchangeFreshness{Exchange: exchange, Status: types.FreshnessPaused}
+	if !running {
+		return f
+	}
+
+	f.Status = types.FreshnessFresh
+	if lastTick := p.lastTick.Load(); lastTick != 0 {
+		f.LastTickAt = time.Unix(0, lastTick)
+		f.LastTickAge = now.Sub(f.LastTickAt).Round(time.Millisecond).String()
+	}
+	if now.Sub(later(f.LastTickAt, p.connectedAt)) > m.freshnessCfg.ExchangeStaleAfter {
+		f.Status = types.FreshnessStale
+	}
+
+	for _, symbol := range types.ValidSymbols {
+		s := domain.SymbolFreshness{Symbol: symbol, LastTickAt: p.symbolTick(symbol)}
+		if !s.LastTickAt.IsZero() {
+			s.LastTickAge = now.Sub(s.LastTickAt).Round(time.Millisecond).String()
+		}
+		s.Stale = now.Sub(later(s.LastTickAt, p.connectedAt)) > m.freshnessCfg.SymbolStaleAfter
+		if s.Stale && f.Status == types.FreshnessFresh {
+			f.Status = types.FreshnessDegraded
+		}
+		f.Symbols = append(f.Symbols, s)
+	}
+
+	return f
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// FeedHealth is health of exchange feed based on freshness of received ticks,
+// unlike connection check it tells whether connected feed keeps sending data
+type FeedHealth struct {
+	manager  *ExchangeManager
+	exchange types.Exchange
+}
+
+// FeedHealth returns health checks of exchange feeds
+func (m *ExchangeManager) FeedHealth() []*FeedHealth {
+	checks := make([]*FeedHealth, 0, len(m.initialSources))
+	for _, source := range m.initialSources {
+		checks = append(checks, &FeedHealth{manager: m, exchange: types.Exchange(source.Name())})
+	}
+	return checks
+}
+
+func (h *FeedHealth) Name() string {
+	return string(h.exchange)
+}
+
+// Health reports feed unhealthy when exchange is stale, paused exchange is healthy
+func (h *FeedHealth) Health(ctx context.Context) (bool, error) {
+	return h.manager.exchangeFreshness(h.exchange, time.Now()).Status != types.FreshnessStale, nil
+}
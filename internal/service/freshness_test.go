@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+func TestFreshnessAgreesWithStaleLatest(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Freshness{ExchangeStaleAfter: time.Minute, SymbolStaleAfter: 10 * time.Second}
+	now := time.Now()
+
+	// tick of BTCUSDT was received now but is stamped 20s ago, ETHUSDT is recent, the older one comes out of order
+	btc := &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: 100, Timestamp: now.Add(-20 * time.Second), ReceivedAt: now}
+	eth := &domain.PriceData{Exchange: types.Exchange1, Symbol: types.ETHUSDT, Price: 10, Timestamp: now.Add(-time.Second), ReceivedAt: now}
+	old := &domain.PriceData{Exchange: types.Exchange1, Symbol: types.ETHUSDT, Price: 9, Timestamp: now.Add(-time.Hour), ReceivedAt: now}
+
+	p := &pipeline{connectedAt: now.Add(-time.Hour)}
+	for _, data := range []*domain.PriceData{btc, eth, old} {
+		p.touch(data)
+	}
+	m := &ExchangeManager{pipelines: map[types.Exchange]*pipeline{types.Exchange1: p}, freshnessCfg: cfg}
+	f := m.exchangeFreshness(types.Exchange1, now)
+
+	market := NewMarket(nil, &fakeCache{latest: map[types.Symbol]*domain.PriceData{
+		types.BTCUSDT: btc,
+		types.ETHUSDT: eth,
+	}}, cfg.SymbolStaleAfter, logger.InitLogger(ctx, "error"))
+	results := market.GetBatch(ctx, []domain.PriceQuery{
+		{Metric: types.MetricLatest, Exchange: types.Exchange1, Symbol: types.BTCUSDT},
+		{Metric: types.MetricLatest, Exchange: types.Exchange1, Symbol: types.ETHUSDT},
+	})
+
+	for i, symbol := range []types.Symbol{types.BTCUSDT, types.ETHUSDT} {
+		var freshness *domain.SymbolFreshness
+		for j := range f.Symbols {
+			if f.Symbols[j].Symbol == symbol {
+				freshness = &f.Symbols[j]
+			}
+		}
+		if freshness == nil || freshness.Stale != results[i].Latest.Stale {
+			t.Fatalf("%s: freshness %+v disagrees with latest price %+v", symbol, freshness, results[i].Latest)
+		}
+	}
+	if !results[0].Latest.Stale || results[1].Latest.Stale {
+		t.Fatalf("only BTCUSDT must be stale, got %+v, %+v", results[0].Latest, results[1].Latest)
+	}
+	if !f.LastTickAt.Equal(eth.Timestamp) {
+		t.Fatalf("last tick at %v, out of order tick must not move it back from %v", f.LastTickAt, eth.Timestamp)
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// LeaderElector makes sure only one instance runs singleton duties.
+// Leader holds expiring lease and renews it, followers try to acquire it,
+// so if leader dies the lease is taken over within lease TTL.
+type LeaderElector struct {
+	lease  ports.Lease
+	holder string
+
+	onElected func(ctx context.Context) // called with context cancelled on losing leadership
+	onRevoked func()
+
+	mu           sync.RWMutex
+	isLeader     bool
+	expiresAt    time.Time
+	leaderCancel context.CancelFunc
+
+	cancelFunc context.CancelFunc
+	doneChan   chan struct{}
+
+	cfg    config.Leader
+	logger logger.Logger
+}
+
+func NewLeaderElector(lease ports.Lease, cfg config.Leader, onElected func(ctx context.Context), onRevoked func(), logger logger.Logger) *LeaderElector {
+	return &LeaderElector{
+		lease:     lease,
+		onElected: onElected,
+		onRevoked: onRevoked,
+		doneChan:  make(chan struct{}),
+		cfg:       cfg,
+		logger:    logger,
+	}
+}
+
+// Start starts election loop
+func (e *LeaderElector) Start(ctx context.Context) error {
+	e.holder = e.cfg.ID
+	if e.holder == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to resolve leader id: %w", err)
+		}
+		e.holder = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	e.cancelFunc = cancel
+
+	go e.run(ctx)
+
+	return nil
+}
+
+func (e *LeaderElector) run(ctx context.Context) {
+	defer close(e.doneChan)
+
+	// Renewing few times per TTL, so short network issues do not cause failover
+	ticker := time.NewTicker(e.cfg.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick renews lease if instance is leader, otherwise tries to acquire it
+func (e *LeaderElector) tick(ctx context.Context) {
+	log := e.logger.GetSlogLogger().With("holder", e.holder, "key", e.cfg.Key)
+	now := time.Now()
+
+	if e.IsLeader() {
+		ok, err := e.lease.Renew(ctx, e.cfg.Key, e.holder, e.cfg.LeaseTTL)
+		switch {
+		case err != nil:
+			log.Warn("failed to renew leader lease", "error", err)
+			// Lease may have expired already, someone else could be the leader now
+			if now.After(e.leaseExpiresAt()) {
+				e.revoke(ctx)
+			}
+		case !ok:
+			log.Warn("leader lease lost")
+			e.revoke(ctx)
+		default:
+			e.setExpiresAt(now.Add(e.cfg.LeaseTTL))
+		}
+		return
+	}
+
+	ok, err := e.lease.Acquire(ctx, e.cfg.Key, e.holder, e.cfg.LeaseTTL)
+	if err != nil {
+		log.Warn("failed to acquire leader lease", "error", err)
+		return
+	}
+	if ok {
+		e.elect(ctx, now.Add(e.cfg.LeaseTTL))
+	}
+}
+
+func (e *LeaderElector) elect(ctx context.Context, expiresAt time.Time) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+
+	e.mu.Lock()
+	e.isLeader = true
+	e.expiresAt = expiresAt
+	e.leaderCancel = cancel
+	e.mu.Unlock()
+
+	e.logger.Info(ctx, "elected as leader", "holder", e.holder)
+	e.onElected(leaderCtx)
+}
+
+func (e *LeaderElector) revoke(ctx context.Context) {
+	e.mu.Lock()
+	if !e.isLeader {
+		e.mu.Unlock()
+		return
+	}
+	e.isLeader = false
+	e.expiresAt = time.Time{}
+	cancel := e.leaderCancel
+	e.leaderCancel = nil
+	e.mu.Unlock()
+
+	e.logger.Info(ctx, "stepping down from leadership", "holder", e.holder)
+	cancel()
+	e.onRevoked()
+}
+
+// IsLeader reports whether this instance is the leader
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+func (e *LeaderElector) leaseExpiresAt() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.expiresAt
+}
+
+func (e *LeaderElector) setExpiresAt(t time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expiresAt = t
+}
+
+// Leadership returns current leader and lease expiry as seen in the lease storage
+func (e *LeaderElector) Leadership(ctx context.Context) (*domain.Leadership, error) {
+	holder, ttl, err := e.lease.Holder(ctx, e.cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	leadership := &domain.Leadership{
+		Holder:   holder,
+		IsLeader: holder != "" && holder == e.holder,
+	}
+	if holder != "" && ttl > 0 {
+		leadership.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	return leadership, nil
+}
+
+// Close stops election loop, stops singleton duties and releases the lease,
+// so other instance can take over immediately.
+func (e *LeaderElector) Close() error {
+	if e.cancelFunc == nil {
+		return nil
+	}
+	e.cancelFunc()
+
+	select {
+	case <-e.doneChan:
+	case <-time.After(5 * time.Second):
+		return errors.New("timeout waiting for leader elector to stop")
+	}
+
+	wasLeader := e.IsLeader()
+	e.revoke(context.Background())
+
+	if wasLeader {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		return e.lease.Release(ctx, e.cfg.Key, e.holder)
+	}
+	return nil
+}
@@ -11,37 +11,46 @@ import (
 )
 
 type Market struct {
-	storage ports.MarketRepository
-	cache   ports.Cache
-	logger  logger.Logger
+	storage    ports.MarketRepository
+	cache      ports.Cache
+	staleAfter time.Duration // age of latest price to be marked stale
+	logger     logger.Logger
 }
 
-func NewMarket(repo ports.MarketRepository, cache ports.Cache, logger logger.Logger) *Market {
+func NewMarket(repo ports.MarketRepository, cache ports.Cache, staleAfter time.Duration, logger logger.Logger) *Market {
 	return &Market{
-		storage: repo,
-		cache:   cache,
-		logger:  logger,
+		storage:    repo,
+		cache:      cache,
+		staleAfter: staleAfter,
+		logger:     logger,
 	}
 }
 
-// GetLatest returns latest price data from cache.
+// GetLatest returns latest price data from cache, marked stale if it is older than threshold.
 func (s *Market) GetLatest(ctx context.Context, exchange types.Exchange, symbol types.Symbol) (*domain.PriceData, error) {
 	const fn = "GetLatest"
 	log := s.logger.GetSlogLogger().With("fn", fn, "exchange", exchange, "symbol", symbol)
 
 	latest, err := s.cache.GetLatest(ctx, exchange, symbol)
 	if err != nil {
-		log.Error("failed to get latest data from cache", "error", err)
+		log.ErrorContext(ctx, "failed to get latest data from cache", "error", err)
 		return nil, err
 	}
 
 	if latest == nil {
 		return nil, domain.ErrNotFound
 	}
+	s.markStale(latest)
 
 	return latest, nil
 }
 
+// markStale marks latest price stale if it is older than threshold. Age is counted by tick timestamp,
+// the same clock exchange freshness is measured by.
+func (s *Market) markStale(latest *domain.PriceData) {
+	latest.Stale = time.Since(latest.Timestamp) > s.staleAfter
+}
+
 func (s *Market) GetHighest(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) (*domain.PriceStats, error) {
 	const fn = "GetHighest"
 	log := s.logger.GetSlogLogger().With("fn", fn, "exchange", exchange, "symbol", symbol)
@@ -52,7 +61,7 @@ func (s *Market) GetHighest(ctx context.Context, exchange types.Exchange, symbol
 
 	highest, err := s.storage.GetHighestStat(ctx, exchange, symbol, period)
 	if err != nil {
-		log.Error("failed to get stats from database", "error", err)
+		log.ErrorContext(ctx, "failed to get stats from database", "error", err)
 		return s.fetchHighestFromCache(ctx, exchange, symbol, period)
 	}
 
@@ -69,15 +78,13 @@ func (s *Market) GetHighest(ctx context.Context, exchange types.Exchange, symbol
 }
 
 func (s *Market) fetchHighestFromCache(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) (*domain.PriceStats, error) {
-	prices, err := s.cache.GetPriceInPeriod(ctx, exchange, symbol, period)
+	_, max, _, err := s.cache.GetStatsInPeriod(ctx, exchange, symbol, period, types.SourceLive)
 	if err != nil {
 		s.logger.Error(ctx, "failed to get data from Cache", "exchange", exchange, "symbol", symbol, "period", period, "error", err)
 		return nil, nil
 	}
 
-	_, max, _ := aggregateAndPrice(prices)
-
-	if len(prices) == 0 {
+	if max == nil {
 		s.logger.Warn(ctx, "no prices found in cache")
 		return nil, domain.ErrNotFound
 	}
@@ -100,7 +107,7 @@ func (s *Market) GetLowest(ctx context.Context, exchange types.Exchange, symbol
 
 	lowest, err := s.storage.GetLowestStat(ctx, exchange, symbol, period)
 	if err != nil {
-		log.Error("failed to get stats from database", "error", err)
+		log.ErrorContext(ctx, "failed to get stats from database", "error", err)
 		return s.fetchLowestFromCache(ctx, exchange, symbol, period)
 	}
 
@@ -117,19 +124,17 @@ func (s *Market) GetLowest(ctx context.Context, exchange types.Exchange, symbol
 }
 
 func (s *Market) fetchLowestFromCache(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) (*domain.PriceStats, error) {
-	prices, err := s.cache.GetPriceInPeriod(ctx, exchange, symbol, period)
+	min, _, _, err := s.cache.GetStatsInPeriod(ctx, exchange, symbol, period, types.SourceLive)
 	if err != nil {
 		s.logger.Error(ctx, "failed to get data from Cache", "exchange", exchange, "symbol", symbol, "period", period, "error", err)
 		return nil, nil
 	}
 
-	if len(prices) == 0 {
+	if min == nil {
 		s.logger.Warn(ctx, "no prices found in cache")
 		return nil, domain.ErrNotFound
 	}
 
-	min, _, _ := aggregateAndPrice(prices)
-
 	return &domain.PriceStats{
 		Exchange:  exchange,
 		Pair:      symbol,
@@ -148,7 +153,7 @@ func (s *Market) GetAverage(ctx context.Context, exchange types.Exchange, symbol
 
 	avg, err := s.storage.GetAverageStat(ctx, exchange, symbol, period)
 	if err != nil {
-		log.Error("failed to get stats from database, trying to check from cache...", "error", err)
+		log.ErrorContext(ctx, "failed to get stats from database, trying to check from cache...", "error", err)
 		return s.fetchAverageFromCache(ctx, exchange, symbol, period)
 	}
 
@@ -165,19 +170,17 @@ func (s *Market) GetAverage(ctx context.Context, exchange types.Exchange, symbol
 }
 
 func (s *Market) fetchAverageFromCache(ctx context.Context, exchange types.Exchange, symbol types.Symbol, period time.Duration) (*domain.PriceStats, error) {
-	prices, err := s.cache.GetPriceInPeriod(ctx, exchange, symbol, period)
+	_, _, avg, err := s.cache.GetStatsInPeriod(ctx, exchange, symbol, period, types.SourceLive)
 	if err != nil {
 		s.logger.Error(ctx, "failed to get data from Cache", "exchange", exchange, "symbol", symbol, "period", period, "error", err)
 		return nil, nil
 	}
 
-	if len(prices) == 0 {
+	if avg == nil {
 		s.logger.Warn(ctx, "no prices found in cache")
 		return nil, domain.ErrNotFound
 	}
 
-	_, _, avg := aggregateAndPrice(prices)
-
 	return &domain.PriceStats{
 		Exchange:  exchange,
 		Pair:      symbol,
@@ -185,3 +188,123 @@ func (s *Market) fetchAverageFromCache(ctx context.Context, exchange types.Excha
 		Average:   avg.Price,
 	}, nil
 }
+
+// GetBatch returns results of queries in the same order. Latest prices are read with single MGET,
+// stats of periods of a minute or longer are read in single database batch, and stats of shorter periods
+// or missing in database are computed in single Redis pipeline.
+func (s *Market) GetBatch(ctx context.Context, queries []domain.PriceQuery) []domain.PriceQueryResult {
+	const fn = "GetBatch"
+	log := s.logger.GetSlogLogger().With("fn", fn)
+
+	results := make([]domain.PriceQueryResult, len(queries))
+
+	// Indexes of queries answered by database and by cache
+	var latest, stored, cached []int
+	for i, q := range queries {
+		switch {
+		case q.Metric == types.MetricLatest:
+			latest = append(latest, i)
+		case q.Period < time.Minute:
+			cached = append(cached, i)
+		default:
+			stored = append(stored, i)
+		}
+	}
+
+	if len(stored) > 0 {
+		stats, err := s.storage.GetStatsBatch(ctx, pick(queries, stored))
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get stats from database", "queries", len(stored), "error", err)
+		}
+		for j, i := range stored {
+			if err != nil || stats[j] == nil {
+				cached = append(cached, i)
+				continue
+			}
+			results[i].Stats = stats[j]
+		}
+	}
+
+	if len(latest) > 0 {
+		prices, err := s.cache.GetLatestBatch(ctx, pick(queries, latest))
+		for j, i := range latest {
+			switch {
+			case err != nil:
+				results[i].Err = err
+			case prices[j] == nil:
+				results[i].Err = domain.ErrNotFound
+			default:
+				s.markStale(prices[j])
+				results[i].Latest = prices[j]
+			}
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get latest prices from cache", "error", err)
+		}
+	}
+
+	if len(cached) > 0 {
+		stats, err := s.cache.GetStatsBatch(ctx, pick(queries, cached), types.SourceLive)
+		for j, i := range cached {
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			results[i].Stats, results[i].Err = statFromCache(queries[i], stats[j])
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get stats from cache", "error", err)
+		}
+	}
+
+	return results
+}
+
+// statFromCache picks query metric from stats computed by cache
+func statFromCache(q domain.PriceQuery, stats domain.PeriodStats) (*domain.PriceStats, error) {
+	if stats.Err != nil {
+		return nil, stats.Err
+	}
+
+	result := &domain.PriceStats{
+		Exchange: q.Exchange,
+		Pair:     q.Symbol,
+	}
+
+	var price *domain.PriceData
+	switch q.Metric {
+	case types.MetricHighest:
+		price = stats.Max
+		if price != nil {
+			result.Max = price.Price
+		}
+	case types.MetricLowest:
+		price = stats.Min
+		if price != nil {
+			result.Min = price.Price
+		}
+	case types.MetricAverage:
+		price = stats.Average
+		if price != nil {
+			result.Average = price.Price
+		}
+	default:
+		return nil, domain.ErrInvalidMetric
+	}
+
+	if price == nil {
+		return nil, domain.ErrNotFound
+	}
+	result.Timestamp = price.Timestamp
+
+	return result, nil
+}
+
+// pick returns queries of given indexes
+func pick(queries []domain.PriceQuery, indexes []int) []domain.PriceQuery {
+	picked := make([]domain.PriceQuery, len(indexes))
+	for j, i := range indexes {
+		picked[j] = queries[i]
+	}
+	return picked
+}
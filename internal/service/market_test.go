@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// fakeMarketRepo answers stats batches, stubs other methods by embedded nil interface
+type fakeMarketRepo struct {
+	ports.MarketRepository
+	stats   map[types.Metric]*domain.PriceStats
+	err     error
+	batches int
+}
+
+func (r *fakeMarketRepo) GetStatsBatch(ctx context.Context, queries []domain.PriceQuery) ([]*domain.PriceStats, error) {
+	r.batches++
+	if r.err != nil {
+		return nil, r.err
+	}
+	stats := make([]*domain.PriceStats, len(queries))
+	for i, q := range queries {
+		stats[i] = r.stats[q.Metric]
+	}
+	return stats, nil
+}
+
+// fakeCache answers batches of latest prices and stats
+type fakeCache struct {
+	ports.Cache
+	latest  map[types.Symbol]*domain.PriceData
+	stats   domain.PeriodStats
+	sources []types.Source
+	queries int
+}
+
+func (c *fakeCache) GetLatestBatch(ctx context.Context, queries []domain.PriceQuery) ([]*domain.PriceData, error) {
+	prices := make([]*domain.PriceData, len(queries))
+	for i, q := range queries {
+		if p, ok := c.latest[q.Symbol]; ok {
+			copied := *p
+			prices[i] = &copied
+		}
+	}
+	return prices, nil
+}
+
+func (c *fakeCache) GetStatsBatch(ctx context.Context, queries []domain.PriceQuery, source types.Source) ([]domain.PeriodStats, error) {
+	c.sources = append(c.sources, source)
+	c.queries += len(queries)
+	stats := make([]domain.PeriodStats, len(queries))
+	for i := range stats {
+		stats[i] = c.stats
+	}
+	return stats, nil
+}
+
+func TestMarketGetBatch(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+	now := time.Now()
+
+	repo := &fakeMarketRepo{stats: map[types.Metric]*domain.PriceStats{
+		types.MetricHighest: {Exchange: types.Exchange1, Pair: types.BTCUSDT, Max: 200, Timestamp: now},
+	}}
+	cache := &fakeCache{
+		latest: map[types.Symbol]*domain.PriceData{
+			types.BTCUSDT: {Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: 100, Timestamp: now.Add(-time.Minute)},
+			types.ETHUSDT: {Exchange: types.Exchange1, Symbol: types.ETHUSDT, Price: 10, Timestamp: now},
+		},
+		stats: domain.PeriodStats{Min: &domain.PriceData{Price: 90, Timestamp: now}},
+	}
+	market := NewMarket(repo, cache, 30*time.Second, log)
+
+	queries := []domain.PriceQuery{
+		{Metric: types.MetricLatest, Exchange: types.Exchange1, Symbol: types.BTCUSDT},
+		{Metric: types.MetricLatest, Exchange: types.Exchange1, Symbol: types.ETHUSDT},
+		{Metric: types.MetricHighest, Exchange: types.Exchange1, Symbol: types.BTCUSDT, Period: time.Hour},
+		{Metric: types.MetricLowest, Exchange: types.Exchange1, Symbol: types.BTCUSDT, Period: time.Hour},
+		{Metric: types.MetricLowest, Exchange: types.Exchange1, Symbol: types.BTCUSDT, Period: time.Second},
+	}
+	results := market.GetBatch(ctx, queries)
+
+	if !results[0].Latest.Stale || results[1].Latest.Stale {
+		t.Fatalf("latest prices must be marked stale like single latest, got %+v, %+v", results[0].Latest, results[1].Latest)
+	}
+	if results[2].Stats == nil || results[2].Stats.Max != 200 {
+		t.Fatalf("highest must be read from database, got %+v", results[2])
+	}
+	// lowest has no stored stats, so it falls back to cache with the short period query
+	for _, i := range []int{3, 4} {
+		if results[i].Err != nil || results[i].Stats == nil || results[i].Stats.Min != 90 {
+			t.Fatalf("lowest must be computed by cache, got %+v", results[i])
+		}
+	}
+
+	if repo.batches != 1 {
+		t.Fatalf("stored stats must be read in single batch, got %d", repo.batches)
+	}
+	if len(cache.sources) != 1 || cache.sources[0] != types.SourceLive || cache.queries != 2 {
+		t.Fatalf("cache stats must be computed of live ticks in single pipeline, got sources %v and %d queries", cache.sources, cache.queries)
+	}
+}
+
+func TestMarketGetBatchFallsBackToCache(t *testing.T) {
+	ctx := context.Background()
+	log := logger.InitLogger(ctx, "error")
+
+	repo := &fakeMarketRepo{err: errors.New("connection refused")}
+	cache := &fakeCache{stats: domain.PeriodStats{Max: &domain.PriceData{Price: 150, Timestamp: time.Now()}}}
+	market := NewMarket(repo, cache, time.Minute, log)
+
+	results := market.GetBatch(ctx, []domain.PriceQuery{
+		{Metric: types.MetricHighest, Exchange: types.Exchange1, Symbol: types.BTCUSDT, Period: time.Hour},
+		{Metric: types.MetricHighest, Exchange: types.AllExchanges, Symbol: types.BTCUSDT, Period: time.Hour},
+	})
+	for _, r := range results {
+		if r.Err != nil || r.Stats == nil || r.Stats.Max != 150 {
+			t.Fatalf("failed database batch must fall back to cache, got %+v", r)
+		}
+	}
+}
@@ -2,121 +2,299 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"runtime/debug"
 	"sync"
 	"time"
 
+	"marketflow/internal/domain"
 	"marketflow/internal/domain/types"
+	"marketflow/pkg/cron"
 	"marketflow/pkg/logger"
 )
 
-// Task представляет задачу, которую нужно периодически выполнять
-type Task struct {
-	Name string
-	Type types.TaskType
-
-	Interval time.Duration
-
-	Handler func(ctx context.Context) error
+// scheduledTask представляет задачу и состояние ее выполнения
+type scheduledTask struct {
+	domain.Task
+	schedule *cron.Schedule // только для TaskTypeCron
+
+	cancel context.CancelFunc // останавливает задачу при удалении
+
+	mu           sync.Mutex
+	running      bool
+	queued       bool
+	runs         int
+	lastRun      time.Time
+	lastDuration time.Duration
+	nextRun      time.Time
+	lastError    string
 }
 
 // Scheduler представляет планировщик задач
 type Scheduler struct {
+	parent  context.Context
 	ctx     context.Context
 	cancel  context.CancelFunc
-	tasks   []Task
+	tasks   map[string]*scheduledTask
+	order   []string // порядок добавления задач
 	logger  logger.Logger
 	wg      sync.WaitGroup
 	started bool
+	stopped chan struct{} // закрывается, когда все горутины задач завершились после Close, nil до Close
 	mu      sync.Mutex
+
+	stopTimeout time.Duration // время ожидания завершения задач в Close
 }
 
 func NewScheduler(ctx context.Context, logger logger.Logger) *Scheduler {
-	schedulerCtx, cancel := context.WithCancel(ctx)
-
 	return &Scheduler{
-		ctx:    schedulerCtx,
-		cancel: cancel,
-		tasks:  make([]Task, 0),
+		parent: ctx,
+		ctx:    ctx,
+		tasks:  make(map[string]*scheduledTask),
 		logger: logger,
+
+		stopTimeout: 5 * time.Second,
 	}
 }
 
 // Start запускает планировщик задач
 func (s *Scheduler) Start() {
-	if len(s.tasks) == 0 {
-		s.logger.Info(s.ctx, "no tasks to start in sheduler")
-		return
-	}
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.started {
+	if s.started && !s.stoppedNow() {
+		if s.stopped != nil {
+			s.logger.Warn(s.ctx, "Scheduler tasks of previous run are still running, not starting")
+			return
+		}
 		s.logger.Warn(s.ctx, "Scheduler already started")
 		return
 	}
 
-	s.logger.Info(s.ctx, "Starting scheduler")
+	s.logger.Info(s.ctx, "Starting scheduler", "tasks", len(s.tasks))
+
+	// Новый контекст на каждый запуск, чтобы шедулер можно было перезапустить после Close
+	s.ctx, s.cancel = context.WithCancel(s.parent)
 
 	// Запускаем каждую задачу в отдельной горутине
-	for _, task := range s.tasks {
-		s.wg.Add(1)
-		switch task.Type {
-		case types.TaskTypeInterval:
-			go s.runTask(task)
-		default:
-			s.logger.Warn(s.ctx, "failed to start task, invalid task type", "name", task.Name, "type", task.Type)
-		}
+	for _, name := range s.order {
+		s.startTask(s.tasks[name])
 	}
 
 	s.started = true
 }
 
-// runTask запускает отдельную задачу в цикле с заданным интервалом
-func (s *Scheduler) runTask(task Task) {
+// startTask запускает горутину задачи, вызывается под s.mu
+func (s *Scheduler) startTask(task *scheduledTask) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	task.cancel = cancel
+
+	s.wg.Add(1)
+	go s.runTask(ctx, task)
+}
+
+// runTask ожидает время следующего запуска задачи и запускает ее
+func (s *Scheduler) runTask(ctx context.Context, task *scheduledTask) {
 	defer s.wg.Done()
 
 	log := s.logger.GetSlogLogger().With("task name", task.Name)
+	log.InfoContext(ctx, "Starting task", "type", task.Type, "schedule", scheduleString(task.Task))
 
-	defer func() {
-		if r := recover(); r != nil {
-			log.ErrorContext(s.ctx, "Task panicked",
-				"panic", r,
-				"stack", debug.Stack())
+	var prev time.Time
+	for {
+		next, ok := task.next(prev, time.Now())
+		if !ok {
+			task.setNextRun(time.Time{})
+			log.InfoContext(ctx, "Task has no more runs")
+			return
 		}
-	}()
+		task.setNextRun(next)
+		prev = next
 
-	log.InfoContext(s.ctx, "Starting task", "interval", task.Interval)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			task.setNextRun(time.Time{})
+			log.InfoContext(ctx, "Stopping task")
+			return
+		case <-timer.C:
+		}
 
-	// Первый запуск задачи сразу после старта
-	if err := task.Handler(s.ctx); err != nil {
-		log.ErrorContext(s.ctx, "Failed to execute task", "error", err)
+		s.trigger(ctx, task)
 	}
+}
 
-	// Создаем тикер для периодического запуска
-	ticker := time.NewTicker(task.Interval)
-	defer ticker.Stop()
+// next возвращает время следующего запуска задачи. prev - время предыдущего запуска по расписанию.
+func (t *scheduledTask) next(prev, now time.Time) (time.Time, bool) {
+	switch t.Type {
+	case types.TaskTypeInterval:
+		// Первый запуск задачи сразу после старта
+		if prev.IsZero() {
+			return now, true
+		}
+		next := prev.Add(t.Interval)
+		if next.Before(now) {
+			next = now // пропущенные запуски не наверстываем
+		}
+		return next, true
+	case types.TaskTypeCron:
+		next := t.schedule.Next(now)
+		return next, !next.IsZero()
+	case types.TaskTypeOnce:
+		// Одноразовая задача не повторяется и после перезапуска шедулера
+		if !prev.IsZero() || t.hasRun() {
+			return time.Time{}, false
+		}
+		return now.Add(t.Delay), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// trigger запускает выполнение задачи с учетом политики перекрытия
+func (s *Scheduler) trigger(ctx context.Context, task *scheduledTask) {
+	task.mu.Lock()
+	if task.running {
+		if task.Overlap == types.OverlapQueue {
+			task.queued = true
+		} else {
+			s.logger.Warn(ctx, "Skipping task run, previous run is not finished", "task name", task.Name)
+		}
+		task.mu.Unlock()
+		return
+	}
+	task.running = true
+	task.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.execute(ctx, task)
+}
+
+// execute выполняет задачу и запуски, поставленные в очередь за время выполнения
+func (s *Scheduler) execute(ctx context.Context, task *scheduledTask) {
+	defer s.wg.Done()
 
 	for {
-		select {
-		case <-ticker.C:
-			log.DebugContext(s.ctx, "Executing task")
+		s.runWithRetries(ctx, task)
+
+		task.mu.Lock()
+		if task.queued && ctx.Err() == nil {
+			task.queued = false
+			task.mu.Unlock()
+			continue
+		}
+		task.queued = false
+		task.running = false
+		task.mu.Unlock()
+		return
+	}
+}
+
+// runWithRetries выполняет задачу, повторяя неудачные попытки с экспоненциальной задержкой
+func (s *Scheduler) runWithRetries(ctx context.Context, task *scheduledTask) {
+	log := s.logger.GetSlogLogger().With("task name", task.Name)
+	log.DebugContext(ctx, "Executing task")
+
+	start := time.Now()
+	backoff := task.Backoff
 
-			// Создаем тайм-аут для выполнения задачи
-			taskCtx, cancel := context.WithTimeout(s.ctx, task.Interval/2)
+	var err error
+	for attempt := 0; attempt <= task.Retries; attempt++ {
+		if attempt > 0 {
+			log.WarnContext(ctx, "Retrying task", "attempt", attempt, "backoff", backoff)
 
-			if err := task.Handler(taskCtx); err != nil {
-				log.ErrorContext(s.ctx, "Failed to execute task", "error", err)
+			select {
+			case <-ctx.Done():
+				task.finish(start, ctx.Err())
+				return
+			case <-time.After(backoff):
 			}
+			backoff *= 2
+		}
+
+		if err = s.runOnce(ctx, task); err == nil {
+			break
+		}
+		log.ErrorContext(ctx, "Failed to execute task", "attempt", attempt, "error", err)
+	}
 
-			cancel()
+	task.finish(start, err)
+}
 
-		case <-s.ctx.Done():
-			log.InfoContext(s.ctx, "Stopping task")
-			return
+// runOnce выполняет одну попытку задачи с тайм-аутом
+func (s *Scheduler) runOnce(ctx context.Context, task *scheduledTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error(ctx, "Task panicked",
+				"task name", task.Name,
+				"panic", r,
+				"stack", string(debug.Stack()))
+			err = fmt.Errorf("task panicked: %v", r)
 		}
+	}()
+
+	timeout := task.Timeout
+	if timeout == 0 && task.Type == types.TaskTypeInterval {
+		timeout = task.Interval / 2
+	}
+
+	// Создаем тайм-аут для выполнения задачи
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
+
+	return task.Handler(ctx)
+}
+
+// finish сохраняет результат выполнения задачи
+func (t *scheduledTask) finish(start time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.runs++
+	t.lastRun = start
+	t.lastDuration = time.Since(start)
+	t.lastError = ""
+	if err != nil {
+		t.lastError = err.Error()
+	}
+}
+
+func (t *scheduledTask) hasRun() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.runs > 0 || t.running
+}
+
+func (t *scheduledTask) setNextRun(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextRun = next
+}
+
+// info возвращает текущее состояние задачи
+func (t *scheduledTask) info() domain.TaskInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info := domain.TaskInfo{
+		Name:      t.Name,
+		Type:      t.Type,
+		Schedule:  scheduleString(t.Task),
+		Running:   t.running,
+		Runs:      t.runs,
+		LastRun:   t.lastRun,
+		NextRun:   t.nextRun,
+		LastError: t.lastError,
+	}
+	if t.runs > 0 {
+		info.LastDuration = t.lastDuration.String()
+	}
+
+	return info
 }
 
 // Close останавливает планировщик задач
@@ -131,35 +309,158 @@ func (s *Scheduler) Close() {
 	s.logger.Info(s.ctx, "Stopping scheduler")
 	s.cancel()
 
-	// Ожидаем завершения всех задач с тайм-аутом
-	done := make(chan struct{})
-	go func() {
-		s.wg.Wait()
-		close(done)
-	}()
+	// Ожидаем завершения всех задач с тайм-аутом, повторный Close ждет те же задачи
+	if s.stopped == nil {
+		done := make(chan struct{})
+		go func() {
+			s.wg.Wait()
+			close(done)
+		}()
+		s.stopped = done
+	}
 
 	select {
-	case <-done:
+	case <-s.stopped:
 		s.logger.Info(s.ctx, "All scheduler tasks stopped")
-	case <-time.After(5 * time.Second):
+		s.started, s.stopped = false, nil
+	case <-time.After(s.stopTimeout):
+		// started остается установленным, пока задачи не завершатся, чтобы Start не запустил их второй раз
 		s.logger.Warn(s.ctx, "Scheduler tasks shutdown timed out")
 	}
+}
 
-	s.started = false
+// stoppedNow сбрасывает состояние, если задачи, не завершившиеся за время Close, уже завершились.
+// Вызывается под s.mu
+func (s *Scheduler) stoppedNow() bool {
+	if s.stopped == nil {
+		return false
+	}
+	select {
+	case <-s.stopped:
+		s.started, s.stopped = false, nil
+		return true
+	default:
+		return false
+	}
 }
 
 // AddTask добавляет новую задачу в шедулер
-// Задача будет запущена только после перезапуска шедулера
-func (s *Scheduler) AddTask(name string, taskType types.TaskType, interval time.Duration, handler func(ctx context.Context) error) {
+// Если шедулер уже запущен, задача запускается сразу
+func (s *Scheduler) AddTask(task domain.Task) error {
+	scheduled, err := newScheduledTask(task)
+	if err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.tasks = append(s.tasks, Task{
-		Name:     name,
-		Type:     taskType,
-		Interval: interval,
-		Handler:  handler,
-	})
+	if _, exists := s.tasks[task.Name]; exists {
+		return fmt.Errorf("%w: %s", domain.ErrTaskExists, task.Name)
+	}
+
+	s.tasks[task.Name] = scheduled
+	s.order = append(s.order, task.Name)
+
+	if s.started {
+		s.startTask(scheduled)
+	}
+
+	s.logger.Info(s.ctx, "Task added", "name", task.Name, "type", task.Type, "schedule", scheduleString(task))
+	return nil
+}
+
+// RemoveTask останавливает и удаляет задачу из шедулера.
+// Уже начатое выполнение задачи получает отмену контекста.
+func (s *Scheduler) RemoveTask(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	s.logger.Info(s.ctx, "Task added", "name", name, "interval", interval)
+	task, exists := s.tasks[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", domain.ErrTaskNotFound, name)
+	}
+
+	if task.cancel != nil {
+		task.cancel()
+	}
+
+	delete(s.tasks, name)
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	s.logger.Info(s.ctx, "Task removed", "name", name)
+	return nil
+}
+
+// Tasks возвращает состояние всех задач в порядке добавления
+func (s *Scheduler) Tasks() []domain.TaskInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]domain.TaskInfo, 0, len(s.order))
+	for _, name := range s.order {
+		infos = append(infos, s.tasks[name].info())
+	}
+	return infos
+}
+
+// newScheduledTask проверяет задачу и подготавливает ее расписание
+func newScheduledTask(task domain.Task) (*scheduledTask, error) {
+	if task.Name == "" {
+		return nil, fmt.Errorf("%w: name must be provided", domain.ErrInvalidTask)
+	}
+	if task.Handler == nil {
+		return nil, fmt.Errorf("%w: handler must be provided", domain.ErrInvalidTask)
+	}
+	if task.Retries < 0 || task.Backoff < 0 || task.Timeout < 0 {
+		return nil, fmt.Errorf("%w: retries, backoff and timeout must not be negative", domain.ErrInvalidTask)
+	}
+	if task.Overlap == "" {
+		task.Overlap = types.OverlapSkip
+	}
+	if task.Overlap != types.OverlapSkip && task.Overlap != types.OverlapQueue {
+		return nil, fmt.Errorf("%w: unknown overlap policy %q", domain.ErrInvalidTask, task.Overlap)
+	}
+
+	scheduled := &scheduledTask{Task: task}
+
+	switch task.Type {
+	case types.TaskTypeInterval:
+		if task.Interval <= 0 {
+			return nil, fmt.Errorf("%w: interval must be positive", domain.ErrInvalidTask)
+		}
+	case types.TaskTypeCron:
+		schedule, err := cron.Parse(task.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidTask, err)
+		}
+		scheduled.schedule = schedule
+	case types.TaskTypeOnce:
+		if task.Delay < 0 {
+			return nil, fmt.Errorf("%w: delay must not be negative", domain.ErrInvalidTask)
+		}
+	default:
+		return nil, fmt.Errorf("%w: invalid task type %q", domain.ErrInvalidTask, task.Type)
+	}
+
+	return scheduled, nil
+}
+
+// scheduleString возвращает расписание задачи в читаемом виде
+func scheduleString(task domain.Task) string {
+	switch task.Type {
+	case types.TaskTypeInterval:
+		return "every " + task.Interval.String()
+	case types.TaskTypeCron:
+		return task.Cron
+	case types.TaskTypeOnce:
+		return "once after " + task.Delay.String()
+	default:
+		return ""
+	}
 }
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+// waitFor polls cond until it holds or fails test after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRestartWaitsForTasks(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(ctx, logger.InitLogger(ctx, "error"))
+	s.stopTimeout = 20 * time.Millisecond
+
+	var stuck atomic.Bool
+	var quickRuns atomic.Int32
+	release := make(chan struct{})
+	for _, task := range []domain.Task{
+		// ignores cancellation until released
+		{Name: "stuck", Type: types.TaskTypeInterval, Interval: 5 * time.Millisecond, Timeout: time.Minute, Handler: func(ctx context.Context) error {
+			if stuck.Swap(true) {
+				return nil
+			}
+			<-release
+			return nil
+		}},
+		{Name: "quick", Type: types.TaskTypeInterval, Interval: 5 * time.Millisecond, Handler: func(ctx context.Context) error {
+			quickRuns.Add(1)
+			return nil
+		}},
+	} {
+		if err := s.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.Start()
+	waitFor(t, "tasks to run", func() bool { return stuck.Load() && quickRuns.Load() > 0 })
+
+	// Close times out while a task is running, so tasks must not be started again alongside it
+	s.Close()
+	runs := quickRuns.Load()
+	s.Start()
+	time.Sleep(30 * time.Millisecond)
+	if quickRuns.Load() != runs {
+		t.Fatal("scheduler started again while task of previous run was running")
+	}
+
+	// once the task finished, scheduler starts again
+	close(release)
+	waitFor(t, "scheduler to start again", func() bool {
+		s.Start()
+		return quickRuns.Load() > runs
+	})
+	s.Close()
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/metrics"
+)
+
+// Stages of exchange pipeline with bounded buffers
+const (
+	stageWorkers   = "workers"   // received ticks waiting for worker pool
+	stageProcessed = "processed" // validated ticks waiting to be forwarded to collector
+	stageCollector = "collector" // ticks of all exchanges waiting for collector
+)
+
+var (
+	stageDropped = metrics.NewCounterVec("marketflow_pipeline_dropped_total",
+		"Ticks dropped by full stage buffer, reason is the policy: drop-oldest, drop-newest or conflate.", "stage", "exchange", "reason")
+	stageBlocked = metrics.NewCounterVec("marketflow_pipeline_blocked_seconds_total",
+		"Time senders waited for free slot in stage buffer with block policy.", "stage", "exchange")
+	stageBuffered = metrics.NewGaugeVec("marketflow_pipeline_buffered_ticks",
+		"Ticks in stage buffer, updated on every send.", "stage", "exchange")
+	stageCapacity = metrics.NewGaugeVec("marketflow_pipeline_buffer_capacity",
+		"Capacity of stage buffer.", "stage", "exchange")
+)
+
+// stageQueue is a bounded buffer between pipeline stages. When the buffer is full, its policy decides
+// whether sender waits or which tick is dropped, so a slow stage does not have to stall the stages before it.
+// Warning is logged when the buffer fills above watermark and again when it drains below half of it.
+type stageQueue struct {
+	stage    string
+	exchange string
+	policy   types.BackpressurePolicy
+
+	ch        chan *domain.PriceData
+	watermark int
+	above     atomic.Bool
+
+	// conflate policy only: ticks are sent to conflating goroutine, it keeps the latest tick
+	// of every exchange and symbol while buffer is full
+	in chan *domain.PriceData
+
+	closeOnce sync.Once
+
+	dropped  *metrics.Counter
+	blocked  *metrics.Counter
+	buffered *metrics.Gauge
+
+	logger logger.Logger
+}
+
+// newStageQueue creates buffer of stage, exchange is empty for buffers shared by exchanges
+func newStageQueue(stage string, exchange types.Exchange, policy types.BackpressurePolicy, size int, watermark float64, logger logger.Logger) *stageQueue {
+	size = max(size, 1)
+	exchangeLabel := string(exchange)
+	if exchangeLabel == "" {
+		exchangeLabel = "all"
+	}
+
+	q := &stageQueue{
+		stage:     stage,
+		exchange:  exchangeLabel,
+		policy:    policy,
+		ch:        make(chan *domain.PriceData, size),
+		watermark: max(int(float64(size)*watermark), 1),
+		dropped:   stageDropped.With(stage, exchangeLabel, string(policy)),
+		blocked:   stageBlocked.With(stage, exchangeLabel),
+		buffered:  stageBuffered.With(stage, exchangeLabel),
+		logger:    logger,
+	}
+	stageCapacity.With(stage, exchangeLabel).Set(float64(size))
+
+	if policy == types.PolicyConflate {
+		q.in = make(chan *domain.PriceData)
+	}
+
+	return q
+}
+
+// Start starts conflating goroutine of conflate policy, it stops when queue is closed or context is done
+func (q *stageQueue) Start(ctx context.Context) {
+	if q.policy == types.PolicyConflate {
+		go q.conflate(ctx)
+	}
+}
+
+// Send buffers tick applying policy if buffer is full.
+// Returns false if context is done before tick is buffered, dropped ticks are reported as sent.
+func (q *stageQueue) Send(ctx context.Context, p *domain.PriceData) bool {
+	defer q.checkWatermark()
+
+	switch q.policy {
+	case types.PolicyDropNewest:
+		select {
+		case q.ch <- p:
+		default:
+			q.dropped.Inc()
+		}
+		return true
+
+	case types.PolicyDropOldest:
+		for {
+			select {
+			case q.ch <- p:
+				return true
+			default:
+			}
+
+			// Making room, receiver may take the oldest tick first
+			select {
+			case <-q.ch:
+				q.dropped.Inc()
+			default:
+			}
+		}
+
+	case types.PolicyConflate:
+		select {
+		case q.in <- p:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+
+	default: // block
+		select {
+		case q.ch <- p:
+			return true
+		default:
+		}
+
+		start := time.Now()
+		defer func() { q.blocked.Add(time.Since(start).Seconds()) }()
+
+		select {
+		case q.ch <- p:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// conflate moves ticks to buffer, while buffer is full only the latest tick of every exchange and symbol waits
+func (q *stageQueue) conflate(ctx context.Context) {
+	defer close(q.ch)
+
+	type key struct {
+		exchange types.Exchange
+		symbol   types.Symbol
+	}
+	waiting := make(map[key]*domain.PriceData)
+	var order []key // keys of waiting ticks in order of arrival
+
+	// flush moves waiting ticks to buffer while it has room
+	flush := func() {
+		for len(order) > 0 {
+			select {
+			case q.ch <- waiting[order[0]]:
+				delete(waiting, order[0])
+				order = order[1:]
+			default:
+				return
+			}
+		}
+	}
+
+	in := q.in
+	for in != nil || len(order) > 0 {
+		var out chan *domain.PriceData
+		var next *domain.PriceData
+		if len(order) > 0 {
+			out, next = q.ch, waiting[order[0]]
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case p, ok := <-in:
+			if !ok {
+				in = nil // sending waiting ticks before closing buffer
+				continue
+			}
+
+			// select may pick input while buffer has room, tick waits or is conflated only if buffer is full
+			flush()
+			if len(order) == 0 {
+				select {
+				case q.ch <- p:
+					continue
+				default:
+				}
+			}
+
+			k := key{p.Exchange, p.Symbol}
+			if _, ok := waiting[k]; ok {
+				q.dropped.Inc()
+			} else {
+				order = append(order, k)
+			}
+			waiting[k] = p
+
+		case out <- next:
+			delete(waiting, order[0])
+			order = order[1:]
+		}
+	}
+}
+
+// checkWatermark logs when buffer fills above watermark and when it drains below half of it
+func (q *stageQueue) checkWatermark() {
+	n := len(q.ch)
+	q.buffered.Set(float64(n))
+
+	switch {
+	case n >= q.watermark:
+		if !q.above.Swap(true) {
+			q.logger.Warn(context.Background(), "stage buffer is filled above watermark",
+				"stage", q.stage, "exchange", q.exchange, "buffered", n, "capacity", cap(q.ch), "policy", q.policy)
+		}
+	case n <= q.watermark/2:
+		if q.above.Swap(false) {
+			q.logger.Info(context.Background(), "stage buffer drained below watermark",
+				"stage", q.stage, "exchange", q.exchange, "buffered", n, "capacity", cap(q.ch))
+		}
+	}
+}
+
+// Out returns buffer read by the next stage, it is closed after queue is closed and buffered ticks are read
+func (q *stageQueue) Out() <-chan *domain.PriceData {
+	return q.ch
+}
+
+// Close signals that no more ticks will be sent, must be called after all senders are done
+func (q *stageQueue) Close() {
+	q.closeOnce.Do(func() {
+		if q.policy == types.PolicyConflate {
+			close(q.in)
+			return
+		}
+		close(q.ch)
+	})
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+// Tests are meant to be run with -race, senders and receivers run concurrently
+
+const (
+	senders        = 4
+	ticksPerSender = 200
+)
+
+func newTestQueue(t *testing.T, policy types.BackpressurePolicy, size int) *stageQueue {
+	t.Helper()
+	log := logger.InitLogger(context.Background(), "error")
+	// exchange label keeps metrics of tests apart, counters still add up over -count runs
+	q := newStageQueue(stageCollector, types.Exchange("test-"+t.Name()), policy, size, 0.8, log)
+	q.Start(context.Background())
+	return q
+}
+
+// sendConcurrently sends ticks of every sender in order, prices count up from 1 per sender symbol
+func sendConcurrently(q *stageQueue) {
+	var wg sync.WaitGroup
+	for sender := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			symbol := types.Symbol(rune('A' + sender))
+			for i := 1; i <= ticksPerSender; i++ {
+				q.Send(context.Background(), &domain.PriceData{Exchange: types.Exchange1, Symbol: symbol, Price: float64(i)})
+			}
+		}()
+	}
+	wg.Wait()
+	q.Close()
+}
+
+// receiveAll reads queue until it is closed, checking that ticks of every symbol keep their order
+func receiveAll(t *testing.T, q *stageQueue, slow bool) map[types.Symbol][]float64 {
+	t.Helper()
+	got := make(map[types.Symbol][]float64)
+	for p := range q.Out() {
+		prices := got[p.Symbol]
+		if len(prices) > 0 && prices[len(prices)-1] >= p.Price {
+			t.Fatalf("tick %g of %s received after %g", p.Price, p.Symbol, prices[len(prices)-1])
+		}
+		got[p.Symbol] = append(prices, p.Price)
+		if slow {
+			time.Sleep(10 * time.Microsecond)
+		}
+	}
+	return got
+}
+
+func TestStageQueueBlock(t *testing.T) {
+	q := newTestQueue(t, types.PolicyBlock, 8)
+	dropped, blocked := q.dropped.Value(), q.blocked.Value()
+	go sendConcurrently(q)
+
+	got := receiveAll(t, q, true)
+	lost := q.dropped.Value() - dropped
+	for symbol, prices := range got {
+		if len(prices) != ticksPerSender {
+			t.Fatalf("%s: received %d of %d ticks, block policy must not drop", symbol, len(prices), ticksPerSender)
+		}
+	}
+	if len(got) != senders || lost != 0 {
+		t.Fatalf("received %d symbols, dropped %g", len(got), lost)
+	}
+	if q.blocked.Value() == blocked {
+		t.Fatal("senders waiting for slow receiver must be counted as blocked")
+	}
+}
+
+func TestStageQueueBlockCancelled(t *testing.T) {
+	q := newTestQueue(t, types.PolicyBlock, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if !q.Send(ctx, &domain.PriceData{Price: 1}) {
+		t.Fatal("send to buffer with room must succeed")
+	}
+	done := make(chan bool)
+	go func() { done <- q.Send(ctx, &domain.PriceData{Price: 2}) }()
+
+	select {
+	case <-done:
+		t.Fatal("send to full buffer must block")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	if <-done {
+		t.Fatal("cancelled send must report tick was not buffered")
+	}
+}
+
+func TestStageQueueDropNewest(t *testing.T) {
+	q := newTestQueue(t, types.PolicyDropNewest, 8)
+	dropped := q.dropped.Value()
+	go sendConcurrently(q)
+
+	got := receiveAll(t, q, true)
+	lost := q.dropped.Value() - dropped
+	// the first ticks fill the empty buffer, so the first tick of some sender is never dropped
+	received, first := 0, false
+	for _, prices := range got {
+		first = first || prices[0] == 1
+		received += len(prices)
+	}
+	if !first {
+		t.Fatal("no first tick received, the newest ticks must be dropped")
+	}
+	if received+int(lost) != senders*ticksPerSender || lost == 0 {
+		t.Fatalf("received %d and dropped %g of %d ticks", received, lost, senders*ticksPerSender)
+	}
+}
+
+func TestStageQueueDropOldest(t *testing.T) {
+	q := newTestQueue(t, types.PolicyDropOldest, 8)
+	dropped := q.dropped.Value()
+	go sendConcurrently(q)
+
+	got := receiveAll(t, q, true)
+	lost := q.dropped.Value() - dropped
+	// nothing is sent after the last tick, so the last tick of some sender is never dropped
+	received, last := 0, false
+	for _, prices := range got {
+		last = last || prices[len(prices)-1] == ticksPerSender
+		received += len(prices)
+	}
+	if !last {
+		t.Fatal("no last tick received, the oldest ticks must be dropped")
+	}
+	if received+int(lost) != senders*ticksPerSender || lost == 0 {
+		t.Fatalf("received %d and dropped %g of %d ticks", received, lost, senders*ticksPerSender)
+	}
+}
+
+func TestStageQueueConflate(t *testing.T) {
+	q := newTestQueue(t, types.PolicyConflate, 1)
+	dropped := q.dropped.Value()
+	go sendConcurrently(q)
+
+	got := receiveAll(t, q, true)
+	lost := q.dropped.Value() - dropped
+	received := 0
+	for symbol, prices := range got {
+		// waiting tick is replaced by newer ones, the latest tick of every symbol is delivered
+		if prices[len(prices)-1] != ticksPerSender {
+			t.Fatalf("%s: last received tick %g, the latest tick must be delivered", symbol, prices[len(prices)-1])
+		}
+		received += len(prices)
+	}
+	if len(got) != senders || received+int(lost) != senders*ticksPerSender || lost == 0 {
+		t.Fatalf("received %d symbols, %d and dropped %g of %d ticks", len(got), received, lost, senders*ticksPerSender)
+	}
+}
+
+func TestStageQueueConflateWithRoom(t *testing.T) {
+	q := newTestQueue(t, types.PolicyConflate, 4)
+	dropped := q.dropped.Value()
+
+	// ticks of the same symbol are conflated only while buffer is full
+	for i := 1; i <= 2; i++ {
+		q.Send(context.Background(), &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: float64(i)})
+	}
+	q.Close()
+
+	got := receiveAll(t, q, false)
+	if prices := got[types.BTCUSDT]; len(prices) != 2 || q.dropped.Value() != dropped {
+		t.Fatalf("received %v and dropped %g, buffer with room must not conflate", prices, q.dropped.Value()-dropped)
+	}
+}
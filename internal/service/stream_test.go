@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// fakeStream keeps entries pending for the consumer until they are acknowledged, publishes fail while failures are left
+type fakeStream struct {
+	ports.TickStream
+	mu           sync.Mutex
+	pending      []domain.StreamTick
+	batchSize    int
+	pendingReads int
+	failures     int
+	published    int
+}
+
+func (s *fakeStream) EnsureGroup(ctx context.Context, exchange types.Exchange, group string) error {
+	return nil
+}
+
+// Read of pending entries returns the oldest batch not acknowledged yet, like reading from id "0"
+func (s *fakeStream) Read(ctx context.Context, group, consumer string, exchanges []types.Exchange, pending bool) ([]domain.StreamTick, error) {
+	if !pending {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pendingReads++
+	return append([]domain.StreamTick(nil), s.pending[:min(s.batchSize, len(s.pending))]...), nil
+}
+
+func (s *fakeStream) Claim(ctx context.Context, exchange types.Exchange, group, consumer string, minIdle time.Duration) ([]domain.StreamTick, error) {
+	return nil, nil
+}
+
+func (s *fakeStream) Ack(ctx context.Context, exchange types.Exchange, group string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		acked[id] = true
+	}
+	left := s.pending[:0]
+	for _, tick := range s.pending {
+		if !acked[tick.ID] {
+			left = append(left, tick)
+		}
+	}
+	s.pending = left
+	return nil
+}
+
+func (s *fakeStream) Publish(ctx context.Context, p *domain.PriceData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("connection refused")
+	}
+	s.published++
+	return nil
+}
+
+func (s *fakeStream) left() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending), s.pendingReads
+}
+
+// storeCache counts stored ticks, stubs other methods by embedded nil interface
+type storeCache struct {
+	ports.Cache
+	mu     sync.Mutex
+	stored int
+	err    error
+	delay  time.Duration // time of writing every batch
+}
+
+func (c *storeCache) StoreBatch(ctx context.Context, latest, history []*domain.PriceData, latestTTL time.Duration) error {
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.stored += len(history)
+	return nil
+}
+
+func pendingTicks(n int) []domain.StreamTick {
+	ticks := make([]domain.StreamTick, n)
+	for i := range ticks {
+		ticks[i] = domain.StreamTick{
+			ID:       fmt.Sprintf("%d-0", i+1),
+			Exchange: types.Exchange1,
+			Price:    &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: float64(i + 1)},
+		}
+	}
+	return ticks
+}
+
+func startConsumer(t *testing.T, stream *fakeStream, cache *storeCache) *StreamConsumer {
+	t.Helper()
+	log := logger.InitLogger(context.Background(), "error")
+	cfg := config.Stream{Group: "collectors", Consumer: "test", BatchSize: int64(stream.batchSize), ClaimIdle: time.Minute}
+	c := NewStreamConsumer(stream, cache, nil, cfg, config.Collector{}, log)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Cancel() })
+	return c
+}
+
+func TestStreamConsumerDrainsPending(t *testing.T) {
+	stream := &fakeStream{pending: pendingTicks(250), batchSize: 100}
+	cache := &storeCache{}
+	startConsumer(t, stream, cache)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for left, _ := stream.left(); left > 0; left, _ = stream.left() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of 250 pending entries left, pending entries must be read until drained", left)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.stored != 250 {
+		t.Fatalf("stored %d of 250 pending ticks", cache.stored)
+	}
+}
+
+func TestStreamConsumerKeepsFailedPending(t *testing.T) {
+	stream := &fakeStream{pending: pendingTicks(250), batchSize: 100}
+	c := startConsumer(t, stream, &storeCache{err: errors.New("connection refused")})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, reads := stream.left(); reads == 0; _, reads = stream.left() {
+		if time.Now().After(deadline) {
+			t.Fatal("pending entries were not read")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := c.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	// failed batch is read again on every read of pending entries, so draining stops at the first failure
+	if left, reads := stream.left(); left != 250 || reads != 1 {
+		t.Fatalf("%d entries left after %d reads, failed batch must stay pending and stop draining", left, reads)
+	}
+}
+
+func TestStreamPublisherRetries(t *testing.T) {
+	log := logger.InitLogger(context.Background(), "error")
+	for name, tc := range map[string]struct {
+		failures  int
+		published int
+		dropped   float64
+	}{
+		"retried":  {failures: publishAttempts - 1, published: 1},
+		"dropped":  {failures: publishAttempts, dropped: 1},
+		"no retry": {failures: 0, published: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// exchange label keeps metrics of tests apart, counters still add up over -count runs
+			exchange := types.Exchange("test-" + t.Name())
+			dropped := streamDropped.With(string(exchange))
+			before := dropped.Value()
+
+			stream := &fakeStream{failures: tc.failures}
+			p := NewStreamPublisher(stream, log)
+			prices := make(chan *domain.PriceData)
+			p.Start(context.Background(), prices)
+			prices <- &domain.PriceData{Exchange: exchange, Symbol: types.BTCUSDT, Price: 1}
+			close(prices)
+			<-p.doneChan
+
+			if stream.published != tc.published || stream.failures != 0 {
+				t.Fatalf("published %d with %d failures left, want %d published", stream.published, stream.failures, tc.published)
+			}
+			if got := dropped.Value() - before; got != tc.dropped {
+				t.Fatalf("dropped %g ticks, want %g", got, tc.dropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// StreamConsumer reads prices from Redis Streams as a member of consumer group,
+// stores them to the cache and acknowledges entries only after they were stored.
+// Entries left pending by crashed consumers are reclaimed, so processing is at-least-once.
+type StreamConsumer struct {
+	stream    ports.TickStream
+	collector *Collector
+	exchanges []types.Exchange
+	consumer  string
+
+	cancelFunc context.CancelFunc
+	doneChan   chan struct{}
+
+	cfg    config.Stream
+	logger logger.Logger
+}
+
+func NewStreamConsumer(stream ports.TickStream, cache ports.Cache, ticks ports.TickRecorder, cfg config.Stream, collectorCfg config.Collector, logger logger.Logger) *StreamConsumer {
+	return &StreamConsumer{
+		stream:    stream,
+		collector: NewCollector(cache, ticks, collectorCfg, logger),
+		exchanges: types.ValidExchanges,
+		doneChan:  make(chan struct{}),
+		cfg:       cfg,
+		logger:    logger,
+	}
+}
+
+// Start creates consumer groups and starts consuming streams
+func (c *StreamConsumer) Start(ctx context.Context) error {
+	c.consumer = c.cfg.Consumer
+	if c.consumer == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to resolve consumer name: %w", err)
+		}
+		c.consumer = hostname
+	}
+
+	for _, exchange := range c.exchanges {
+		if err := c.stream.EnsureGroup(ctx, exchange, c.cfg.Group); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancelFunc = cancel
+
+	go c.run(ctx)
+
+	return nil
+}
+
+func (c *StreamConsumer) run(ctx context.Context) {
+	defer close(c.doneChan)
+
+	const fn = "streamConsumer.run"
+	log := c.logger.GetSlogLogger().With("fn", fn, "group", c.cfg.Group, "consumer", c.consumer)
+	log.Info("stream consumer started")
+
+	c.drainPending(ctx)
+	c.claim(ctx)
+
+	claimTicker := time.NewTicker(c.cfg.ClaimIdle)
+	defer claimTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("shutting down stream consumer")
+			return
+		case <-claimTicker.C:
+			c.claim(ctx)
+		default:
+		}
+
+		ticks, err := c.stream.Read(ctx, c.cfg.Group, c.consumer, c.exchanges, false)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			log.Error("failed to read streams", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		c.process(ctx, ticks)
+	}
+}
+
+// drainPending processes entries delivered to us before restart but never acknowledged.
+// They are read batch by batch until none are left; entries of failed batch stay pending and are reclaimed later.
+func (c *StreamConsumer) drainPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		ticks, err := c.stream.Read(ctx, c.cfg.Group, c.consumer, c.exchanges, true)
+		if err != nil {
+			c.logger.Error(ctx, "failed to read pending entries", "error", err)
+			return
+		}
+		if len(ticks) == 0 || !c.process(ctx, ticks) {
+			return
+		}
+	}
+}
+
+// claim takes over entries which other consumers failed to acknowledge in time
+func (c *StreamConsumer) claim(ctx context.Context) {
+	for _, exchange := range c.exchanges {
+		ticks, err := c.stream.Claim(ctx, exchange, c.cfg.Group, c.consumer, c.cfg.ClaimIdle)
+		if err != nil {
+			c.logger.Error(ctx, "failed to claim pending entries", "exchange", exchange, "error", err)
+			continue
+		}
+
+		if len(ticks) > 0 {
+			c.logger.Info(ctx, "claimed pending entries", "exchange", exchange, "count", len(ticks))
+		}
+		c.process(ctx, ticks)
+	}
+}
+
+// process stores ticks read at once as single batch and acknowledges them. If batch fails, ticks stay pending and will be reclaimed.
+// Reports whether all ticks were stored and acknowledged.
+func (c *StreamConsumer) process(ctx context.Context, ticks []domain.StreamTick) bool {
+	if len(ticks) == 0 {
+		return true
+	}
+
+	acks := make(map[types.Exchange][]string)
+	batch := make([]*domain.PriceData, 0, len(ticks))
+	for _, tick := range ticks {
+		if tick.Price == nil {
+			c.logger.Warn(ctx, "dropping corrupted stream entry", "exchange", tick.Exchange, "id", tick.ID)
+		} else {
+			batch = append(batch, tick.Price)
+		}
+		acks[tick.Exchange] = append(acks[tick.Exchange], tick.ID)
+	}
+
+	if err := c.collector.storeBatch(ctx, batch); err != nil {
+		c.logger.Error(ctx, "failed to store prices", "ticks", len(batch), "error", err)
+		return false
+	}
+
+	acked := true
+	for exchange, ids := range acks {
+		if err := c.stream.Ack(ctx, exchange, c.cfg.Group, ids...); err != nil {
+			c.logger.Error(ctx, "failed to ack entries", "exchange", exchange, "error", err)
+			acked = false
+		}
+	}
+	return acked
+}
+
+// Cancel gracefully shutdowns consumer
+func (c *StreamConsumer) Cancel() error {
+	if c.cancelFunc != nil {
+		c.cancelFunc()
+
+		select {
+		case <-c.doneChan:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("timeout waiting for stream consumer to stop")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+	"marketflow/pkg/metrics"
+)
+
+const (
+	// publishAttempts is how many times tick is appended to stream before it is dropped
+	publishAttempts = 3
+	// publishBackoff is delay before the second attempt, it doubles with every attempt
+	publishBackoff = 100 * time.Millisecond
+)
+
+var streamDropped = metrics.NewCounterVec("marketflow_stream_dropped_ticks_total",
+	"Ticks dropped because appending them to Redis Stream failed after retries.", "exchange")
+
+// StreamPublisher is a Collector that appends processed prices to durable Redis Streams
+// instead of writing them to the cache directly. Prices are stored by StreamConsumer.
+type StreamPublisher struct {
+	stream ports.TickStream
+
+	cancelFunc context.CancelFunc
+	doneChan   chan struct{}
+
+	logger logger.Logger
+}
+
+func NewStreamPublisher(stream ports.TickStream, logger logger.Logger) *StreamPublisher {
+	return &StreamPublisher{
+		stream:   stream,
+		doneChan: make(chan struct{}),
+		logger:   logger,
+	}
+}
+
+// Start starts to listens incoming channel
+func (p *StreamPublisher) Start(ctx context.Context, processedPrices <-chan *domain.PriceData) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancelFunc = cancel
+
+	go p.run(ctx, processedPrices)
+}
+
+func (p *StreamPublisher) run(ctx context.Context, processedPrices <-chan *domain.PriceData) {
+	defer close(p.doneChan)
+
+	const fn = "streamPublisher.run"
+	log := p.logger.GetSlogLogger().With("fn", fn)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("shutting down stream publisher")
+			return
+
+		case price, ok := <-processedPrices:
+			if !ok {
+				log.Info("stream publisher's input channel closed")
+				return
+			}
+
+			if err := p.publish(ctx, price); err != nil {
+				dropped := streamDropped.With(string(price.Exchange))
+				dropped.Inc()
+				log.Error("failed to publish price, tick is dropped", "exchange", price.Exchange, "dropped", dropped.Value(), "error", err)
+			}
+		}
+	}
+}
+
+// publish appends tick to stream, failed attempts are retried with backoff until attempts run out or context is done
+func (p *StreamPublisher) publish(ctx context.Context, price *domain.PriceData) error {
+	backoff := publishBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.stream.Publish(ctx, price)
+		if err == nil || attempt == publishAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Done is closed when publisher has stopped
+func (p *StreamPublisher) Done() <-chan struct{} {
+	return p.doneChan
+}
+
+// Cancel gracefully shutdowns publisher
+func (p *StreamPublisher) Cancel() error {
+	if p.cancelFunc != nil {
+		p.cancelFunc()
+
+		select {
+		case <-p.doneChan:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("timeout waiting for stream publisher to stop")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/ports"
+	"marketflow/pkg/logger"
+)
+
+// partitionsAhead is how many days after the current one have partitions created in advance,
+// so ticks never land in default partition around midnight
+const partitionsAhead = 1
+
+// TickWriter persists ticks recorded by collector to Postgres, batching them into COPY statements.
+// Recording never blocks collector: when Postgres does not keep up, buffer fills and new ticks are dropped and counted.
+// Failed batch is kept and retried every flush interval up to max retries, then it is split in halves
+// to write ticks that can be written, and ticks that still fail are discarded and counted.
+type TickWriter struct {
+	repo ports.TickRepository
+	in   chan *domain.PriceData
+
+	// days with ensured partitions, forgotten when partitions are dropped
+	partitionsMu sync.Mutex
+	partitions   map[time.Time]struct{}
+
+	written   atomic.Int64
+	dropped   atomic.Int64
+	discarded atomic.Int64
+	failing   atomic.Bool  // last batch was not written
+	attempts  atomic.Int32 // failed attempts of current batch
+
+	cancelFunc context.CancelFunc
+	doneChan   chan struct{}
+
+	cfg    config.Ticks
+	logger logger.Logger
+}
+
+func NewTickWriter(repo ports.TickRepository, cfg config.Ticks, logger logger.Logger) *TickWriter {
+	return &TickWriter{
+		repo:       repo,
+		in:         make(chan *domain.PriceData, cfg.BufferSize),
+		partitions: make(map[time.Time]struct{}),
+		doneChan:   make(chan struct{}),
+		cfg:        cfg,
+		logger:     logger,
+	}
+}
+
+// Record queues tick to be written, tick is dropped if buffer is full
+func (w *TickWriter) Record(p *domain.PriceData) {
+	select {
+	case w.in <- p:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Start starts writing recorded ticks
+func (w *TickWriter) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancelFunc = cancel
+
+	go w.run(ctx)
+}
+
+func (w *TickWriter) run(ctx context.Context) {
+	defer close(w.doneChan)
+
+	const fn = "tickWriter.run"
+	log := w.logger.GetSlogLogger().With("fn", fn)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.PriceData, 0, w.cfg.BatchSize)
+	var reportedDrops int64
+
+	for {
+		// Full batch is not extended until it is written, buffer takes new ticks meanwhile
+		in := w.in
+		if len(batch) >= w.cfg.BatchSize {
+			in = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// Collectors are stopped before writer, writing what is left
+			for len(w.in) > 0 {
+				batch = append(batch, <-w.in)
+			}
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			batch = w.flush(flushCtx, batch)
+			cancel()
+
+			log.Info("tick writer stopped", "written", w.written.Load(), "dropped", w.dropped.Load(), "discarded", w.discarded.Load(), "lost", len(batch))
+			return
+
+		case p := <-in:
+			batch = append(batch, p)
+			if len(batch) >= w.cfg.BatchSize {
+				batch = w.flush(ctx, batch)
+			}
+
+		case <-ticker.C:
+			batch = w.flush(ctx, batch)
+			if len(batch) > 0 && int(w.attempts.Load()) > w.cfg.MaxRetries {
+				batch = w.split(ctx, batch)
+			}
+
+			if dropped := w.dropped.Load(); dropped > reportedDrops {
+				log.Warn("tick buffer is full, ticks are dropped", "dropped", dropped-reportedDrops, "total_dropped", dropped)
+				reportedDrops = dropped
+			}
+		}
+	}
+}
+
+// flush writes batch, returns empty batch on success and the same batch on failure
+func (w *TickWriter) flush(ctx context.Context, batch []*domain.PriceData) []*domain.PriceData {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	const fn = "tickWriter.flush"
+	log := w.logger.GetSlogLogger().With("fn", fn)
+
+	if err := w.write(ctx, batch); err != nil {
+		log.Error("failed to write ticks", "ticks", len(batch), "attempt", w.attempts.Add(1), "error", err)
+		w.failing.Store(true)
+		return batch
+	}
+	w.attempts.Store(0)
+	w.failing.Store(false)
+
+	clear(batch) // releasing written ticks
+	return batch[:0]
+}
+
+// split writes halves of batch that failed max retries times, halves that fail are split further
+// until failing ticks are found and discarded. If both halves fail, failure is not caused by ticks
+// and the whole batch is discarded, so unavailable Postgres costs a couple of attempts only.
+// Returns empty batch, new ticks are taken again.
+func (w *TickWriter) split(ctx context.Context, batch []*domain.PriceData) []*domain.PriceData {
+	const fn = "tickWriter.split"
+	log := w.logger.GetSlogLogger().With("fn", fn)
+
+	var failed []*domain.PriceData
+	var bisect func(part []*domain.PriceData)
+	bisect = func(part []*domain.PriceData) {
+		if len(part) == 1 {
+			failed = append(failed, part...)
+			return
+		}
+
+		left, right := part[:len(part)/2], part[len(part)/2:]
+		leftErr, rightErr := w.write(ctx, left), w.write(ctx, right)
+		switch {
+		case leftErr != nil && rightErr != nil:
+			failed = append(failed, part...)
+		case leftErr != nil:
+			bisect(left)
+		case rightErr != nil:
+			bisect(right)
+		}
+	}
+	bisect(batch)
+
+	if len(failed) > 0 {
+		w.discarded.Add(int64(len(failed)))
+		first, last := failed[0], failed[len(failed)-1]
+		log.Error("discarded ticks failed to be written", "ticks", len(failed), "retries", w.cfg.MaxRetries,
+			"first_exchange", first.Exchange, "first_symbol", first.Symbol, "first_timestamp", first.Timestamp,
+			"last_exchange", last.Exchange, "last_symbol", last.Symbol, "last_timestamp", last.Timestamp)
+	}
+	// writer stays failing until the next batch is written
+	w.attempts.Store(0)
+
+	clear(batch)
+	return batch[:0]
+}
+
+// write ensures partitions of batch and copies it
+func (w *TickWriter) write(ctx context.Context, batch []*domain.PriceData) error {
+	if err := w.ensurePartitions(ctx, batch); err != nil {
+		return fmt.Errorf("failed to ensure tick partitions: %w", err)
+	}
+	if err := w.repo.CopyTicks(ctx, batch); err != nil {
+		return err
+	}
+	w.written.Add(int64(len(batch)))
+	return nil
+}
+
+// ensurePartitions creates partitions of days of batch ticks, so rows are never routed to default partition.
+// Partition of a day with rows in default partition can not be created.
+func (w *TickWriter) ensurePartitions(ctx context.Context, batch []*domain.PriceData) error {
+	w.partitionsMu.Lock()
+	defer w.partitionsMu.Unlock()
+
+	for _, p := range batch {
+		day := p.Timestamp.UTC().Truncate(24 * time.Hour)
+		if _, ok := w.partitions[day]; ok {
+			continue
+		}
+
+		if err := w.repo.EnsureTickPartitions(ctx, day, day.AddDate(0, 0, partitionsAhead)); err != nil {
+			return err
+		}
+		for i := 0; i <= partitionsAhead; i++ {
+			w.partitions[day.AddDate(0, 0, i)] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// forgetPartitionsBefore removes days before the given one from ensured partitions
+func (w *TickWriter) forgetPartitionsBefore(before time.Time) {
+	before = before.UTC().Truncate(24 * time.Hour)
+
+	w.partitionsMu.Lock()
+	defer w.partitionsMu.Unlock()
+
+	for day := range w.partitions {
+		if day.Before(before) {
+			delete(w.partitions, day)
+		}
+	}
+}
+
+// MaintainPartitions creates partitions of coming days and drops partitions older than retention
+func (w *TickWriter) MaintainPartitions(ctx context.Context) error {
+	const fn = "TickWriter.MaintainPartitions"
+	log := w.logger.GetSlogLogger().With("fn", fn)
+
+	now := time.Now()
+	err := w.repo.EnsureTickPartitions(ctx, now, now.AddDate(0, 0, partitionsAhead))
+
+	if w.cfg.Retention > 0 {
+		before := now.Add(-w.cfg.Retention)
+		dropped, dropErr := w.repo.DropTickPartitionsBefore(ctx, before)
+		if len(dropped) > 0 {
+			log.InfoContext(ctx, "dropped expired tick partitions", "partitions", dropped)
+		}
+		// partitions may be dropped by another instance, expired days are forgotten in any case
+		w.forgetPartitionsBefore(before)
+		err = errors.Join(err, dropErr)
+	}
+
+	return err
+}
+
+// Cancel writes buffered ticks and stops writer
+func (w *TickWriter) Cancel() error {
+	if w.cancelFunc != nil {
+		w.cancelFunc()
+
+		select {
+		case <-w.doneChan:
+			return nil
+		case <-time.After(10 * time.Second):
+			return errors.New("timeout waiting for tick writer to stop")
+		}
+	}
+	return nil
+}
+
+func (w *TickWriter) Name() string {
+	return "tick_writer"
+}
+
+// Health reports writer unhealthy while batches fail to be written
+func (w *TickWriter) Health(ctx context.Context) (bool, error) {
+	return !w.failing.Load(), nil
+}
+
+// TickWriterStats returns counters of written and lost ticks since start
+func (w *TickWriter) TickWriterStats() domain.TickWriterStats {
+	return domain.TickWriterStats{
+		Written:   w.written.Load(),
+		Dropped:   w.dropped.Load(),
+		Discarded: w.discarded.Load(),
+		Buffered:  len(w.in),
+		Failing:   w.failing.Load(),
+		Attempts:  int(w.attempts.Load()),
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
+	"marketflow/pkg/logger"
+)
+
+// fakeTickRepo rejects batches containing a poison price, or every batch while down
+type fakeTickRepo struct {
+	mu      sync.Mutex
+	down    bool
+	poison  float64
+	copied  []float64
+	copies  int
+	dropped time.Time
+}
+
+func (r *fakeTickRepo) CopyTicks(ctx context.Context, ticks []*domain.PriceData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.copies++
+	if r.down {
+		return errors.New("connection refused")
+	}
+	for _, p := range ticks {
+		if p.Price == r.poison {
+			return errors.New("invalid tick")
+		}
+	}
+	for _, p := range ticks {
+		r.copied = append(r.copied, p.Price)
+	}
+	return nil
+}
+
+func (r *fakeTickRepo) EnsureTickPartitions(ctx context.Context, from, to time.Time) error {
+	return nil
+}
+
+func (r *fakeTickRepo) DropTickPartitionsBefore(ctx context.Context, before time.Time) ([]string, error) {
+	r.dropped = before
+	return nil, nil
+}
+
+func testTicks(prices ...float64) []*domain.PriceData {
+	ticks := make([]*domain.PriceData, len(prices))
+	for i, price := range prices {
+		ticks[i] = &domain.PriceData{Exchange: types.Exchange1, Symbol: types.BTCUSDT, Price: price, Timestamp: time.Now()}
+	}
+	return ticks
+}
+
+func newTestTickWriter(repo *fakeTickRepo, maxRetries int) *TickWriter {
+	log := logger.InitLogger(context.Background(), "error")
+	return NewTickWriter(repo, config.Ticks{BatchSize: 8, BufferSize: 16, FlushInterval: time.Hour, MaxRetries: maxRetries, Retention: 24 * time.Hour}, log)
+}
+
+func TestTickWriterSplitsFailingBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeTickRepo{poison: 5}
+	w := newTestTickWriter(repo, 2)
+
+	batch := testTicks(1, 2, 3, 4, 5, 6, 7, 8)
+	for range 3 {
+		batch = w.flush(ctx, batch)
+	}
+	if len(batch) != 8 || !w.TickWriterStats().Failing || w.TickWriterStats().Attempts != 3 {
+		t.Fatalf("batch must be kept while retried, got %d ticks, stats %+v", len(batch), w.TickWriterStats())
+	}
+
+	batch = w.split(ctx, batch)
+	if len(batch) != 0 {
+		t.Fatalf("split must return empty batch, got %d ticks", len(batch))
+	}
+	slices.Sort(repo.copied)
+	if !slices.Equal(repo.copied, []float64{1, 2, 3, 4, 6, 7, 8}) {
+		t.Fatalf("only poison tick must be discarded, written %v", repo.copied)
+	}
+	stats := w.TickWriterStats()
+	if stats.Written != 7 || stats.Discarded != 1 || stats.Attempts != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// writer recovers with the next batch
+	if batch = w.flush(ctx, testTicks(9)); len(batch) != 0 || w.TickWriterStats().Failing {
+		t.Fatalf("next batch must be written, stats %+v", w.TickWriterStats())
+	}
+}
+
+func TestTickWriterDiscardsBatchWhileDown(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeTickRepo{down: true}
+	w := newTestTickWriter(repo, 0)
+
+	batch := w.flush(ctx, testTicks(1, 2, 3, 4, 5, 6, 7, 8))
+	copies := repo.copies
+	w.split(ctx, batch)
+
+	// both halves fail, so the batch is not bisected down to single ticks
+	if repo.copies-copies != 2 {
+		t.Fatalf("expected 2 attempts of halves, got %d", repo.copies-copies)
+	}
+	if stats := w.TickWriterStats(); stats.Discarded != 8 || stats.Written != 0 || !stats.Failing {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestTickWriterRetriesUntilMaxRetries(t *testing.T) {
+	repo := &fakeTickRepo{down: true}
+	w := newTestTickWriter(repo, 1)
+	w.cfg.FlushInterval = 5 * time.Millisecond
+
+	w.Start(context.Background())
+	for _, p := range testTicks(1, 2, 3) {
+		w.Record(p)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for w.TickWriterStats().Discarded != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("batch was not discarded after retries, stats %+v", w.TickWriterStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// new ticks are taken and written once Postgres is back
+	repo.mu.Lock()
+	repo.down = false
+	repo.mu.Unlock()
+	w.Record(testTicks(4)[0])
+	if err := w.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := w.TickWriterStats(); stats.Written != 1 || stats.Failing {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestTickWriterForgetsDroppedPartitions(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeTickRepo{}
+	w := newTestTickWriter(repo, 0)
+
+	old := testTicks(1)
+	old[0].Timestamp = time.Now().AddDate(0, 0, -3)
+	if err := w.ensurePartitions(ctx, append(old, testTicks(2)...)); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.partitions) != 4 {
+		t.Fatalf("expected partitions of 2 days and days ahead, got %d", len(w.partitions))
+	}
+
+	if err := w.MaintainPartitions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	before := repo.dropped.UTC().Truncate(24 * time.Hour)
+	for day := range w.partitions {
+		if day.Before(before) {
+			t.Fatalf("partition of %v was dropped but is still known", day)
+		}
+	}
+	if len(w.partitions) != 2 {
+		t.Fatalf("expected partitions of today and tomorrow, got %d", len(w.partitions))
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"sync"
 
+	"marketflow/config"
 	"marketflow/internal/domain"
+	"marketflow/internal/domain/types"
 	"marketflow/pkg/logger"
 )
 
@@ -14,25 +16,30 @@ type WorkerPool struct {
 	name string
 
 	workerCount int
-	inputChan   chan *domain.PriceData
-	outputChan  chan *domain.PriceData
+	input       *stageQueue
+	output      *stageQueue
 	wg          sync.WaitGroup
 
 	log logger.Logger
 }
 
-func NewWorkerPool(name string, workerCount int, log logger.Logger) *WorkerPool {
+// NewWorkerPool creates pool with input and output buffers of exchange, buffers apply backpressure policies when full
+func NewWorkerPool(name string, workerCount int, cfg config.Backpressure, log logger.Logger) *WorkerPool {
+	exchange := types.Exchange(name)
 	return &WorkerPool{
 		name:        name,
 		workerCount: workerCount,
-		inputChan:   make(chan *domain.PriceData, 100),
-		outputChan:  make(chan *domain.PriceData, 100),
+		input:       newStageQueue(stageWorkers, exchange, types.BackpressurePolicy(cfg.WorkersPolicy), cfg.WorkersSize, cfg.Watermark, log),
+		output:      newStageQueue(stageProcessed, exchange, types.BackpressurePolicy(cfg.ProcessedPolicy), cfg.ProcessedSize, cfg.Watermark, log),
 		log:         log,
 	}
 }
 
 // Start launches all workers in the pool
 func (wp *WorkerPool) Start(ctx context.Context) {
+	wp.input.Start(ctx)
+	wp.output.Start(ctx)
+
 	for i := 0; i < wp.workerCount; i++ {
 		wp.wg.Add(1)
 		go wp.worker(ctx)
@@ -42,7 +49,7 @@ func (wp *WorkerPool) Start(ctx context.Context) {
 
 	go func() {
 		wp.wg.Wait()
-		close(wp.outputChan)
+		wp.output.Close()
 	}()
 }
 
@@ -57,7 +64,7 @@ func (wp *WorkerPool) worker(ctx context.Context) {
 		case <-ctx.Done():
 			log.Info("worker stopped by context")
 			return
-		case priceData, ok := <-wp.inputChan:
+		case priceData, ok := <-wp.input.Out():
 			if !ok {
 				return
 			}
@@ -67,7 +74,10 @@ func (wp *WorkerPool) worker(ctx context.Context) {
 				continue
 			}
 
-			wp.outputChan <- processed
+			if !wp.output.Send(ctx, processed) {
+				log.Info("worker stopped by context")
+				return
+			}
 		}
 	}
 }
@@ -86,28 +96,19 @@ func (wp *WorkerPool) processPriceData(data *domain.PriceData) (*domain.PriceDat
 	return data, nil
 }
 
-// Input returns a channel to send data into the pool
-func (wp *WorkerPool) Input() chan<- *domain.PriceData {
-	return wp.inputChan
+// Send buffers data for workers applying backpressure policy, returns false if context is done
+func (wp *WorkerPool) Send(ctx context.Context, data *domain.PriceData) bool {
+	return wp.input.Send(ctx, data)
 }
 
 // Output returns a channel to receive processed data from the pool
 func (wp *WorkerPool) Output() <-chan *domain.PriceData {
-	return wp.outputChan
+	return wp.output.Out()
 }
 
-// Close closes the input channel (signals workers to stop)
+// Close closes the input (signals workers to stop after processing buffered data).
+// Must be called by the sender only, it is safe to call it multiple times.
 func (wp *WorkerPool) Close() {
-	wp.log.Info(context.Background(), "closing worker pool")
-
-	// Check if channel is already closed
-	select {
-	case _, ok := <-wp.inputChan:
-		if !ok {
-			return
-		}
-	default:
-	}
-
-	close(wp.inputChan)
+	wp.log.Info(context.Background(), "closing worker pool", "pool_name", wp.name)
+	wp.input.Close()
 }
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_aggregated_prices_source_pair_exchange_time;
+
+ALTER TABLE aggregated_prices DROP COLUMN IF EXISTS source;
@@ -0,0 +1,3 @@
+ALTER TABLE aggregated_prices ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'live';
+
+CREATE INDEX IF NOT EXISTS idx_aggregated_prices_source_pair_exchange_time ON aggregated_prices(source, pair_name, exchange, timestamp);
@@ -0,0 +1,8 @@
+ALTER TABLE aggregated_prices
+    DROP COLUMN IF EXISTS ticks_from,
+    DROP COLUMN IF EXISTS ticks_to,
+    DROP COLUMN IF EXISTS tick_count,
+    DROP COLUMN IF EXISTS min_price_at,
+    DROP COLUMN IF EXISTS max_price_at;
+
+DROP TABLE IF EXISTS ticks;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS ticks (
+    pair_name TEXT NOT NULL,
+    exchange TEXT NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    price FLOAT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'live'
+) PARTITION BY RANGE (timestamp);
+
+-- Daily partitions are created by the tick writer, default partition keeps ticks of days without one
+CREATE TABLE IF NOT EXISTS ticks_default PARTITION OF ticks DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_ticks_pair_exchange_time ON ticks(pair_name, exchange, timestamp);
+
+-- Range of ticks every stat was computed from
+ALTER TABLE aggregated_prices
+    ADD COLUMN IF NOT EXISTS ticks_from TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS ticks_to TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS tick_count INTEGER,
+    ADD COLUMN IF NOT EXISTS min_price_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS max_price_at TIMESTAMPTZ;
@@ -0,0 +1,11 @@
+-- Runs of minutes without live data detected by coverage scan, end is exclusive
+CREATE TABLE IF NOT EXISTS data_gaps (
+    exchange TEXT NOT NULL,
+    pair_name TEXT NOT NULL,
+    gap_start TIMESTAMPTZ NOT NULL,
+    gap_end TIMESTAMPTZ NOT NULL,
+    cause TEXT NOT NULL DEFAULT 'unknown',
+    detail TEXT NOT NULL DEFAULT '',
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (exchange, pair_name, gap_start)
+);
@@ -0,0 +1,67 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		expr             string
+		minute, dow      uint64
+		domStar, dowStar bool
+	}{
+		{"* * * * *", 1<<60 - 1, 1<<8 - 1, true, true},
+		{"0,30 * * * 1-5", 1 | 1<<30, 0b111110, true, false},
+		{"*/15 * */2 * *", 1 | 1<<15 | 1<<30 | 1<<45, 1<<8 - 1, true, true},
+		{"10-20/5 * 1 * */2", 1<<10 | 1<<15 | 1<<20, 0b1010101, false, true},
+		{"5/20 * * * 7", 1<<5 | 1<<25 | 1<<45, 1 | 1<<7, true, false},
+		{"@hourly", 1, 1<<8 - 1, true, true},
+	} {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if s.minute != tc.minute || s.dow != tc.dow || s.domStar != tc.domStar || s.dowStar != tc.dowStar {
+			t.Errorf("Parse(%q) = minute %b, dow %b, stars %v %v", tc.expr, s.minute, s.dow, s.domStar, s.dowStar)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) must fail", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, time.January, 1, 10, 17, 30, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.January, 1, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.January, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 0 * * *", time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{"30 2 1 * *", time.Date(2025, time.February, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2025, time.January, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// both day fields restricted: either may match
+		{"0 0 15 * 5", time.Date(2025, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		// day of week with step counts as unrestricted, so both fields must match: the 15th on even weekday
+		{"0 0 15 * */2", time.Date(2025, time.February, 15, 0, 0, 0, 0, time.UTC)},
+		// day of month with step counts as unrestricted, so both fields must match: odd day on Friday
+		{"0 0 */2 * 5", time.Date(2025, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	} {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tc.want) {
+			t.Errorf("Next(%q) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+// WithRequestID возвращает контекст с ID запроса, который добавляется в каждую запись лога с этим контекстом
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID возвращает ID запроса из контекста, пустую строку если его нет
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// contextHandler добавляет в запись лога атрибуты из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle добавляет request_id, если он есть в контексте
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(string(requestIDKey), id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -94,6 +94,6 @@ func InitLogger(_ context.Context, logLevel string) Logger {
 		l.opts.Level = slog.LevelError
 	}
 
-	l.slog = slog.New(slog.NewJSONHandler(os.Stdout, l.opts))
+	l.slog = slog.New(&contextHandler{slog.NewJSONHandler(os.Stdout, l.opts)})
 	return &l
 }
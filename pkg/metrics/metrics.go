@@ -0,0 +1,431 @@
+// Package metrics keeps counters, gauges and histograms and writes them in Prometheus text exposition format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Default is a registry of metrics created by package functions
+var Default = NewRegistry()
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// family is a metric with all its label values
+type family interface {
+	desc() *desc
+	write(w *bufio.Writer)
+}
+
+// desc describes metric family
+type desc struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+}
+
+// Registry keeps metric families by name
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// register adds family, metric names are unique, so registering the same name twice is a programming error
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := f.desc().name
+	if _, ok := r.families[name]; ok {
+		panic(fmt.Sprintf("metrics: %s is already registered", name))
+	}
+	r.families[name] = f
+}
+
+// WriteText writes all metrics sorted by name
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	families := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(families, func(a, b family) int {
+		return strings.Compare(a.desc().name, b.desc().name)
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		d := f.desc()
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.typ)
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves metrics of registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// Handler serves metrics of default registry
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// vec keeps series of family by label values
+type vec[T any] struct {
+	d      desc
+	newFn  func() *T
+	mu     sync.RWMutex
+	series map[string]*T
+	labels map[string][]string
+}
+
+func newVec[T any](d desc, newFn func() *T) *vec[T] {
+	return &vec[T]{
+		d:      d,
+		newFn:  newFn,
+		series: make(map[string]*T),
+		labels: make(map[string][]string),
+	}
+}
+
+func (v *vec[T]) desc() *desc {
+	return &v.d
+}
+
+// with returns series of label values, creating it on first use
+func (v *vec[T]) with(labelValues ...string) *T {
+	if len(labelValues) != len(v.d.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.d.name, len(v.d.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s
+	}
+	s = v.newFn()
+	v.series[key] = s
+	v.labels[key] = slices.Clone(labelValues)
+	return s
+}
+
+// each calls fn for every series sorted by label values
+func (v *vec[T]) each(fn func(labels string, s *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	v.mu.RUnlock()
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		v.mu.RLock()
+		s, values := v.series[key], v.labels[key]
+		v.mu.RUnlock()
+		fn(formatLabels(v.d.labelNames, values), s)
+	}
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	bits atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds non-negative value
+func (c *Counter) Add(v float64) {
+	addFloat(&c.bits, v)
+}
+
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// CounterVec is a counter with labels
+type CounterVec struct {
+	*vec[Counter]
+}
+
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	v := &CounterVec{newVec(desc{name, help, typeCounter, labelNames}, func() *Counter { return new(Counter) })}
+	r.register(v)
+	return v
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labelNames...)
+}
+
+func NewCounter(name, help string) *Counter {
+	return Default.NewCounter(name, help)
+}
+
+func (v *CounterVec) With(labelValues ...string) *Counter {
+	return v.with(labelValues...)
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.each(func(labels string, c *Counter) {
+		writeSample(w, v.d.name, labels, c.Value())
+	})
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+func (g *Gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// GaugeVec is a gauge with labels
+type GaugeVec struct {
+	*vec[Gauge]
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	v := &GaugeVec{newVec(desc{name, help, typeGauge, labelNames}, func() *Gauge { return new(Gauge) })}
+	r.register(v)
+	return v
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labelNames...)
+}
+
+func NewGauge(name, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+func (v *GaugeVec) With(labelValues ...string) *Gauge {
+	return v.with(labelValues...)
+}
+
+func (v *GaugeVec) write(w *bufio.Writer) {
+	v.each(func(labels string, g *Gauge) {
+		writeSample(w, v.d.name, labels, g.Value())
+	})
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	upperBounds []float64
+	counts      []atomic.Uint64 // per bucket, last one is +Inf
+	count       atomic.Uint64
+	sumBits     atomic.Uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		upperBounds: buckets,
+		counts:      make([]atomic.Uint64, len(buckets)+1),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.upperBounds, v)
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	addFloat(&h.sumBits, v)
+}
+
+// Count returns number of observations
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+// Quantile estimates q-quantile from buckets by linear interpolation inside bucket, as Prometheus histogram_quantile does.
+// Returns NaN if there are no observations, the highest bound if quantile falls into +Inf bucket.
+func (h *Histogram) Quantile(q float64) float64 {
+	total := h.count.Load()
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := q * float64(total)
+	var cumulative uint64
+	for i := range h.counts {
+		count := h.counts[i].Load()
+		if float64(cumulative+count) < rank || count == 0 {
+			cumulative += count
+			continue
+		}
+		if i == len(h.upperBounds) {
+			return h.upperBounds[len(h.upperBounds)-1]
+		}
+
+		lower := 0.0
+		if i > 0 {
+			lower = h.upperBounds[i-1]
+		}
+		return lower + (h.upperBounds[i]-lower)*(rank-float64(cumulative))/float64(count)
+	}
+
+	return h.upperBounds[len(h.upperBounds)-1]
+}
+
+// HistogramVec is a histogram with labels
+type HistogramVec struct {
+	*vec[Histogram]
+}
+
+// NewHistogramVec creates histogram with given sorted upper bounds of buckets, +Inf bucket is implicit
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if !slices.IsSorted(buckets) || len(buckets) == 0 {
+		panic(fmt.Sprintf("metrics: buckets of %s must be sorted and non-empty", name))
+	}
+	v := &HistogramVec{newVec(desc{name, help, typeHistogram, labelNames}, func() *Histogram { return newHistogram(buckets) })}
+	r.register(v)
+	return v
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).With()
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labelNames...)
+}
+
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return Default.NewHistogram(name, help, buckets)
+}
+
+func (v *HistogramVec) With(labelValues ...string) *Histogram {
+	return v.with(labelValues...)
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.each(func(labels string, h *Histogram) {
+		var cumulative uint64
+		for i := range h.counts {
+			cumulative += h.counts[i].Load()
+			le := "+Inf"
+			if i < len(h.upperBounds) {
+				le = formatFloat(h.upperBounds[i])
+			}
+			writeSample(w, v.d.name+"_bucket", appendLabel(labels, "le", le), float64(cumulative))
+		}
+		writeSample(w, v.d.name+"_sum", labels, math.Float64frombits(h.sumBits.Load()))
+		writeSample(w, v.d.name+"_count", labels, float64(h.count.Load()))
+	})
+}
+
+// ExponentialBuckets returns count upper bounds starting at start, each factor times the previous
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+func addFloat(bits *atomic.Uint64, v float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func writeSample(w *bufio.Writer, name, labels string, v float64) {
+	w.WriteString(name)
+	if labels != "" {
+		w.WriteString("{" + labels + "}")
+	}
+	w.WriteString(" " + formatFloat(v) + "\n")
+}
+
+func formatLabels(names, values []string) string {
+	var labels string
+	for i, name := range names {
+		labels = appendLabel(labels, name, values[i])
+	}
+	return labels
+}
+
+func appendLabel(labels, name, value string) string {
+	label := name + `="` + labelValueReplacer.Replace(value) + `"`
+	if labels == "" {
+		return label
+	}
+	return labels + "," + label
+}
+
+var (
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func writeText(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestLabelAndHelpEscaping(t *testing.T) {
+	r := NewRegistry()
+	v := r.NewCounterVec("test_total", "Help with \\ and\nnew line.", "path")
+	v.With(`C:\dir "quoted"` + "\nnext").Add(2)
+
+	want := "# HELP test_total Help with \\\\ and\\nnew line.\n" +
+		"# TYPE test_total counter\n" +
+		`test_total{path="C:\\dir \"quoted\"\nnext"} 2` + "\n"
+	if got := writeText(t, r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSeriesAreSortedByName(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("b_gauge", "B.", "exchange", "kind")
+	g.With("exchange2", "ca").Set(-1.5)
+	g.With("exchange1", "client").Set(math.Inf(1))
+	r.NewCounter("a_total", "A.").Inc()
+
+	want := "# HELP a_total A.\n# TYPE a_total counter\na_total 1\n" +
+		"# HELP b_gauge B.\n# TYPE b_gauge gauge\n" +
+		`b_gauge{exchange="exchange1",kind="client"} +Inf` + "\n" +
+		`b_gauge{exchange="exchange2",kind="ca"} -1.5` + "\n"
+	if got := writeText(t, r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 0.5, 1}, "exchange").With("exchange1")
+
+	// bounds are inclusive, values above the last bound go to +Inf
+	for _, v := range []float64{0.05, 0.1, 0.3, 1, 7} {
+		h.Observe(v)
+	}
+
+	want := "# HELP latency_seconds Latency.\n# TYPE latency_seconds histogram\n" +
+		`latency_seconds_bucket{exchange="exchange1",le="0.1"} 2` + "\n" +
+		`latency_seconds_bucket{exchange="exchange1",le="0.5"} 3` + "\n" +
+		`latency_seconds_bucket{exchange="exchange1",le="1"} 4` + "\n" +
+		`latency_seconds_bucket{exchange="exchange1",le="+Inf"} 5` + "\n" +
+		`latency_seconds_sum{exchange="exchange1"} 8.45` + "\n" +
+		`latency_seconds_count{exchange="exchange1"} 5` + "\n"
+	if got := writeText(t, r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h := NewRegistry().NewHistogram("q", "Q.", []float64{1, 2, 4})
+	if !math.IsNaN(h.Quantile(0.5)) {
+		t.Fatal("quantile of empty histogram must be NaN")
+	}
+
+	// 4 observations in (0, 1], 4 in (1, 2], 2 in (2, 4]
+	for _, v := range []float64{0.5, 0.5, 1, 1, 1.5, 1.5, 2, 2, 3, 3} {
+		h.Observe(v)
+	}
+	for _, tc := range []struct{ q, want float64 }{
+		{0, 0},
+		{0.2, 0.5},    // rank 2 of 4 in the first bucket
+		{0.4, 1},      // the first bucket is exhausted at its bound
+		{0.6, 1.5},    // rank 6 is 2 of 4 in the second bucket
+		{0.9, 3},      // rank 9 is 1 of 2 in the third bucket
+		{1, 4},        // the highest bound
+		{0.99, 3.9},   // interpolated in the last bucket
+		{0.75, 1.875}, // rank 7.5 is 3.5 of 4 in the second bucket
+	} {
+		if got := h.Quantile(tc.q); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Quantile(%g) = %g, want %g", tc.q, got, tc.want)
+		}
+	}
+
+	// observations above the highest bound are reported at it
+	h.Observe(100)
+	h.Observe(100)
+	h.Observe(100)
+	if got := h.Quantile(0.99); got != 4 {
+		t.Fatalf("quantile in +Inf bucket = %g, want the highest bound", got)
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	got := ExponentialBuckets(0.001, 2, 4)
+	want := []float64{0.001, 0.002, 0.004, 0.008}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Fatalf("ExponentialBuckets = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRegistrationMistakesPanic(t *testing.T) {
+	for name, fn := range map[string]func(r *Registry){
+		"duplicate name":   func(r *Registry) { r.NewCounter("x", ""); r.NewGauge("x", "") },
+		"label count":      func(r *Registry) { r.NewCounterVec("x", "", "a", "b").With("a") },
+		"unsorted buckets": func(r *Registry) { r.NewHistogram("x", "", []float64{2, 1}) },
+		"no buckets":       func(r *Registry) { r.NewHistogram("x", "", nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			fn(NewRegistry())
+		})
+	}
+}
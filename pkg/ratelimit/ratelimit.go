@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: bucket holds up to Burst tokens and is refilled with Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is an outcome of taking tokens from the bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // tokens left in the bucket
+	Reset      time.Duration // time until bucket is full again
+	RetryAfter time.Duration // time until request of the same cost is allowed, zero if allowed
+}
+
+// Store keeps buckets by key
+type Store interface {
+	Take(ctx context.Context, key string, cost int, limit Limit) (Result, error)
+}
+
+// Take refills bucket having tokens at last time and takes cost tokens from it if there are enough.
+// Returns tokens left in the bucket and result.
+func Take(tokens float64, last, now time.Time, cost int, limit Limit) (float64, Result) {
+	elapsed := max(now.Sub(last).Seconds(), 0)
+	tokens = min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+
+	allowed := tokens >= float64(cost)
+	if allowed {
+		tokens -= float64(cost)
+	}
+
+	return tokens, NewResult(allowed, tokens, cost, limit)
+}
+
+// NewResult returns result of taking cost tokens, tokens is what left in the bucket
+func NewResult(allowed bool, tokens float64, cost int, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((float64(cost) - tokens) / limit.Rate)
+	}
+
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// Memory is an in-process store, limits are not shared between instances
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // time when bucket is full again and can be forgotten
+}
+
+// sweepInterval defines how often full buckets are removed
+const sweepInterval = time.Minute
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *Memory) Take(_ context.Context, key string, cost int, limit Limit) (Result, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		// new bucket is full
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		m.buckets[key] = b
+	}
+
+	tokens, result := Take(b.tokens, b.last, now, cost, limit)
+	b.tokens = tokens
+	b.last = now
+	b.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// sweep removes buckets which are full, they are the same as new ones. Must be called with mu held.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if now.After(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+// Package websocket implements the subset of RFC 6455 needed for market data feeds:
+// client dialing, server upgrade for stand-in servers, text and binary messages,
+// fragmentation, ping/pong and close. Extensions and subprotocols are not supported.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Opcodes of frames
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// Close codes
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseTooBig        = 1009
+)
+
+// MaxMessageSize limits size of received message, bigger messages close the connection
+const MaxMessageSize = 16 << 20
+
+// DefaultWriteTimeout limits writing of every frame, so a peer that stops reading does not block writers forever
+const DefaultWriteTimeout = 10 * time.Second
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	ErrProtocol     = errors.New("websocket: protocol error")
+	ErrTooBig       = errors.New("websocket: message too big")
+)
+
+// CloseError is returned by ReadMessage when peer closed the connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed by peer with code %d %s", e.Code, e.Reason)
+}
+
+// Conn is a websocket connection. ReadMessage must be called from one goroutine,
+// writes may be called concurrently with it and with each other.
+type Conn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool // frames sent by client are masked
+
+	writeMu      sync.Mutex
+	writeTimeout time.Duration
+	closed       bool // close frame was sent
+
+	onPing func(payload []byte)
+	onPong func(payload []byte)
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, client bool) *Conn {
+	return &Conn{conn: conn, br: br, client: client, writeTimeout: DefaultWriteTimeout}
+}
+
+// Dialer dials websocket servers, zero value dials without extra headers using default TLS config
+type Dialer struct {
+	TLSConfig        *tls.Config
+	Header           http.Header
+	HandshakeTimeout time.Duration
+}
+
+// Dial connects to ws:// or wss:// URL and performs opening handshake
+func (d *Dialer) Dial(ctx context.Context, rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadHandshake, err)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		switch u.Scheme {
+		case "ws":
+			host = net.JoinHostPort(u.Hostname(), "80")
+		case "wss":
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+	}
+
+	if d.HandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.HandshakeTimeout)
+		defer cancel()
+	}
+
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	case "wss":
+		cfg := d.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" {
+			cfg = cfg.Clone()
+			cfg.ServerName = u.Hostname()
+		}
+		conn, err = (&tls.Dialer{Config: cfg}).DialContext(ctx, "tcp", host)
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrBadHandshake, u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := d.handshake(ctx, conn, u)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+func (d *Dialer) handshake(ctx context.Context, conn net.Conn, u *url.URL) (*Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	// closing connection interrupts handshake when context is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Header:     make(http.Header),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	for name, values := range d.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadHandshake, err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadHandshake, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("%w: unexpected status %s", ErrBadHandshake, resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, fmt.Errorf("%w: invalid upgrade response", ErrBadHandshake)
+	}
+
+	return newConn(conn, br, true), nil
+}
+
+// Upgrade completes opening handshake of websocket request on server side
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadHandshake, err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %w", ErrBadHandshake, err)
+	}
+
+	return newConn(conn, rw.Reader, false), nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// SetPingHandler sets function called with payload of received ping, pong is sent before it is called
+func (c *Conn) SetPingHandler(h func(payload []byte)) {
+	c.onPing = h
+}
+
+// SetPongHandler sets function called with payload of received pong
+func (c *Conn) SetPongHandler(h func(payload []byte)) {
+	c.onPong = h
+}
+
+// SetWriteTimeout sets time limit of writing every frame, zero disables it
+func (c *Conn) SetWriteTimeout(d time.Duration) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.writeTimeout = d
+}
+
+// SetReadDeadline sets deadline of reading, ReadMessage fails with timeout error after it
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// ReadMessage returns the next text or binary message. Control frames are handled while reading:
+// pings are answered, pongs are passed to pong handler, close is answered and returned as *CloseError.
+func (c *Conn) ReadMessage() (opcode int, data []byte, err error) {
+	opcode = -1
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case OpPing:
+			if err := c.WriteMessage(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			if c.onPing != nil {
+				c.onPing(payload)
+			}
+			continue
+		case OpPong:
+			if c.onPong != nil {
+				c.onPong(payload)
+			}
+			continue
+		case OpClose:
+			closeErr := &CloseError{Code: CloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.WriteClose(closeErr.Code, "")
+			return 0, nil, closeErr
+		case OpText, OpBinary:
+			if opcode != -1 {
+				return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+			}
+			opcode = op
+		case OpContinuation:
+			if opcode == -1 {
+				return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+		}
+
+		if len(data)+len(payload) > MaxMessageSize {
+			return 0, nil, c.fail(CloseTooBig, ErrTooBig)
+		}
+		data = append(data, payload...)
+		if fin {
+			return opcode, data, nil
+		}
+	}
+}
+
+// readFrame reads single frame and unmasks its payload
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	if header[0]&0x70 != 0 || masked == c.client {
+		return false, 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+	}
+	if opcode >= OpClose && (!fin || length > 125) {
+		return false, 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > MaxMessageSize {
+		return false, 0, nil, c.fail(CloseTooBig, ErrTooBig)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends data as single frame of given opcode
+func (c *Conn) WriteMessage(opcode int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+	if opcode == OpClose {
+		c.closed = true
+	}
+
+	frame := make([]byte, 0, len(data)+14)
+	frame = append(frame, 0x80|byte(opcode))
+
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch {
+	case len(data) <= 125:
+		frame = append(frame, maskBit|byte(len(data)))
+	case len(data) <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(data)))
+	}
+
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, data...)
+		for i := range data {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, data...)
+	}
+
+	if c.writeTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Ping sends ping, answer is passed to pong handler
+func (c *Conn) Ping(payload []byte) error {
+	return c.WriteMessage(OpPing, payload)
+}
+
+// WriteClose sends close frame, connection should be closed after peer answers or reading fails
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	return c.WriteMessage(OpClose, payload)
+}
+
+// fail sends close frame with code and returns err
+func (c *Conn) fail(code int, err error) error {
+	c.WriteClose(code, "")
+	return err
+}
+
+// Close closes underlying connection without closing handshake
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,328 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type frame struct {
+	fin     bool
+	opcode  int
+	payload []byte
+}
+
+// pair returns connection under test and raw end of its peer
+func pair(t *testing.T, client bool) (*Conn, net.Conn) {
+	t.Helper()
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return newConn(a, bufio.NewReader(a), client), b
+}
+
+// rawFrame encodes frame with payload masked by fixed key if masked is set
+func rawFrame(fin bool, opcode int, masked bool, payload []byte) []byte {
+	b := byte(opcode)
+	if fin {
+		b |= 0x80
+	}
+	buf := []byte{b}
+
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		buf = append(buf, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(payload)))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(len(payload)))
+	}
+
+	if !masked {
+		return append(buf, payload...)
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	buf = append(buf, mask...)
+	for i, c := range payload {
+		buf = append(buf, c^mask[i%4])
+	}
+	return buf
+}
+
+// exchange writes raw frames to peer end and returns frames sent back, read by the other side until pipe is closed
+func exchange(peer net.Conn, client bool, frames ...[]byte) <-chan frame {
+	go func() {
+		for _, f := range frames {
+			if _, err := peer.Write(f); err != nil {
+				return
+			}
+		}
+	}()
+
+	replies := make(chan frame, 16)
+	go func() {
+		defer close(replies)
+		reader := newConn(peer, bufio.NewReader(peer), !client)
+		for {
+			fin, opcode, payload, err := reader.readFrame()
+			if err != nil {
+				return
+			}
+			replies <- frame{fin, opcode, payload}
+		}
+	}()
+	return replies
+}
+
+func reply(t *testing.T, replies <-chan frame) frame {
+	t.Helper()
+	select {
+	case f, ok := <-replies:
+		if !ok {
+			t.Fatal("no frame sent back")
+		}
+		return f
+	case <-time.After(5 * time.Second):
+		t.Fatal("no frame sent back")
+	}
+	return frame{}
+}
+
+func closeCode(f frame) int {
+	if f.opcode != OpClose || len(f.payload) < 2 {
+		return -1
+	}
+	return int(binary.BigEndian.Uint16(f.payload))
+}
+
+func TestFragmentedMessage(t *testing.T) {
+	conn, peer := pair(t, false)
+	var pinged []byte
+	conn.SetPingHandler(func(payload []byte) { pinged = payload })
+
+	// control frame may be interleaved with fragments of a message
+	replies := exchange(peer, false,
+		rawFrame(false, OpText, true, []byte("hel")),
+		rawFrame(true, OpPing, true, []byte("beat")),
+		rawFrame(false, OpContinuation, true, []byte("lo ")),
+		rawFrame(true, OpContinuation, true, []byte("world")),
+		rawFrame(true, OpBinary, true, []byte{0, 1, 2}),
+	)
+
+	opcode, data, err := conn.ReadMessage()
+	if err != nil || opcode != OpText || string(data) != "hello world" {
+		t.Fatalf("got opcode %d %q %v, want text message", opcode, data, err)
+	}
+	if f := reply(t, replies); f.opcode != OpPong || string(f.payload) != "beat" || string(pinged) != "beat" {
+		t.Fatalf("ping must be answered with its payload, got %d %q, handler got %q", f.opcode, f.payload, pinged)
+	}
+
+	opcode, data, err = conn.ReadMessage()
+	if err != nil || opcode != OpBinary || !bytes.Equal(data, []byte{0, 1, 2}) {
+		t.Fatalf("got opcode %d %v %v, want binary message", opcode, data, err)
+	}
+}
+
+func TestFragmentationErrors(t *testing.T) {
+	for name, frames := range map[string][][]byte{
+		"continuation without message": {rawFrame(true, OpContinuation, true, []byte("x"))},
+		"message inside message":       {rawFrame(false, OpText, true, []byte("x")), rawFrame(true, OpText, true, []byte("y"))},
+		"fragmented control frame":     {rawFrame(false, OpPing, true, nil)},
+		"control frame too long":       {rawFrame(true, OpPing, true, make([]byte, 126))},
+		"reserved opcode":              {rawFrame(true, 0x3, true, nil)},
+		"reserved bits":                {{0x80 | 0x40 | OpText, 0x80, 0, 0, 0, 0}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conn, peer := pair(t, false)
+			replies := exchange(peer, false, frames...)
+
+			if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrProtocol) {
+				t.Fatalf("got %v, want protocol error", err)
+			}
+			if code := closeCode(reply(t, replies)); code != CloseProtocolError {
+				t.Fatalf("close code %d, want %d", code, CloseProtocolError)
+			}
+		})
+	}
+}
+
+func TestControlFrames(t *testing.T) {
+	conn, peer := pair(t, true)
+	var ponged []byte
+	conn.SetPongHandler(func(payload []byte) { ponged = payload })
+
+	closing := binary.BigEndian.AppendUint16(nil, CloseGoingAway)
+	replies := exchange(peer, true,
+		rawFrame(true, OpPong, false, []byte("late")),
+		rawFrame(true, OpClose, false, append(closing, "restart"...)),
+	)
+
+	_, _, err := conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseGoingAway || closeErr.Reason != "restart" {
+		t.Fatalf("got %v, want close error with code and reason", err)
+	}
+	if string(ponged) != "late" {
+		t.Fatalf("pong handler got %q", ponged)
+	}
+	if code := closeCode(reply(t, replies)); code != CloseGoingAway {
+		t.Fatalf("close must be answered with its code, got %d", code)
+	}
+	if err := conn.WriteMessage(OpText, []byte("x")); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("write after close got %v", err)
+	}
+}
+
+func TestMasking(t *testing.T) {
+	// client frames are masked on the wire and unmasked by server
+	client, peer := pair(t, true)
+	wire := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := peer.Read(buf)
+		wire <- buf[:n]
+	}()
+	if err := client.WriteMessage(OpText, []byte("price")); err != nil {
+		t.Fatal(err)
+	}
+	raw := <-wire
+	if raw[1]&0x80 == 0 || bytes.Contains(raw, []byte("price")) {
+		t.Fatalf("client frame %x must be masked", raw)
+	}
+	server := newConn(peer, bufio.NewReader(bytes.NewReader(raw)), false)
+	if _, _, payload, err := server.readFrame(); err != nil || string(payload) != "price" {
+		t.Fatalf("server read %q %v", payload, err)
+	}
+
+	// server must reject unmasked client frames, client must reject masked server frames
+	for _, client := range []bool{false, true} {
+		conn, peer := pair(t, client)
+		// peer of client masks and peer of server does not, both are wrong
+		replies := exchange(peer, client, rawFrame(true, OpText, client, []byte("x")))
+		if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrProtocol) {
+			t.Fatalf("client %v: got %v, want protocol error", client, err)
+		}
+		if code := closeCode(reply(t, replies)); code != CloseProtocolError {
+			t.Fatalf("client %v: close code %d", client, code)
+		}
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	// peer never reads, write fails after timeout and does not hold writes that follow
+	conn, _ := pair(t, true)
+	conn.SetWriteTimeout(50 * time.Millisecond)
+
+	for range 2 {
+		started := time.Now()
+		err := conn.Ping(nil)
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() || time.Since(started) > 2*time.Second {
+			t.Fatalf("write to peer not reading got %v after %v, want timeout", err, time.Since(started))
+		}
+	}
+}
+
+func TestMessageSizeLimit(t *testing.T) {
+	for name, frames := range map[string][][]byte{
+		// only the header is sent, length is checked before payload is read
+		"frame": {{0x80 | OpBinary, 0x80 | 127, 0, 0, 0, 0, 1, 0, 0, 1}},
+		"fragments": {
+			rawFrame(false, OpBinary, true, make([]byte, MaxMessageSize/2+1)),
+			rawFrame(true, OpContinuation, true, make([]byte, MaxMessageSize/2+1)),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conn, peer := pair(t, false)
+			replies := exchange(peer, false, frames...)
+
+			if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrTooBig) {
+				t.Fatalf("got %v, want too big error", err)
+			}
+			if code := closeCode(reply(t, replies)); code != CloseTooBig {
+				t.Fatalf("close code %d, want %d", code, CloseTooBig)
+			}
+		})
+	}
+}
+
+func TestDialAndUpgrade(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			opcode, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(opcode, bytes.ToUpper(data))
+		}
+	}))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, err := (&Dialer{HandshakeTimeout: time.Second}).Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// large message uses extended length
+	message := strings.Repeat("tick", 20000)
+	if err := conn.WriteMessage(OpText, []byte(message)); err != nil {
+		t.Fatal(err)
+	}
+	if opcode, data, err := conn.ReadMessage(); err != nil || opcode != OpText || string(data) != strings.ToUpper(message) {
+		t.Fatalf("echo got opcode %d, %d bytes, %v", opcode, len(data), err)
+	}
+
+	// plain HTTP server does not upgrade
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+	if _, err := (&Dialer{}).Dial(context.Background(), "ws"+strings.TrimPrefix(plain.URL, "http")); !errors.Is(err, ErrBadHandshake) {
+		t.Fatalf("got %v, want bad handshake", err)
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// accepts connection and never answers
+		conn, err := listener.Accept()
+		if err == nil {
+			<-done
+			conn.Close()
+		}
+	}()
+
+	started := time.Now()
+	_, err = (&Dialer{HandshakeTimeout: 50 * time.Millisecond}).Dial(context.Background(), "ws://"+listener.Addr().String())
+	if err == nil || time.Since(started) > 2*time.Second {
+		t.Fatalf("handshake must time out, got %v after %v", err, time.Since(started))
+	}
+}